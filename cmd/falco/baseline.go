@@ -0,0 +1,158 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter"
+)
+
+// Baseline mode which records current findings to the baseline file
+const baselineModeWrite = "write"
+
+const baselineVersion = 1
+
+// BaselineFinding is the recorded finding in the baseline file.
+// The finding is identified by the rule, file and fingerprint of the code
+// instead of line number so that the baseline survives unrelated edits
+type BaselineFinding struct {
+	Rule        string `json:"rule"`
+	File        string `json:"file"`
+	Fingerprint string `json:"fingerprint"`
+	Count       int    `json:"count"`
+}
+
+type BaselineFile struct {
+	Version  int                `json:"version"`
+	Findings []*BaselineFinding `json:"findings"`
+}
+
+type baselineKey struct {
+	Rule        string
+	File        string
+	Fingerprint string
+}
+
+// Baseline holds the number of accepted findings for each key
+type Baseline struct {
+	path     string
+	findings map[baselineKey]int
+}
+
+func newBaseline(path string) *Baseline {
+	return &Baseline{
+		path:     path,
+		findings: make(map[baselineKey]int),
+	}
+}
+
+// loadBaseline reads the baseline file. Empty baseline is returned if the file does not exist
+func loadBaseline(path string) (*Baseline, error) {
+	b := newBaseline(path)
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+
+	var file BaselineFile
+	if err := json.Unmarshal(buf, &file); err != nil {
+		return nil, errors.WithStack(fmt.Errorf("Failed to parse baseline file %s: %w", path, err))
+	}
+	if file.Version != baselineVersion {
+		return nil, errors.WithStack(fmt.Errorf("Unsupported baseline file version %d", file.Version))
+	}
+	for _, f := range file.Findings {
+		b.findings[baselineKey{Rule: f.Rule, File: f.File, Fingerprint: f.Fingerprint}] += f.Count
+	}
+	return b, nil
+}
+
+// key makes the baseline key of the lint error.
+// The fingerprint is the hash of the whitespace normalized source line which the error is reported on
+func (b *Baseline) key(lx *lexer.Lexer, file string, le *linter.LintError) baselineKey {
+	var code string
+	if lx != nil {
+		if line, ok := lx.GetLine(le.Token.Line); ok {
+			code = strings.Join(strings.Fields(line), " ")
+		}
+	}
+	sum := sha256.Sum256([]byte(code))
+
+	// File path is recorded as relative path from the baseline file in order to share it across environments
+	if filepath.IsAbs(file) {
+		if abs, err := filepath.Abs(b.path); err == nil {
+			if rel, err := filepath.Rel(filepath.Dir(abs), file); err == nil {
+				file = rel
+			}
+		}
+	}
+
+	return baselineKey{
+		Rule:        string(le.Rule),
+		File:        filepath.ToSlash(file),
+		Fingerprint: fmt.Sprintf("%x", sum[:8]),
+	}
+}
+
+// Add records the finding
+func (b *Baseline) Add(key baselineKey) {
+	b.findings[key]++
+}
+
+// Suppress consumes the recorded finding and returns true if the finding exists in the baseline
+func (b *Baseline) Suppress(key baselineKey) bool {
+	if b.findings[key] == 0 {
+		return false
+	}
+	b.findings[key]--
+	return true
+}
+
+// Write writes recorded findings to the baseline file in stable order
+func (b *Baseline) Write() error {
+	file := BaselineFile{
+		Version:  baselineVersion,
+		Findings: []*BaselineFinding{},
+	}
+	for key, count := range b.findings {
+		file.Findings = append(file.Findings, &BaselineFinding{
+			Rule:        key.Rule,
+			File:        key.File,
+			Fingerprint: key.Fingerprint,
+			Count:       count,
+		})
+	}
+	slices.SortFunc(file.Findings, func(a, b *BaselineFinding) int {
+		return cmp.Or(
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Rule, b.Rule),
+			cmp.Compare(a.Fingerprint, b.Fingerprint),
+		)
+	})
+
+	buf, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.WriteFile(b.path, append(buf, '\n'), 0o644))
+}
+
+// Len returns the number of recorded findings
+func (b *Baseline) Len() int {
+	var n int
+	for _, count := range b.findings {
+		n += count
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	baseline := filepath.Join(dir, ".falco-baseline.json")
+
+	lint := func(t *testing.T, vcl, mode string) *RunnerResult {
+		if err := os.WriteFile(main, []byte(vcl), 0o644); err != nil {
+			t.Fatalf("Failed to write VCL: %s", err)
+		}
+		c := &config.Config{
+			Linter: &config.LinterConfig{
+				Baseline:     mode,
+				BaselineFile: baseline,
+			},
+		}
+		resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+		if err != nil {
+			t.Fatalf("Unexpected runner creation error: %s", err)
+		}
+		ret, err := NewRunner(c, nil).Run(resolvers[0])
+		if err != nil {
+			t.Fatalf("Unexpected Run() error: %s", err)
+		}
+		return ret
+	}
+
+	ret := lint(t, `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "a" + 1;
+  set req.http.Bar = "b" + 1;
+  return (lookup);
+}`, baselineModeWrite)
+	if ret.Errors != 0 || ret.Baselined != 2 {
+		t.Fatalf("Expected 2 findings are recorded, got errors=%d, baselined=%d", ret.Errors, ret.Baselined)
+	}
+	if _, err := os.Stat(baseline); err != nil {
+		t.Fatalf("Baseline file must be written: %s", err)
+	}
+
+	tests := []struct {
+		name      string
+		vcl       string
+		errors    int
+		baselined int
+	}{
+		{
+			name: "unchanged",
+			vcl: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "a" + 1;
+  set req.http.Bar = "b" + 1;
+  return (lookup);
+}`,
+			baselined: 2,
+		},
+		{
+			name: "findings are moved by unrelated edits",
+			vcl: `# Legacy VCL
+
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Baz = "c";
+  set req.http.Bar   =   "b" + 1;
+  set req.http.Foo = "a" + 1;
+  return (lookup);
+}`,
+			baselined: 2,
+		},
+		{
+			name: "new findings are reported",
+			vcl: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "a" + 1;
+  set req.http.Bar = "b" + 1;
+  set req.http.Baz = "c" + 1;
+  return (lookup);
+}`,
+			errors:    1,
+			baselined: 2,
+		},
+		{
+			name: "duplicated code is reported over the recorded count",
+			vcl: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "a" + 1;
+  set req.http.Foo = "a" + 1;
+  return (lookup);
+}`,
+			errors:    1,
+			baselined: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret := lint(t, tt.vcl, "")
+			if ret.Errors != tt.errors {
+				t.Errorf("Errors expects %d, got %d", tt.errors, ret.Errors)
+			}
+			if ret.Baselined != tt.baselined {
+				t.Errorf("Baselined expects %d, got %d", tt.baselined, ret.Baselined)
+			}
+		})
+	}
+}
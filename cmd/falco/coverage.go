@@ -0,0 +1,452 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+)
+
+// Coverage report output formats
+const (
+	coverageFormatJSON      = "json"
+	coverageFormatLCOV      = "lcov"
+	coverageFormatCobertura = "cobertura"
+	coverageFormatHTML      = "html"
+)
+
+type coverageWriter func(w io.Writer, files []*shared.CoverageFile) error
+
+var coverageWriters = map[string]coverageWriter{
+	coverageFormatJSON:      writeCoverageJSON,
+	coverageFormatLCOV:      writeCoverageLCOV,
+	coverageFormatCobertura: writeCoverageCobertura,
+	coverageFormatHTML:      writeCoverageHTML,
+}
+
+// coverageFormatFromFile guesses the coverage report format from the file extension
+func coverageFormatFromFile(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".info", ".lcov":
+		return coverageFormatLCOV
+	case ".xml":
+		return coverageFormatCobertura
+	case ".html", ".htm":
+		return coverageFormatHTML
+	default:
+		return coverageFormatJSON
+	}
+}
+
+// writeCoverageReport writes the coverage report to the file in the format
+func writeCoverageReport(file, format string, c *shared.CoverageFactory) error {
+	if format == "" {
+		format = coverageFormatFromFile(file)
+	}
+	write, ok := coverageWriters[format]
+	if !ok {
+		return errors.New(fmt.Sprintf("Unsupported coverage format: %s", format))
+	}
+
+	fp, err := os.Create(file)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fp.Close()
+
+	files := c.Files()
+	for i := range files {
+		files[i].File = coverageFileName(files[i].File)
+	}
+	return write(fp, files)
+}
+
+// coverageFileName returns the relative path of the VCL file.
+// Remote snippets like "snippet::xxx" are kept as it is
+func coverageFileName(file string) string {
+	if !strings.EqualFold(filepath.Ext(file), ".vcl") {
+		return file
+	}
+	return relativePath(file)
+}
+
+// coverageSummary counts covered lines, branches and subroutines of the file
+type coverageSummary struct {
+	LinesFound, LinesHit             int
+	BranchesFound, BranchesHit       int
+	SubroutinesFound, SubroutinesHit int
+}
+
+func summarizeCoverage(files ...*shared.CoverageFile) coverageSummary {
+	var s coverageSummary
+	for _, f := range files {
+		for _, l := range f.Lines {
+			s.LinesFound++
+			if l.Hits > 0 {
+				s.LinesHit++
+			}
+		}
+		for _, b := range f.Branches {
+			s.BranchesFound++
+			if b.Hits > 0 {
+				s.BranchesHit++
+			}
+		}
+		for _, sub := range f.Subroutines {
+			s.SubroutinesFound++
+			if sub.Hits > 0 {
+				s.SubroutinesHit++
+			}
+		}
+	}
+	return s
+}
+
+// coverageRate returns the ratio in 0-1, the rate is 1 when nothing is found
+func coverageRate(hit, found int) float64 {
+	if found == 0 {
+		return 1
+	}
+	return float64(hit) / float64(found)
+}
+
+func writeCoverageJSON(w io.Writer, files []*shared.CoverageFile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(struct {
+		Files []*shared.CoverageFile `json:"files"`
+	}{
+		Files: files,
+	}))
+}
+
+// LCOV tracefile format
+// https://github.com/linux-test-project/lcov/blob/master/man/geninfo.1
+func writeCoverageLCOV(w io.Writer, files []*shared.CoverageFile) error {
+	var b strings.Builder
+	for _, f := range files {
+		s := summarizeCoverage(f)
+		fmt.Fprintf(&b, "TN:\nSF:%s\n", f.File)
+		for _, sub := range f.Subroutines {
+			fmt.Fprintf(&b, "FN:%d,%s\n", sub.Line, sub.Name)
+		}
+		for _, sub := range f.Subroutines {
+			fmt.Fprintf(&b, "FNDA:%d,%s\n", sub.Hits, sub.Name)
+		}
+		fmt.Fprintf(&b, "FNF:%d\nFNH:%d\n", s.SubroutinesFound, s.SubroutinesHit)
+		for _, br := range f.Branches {
+			fmt.Fprintf(&b, "BRDA:%d,%d,%d,%d\n", br.Line, br.Block, br.Branch, br.Hits)
+		}
+		fmt.Fprintf(&b, "BRF:%d\nBRH:%d\n", s.BranchesFound, s.BranchesHit)
+		for _, l := range f.Lines {
+			fmt.Fprintf(&b, "DA:%d,%d\n", l.Line, l.Hits)
+		}
+		fmt.Fprintf(&b, "LF:%d\nLH:%d\nend_of_record\n", s.LinesFound, s.LinesHit)
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.WithStack(err)
+}
+
+// Cobertura XML structures
+// https://github.com/cobertura/web/blob/master/htdocs/xml/coverage-04.dtd
+type coberturaCoverage struct {
+	XMLName         xml.Name            `xml:"coverage"`
+	LineRate        string              `xml:"line-rate,attr"`
+	BranchRate      string              `xml:"branch-rate,attr"`
+	LinesCovered    int                 `xml:"lines-covered,attr"`
+	LinesValid      int                 `xml:"lines-valid,attr"`
+	BranchesCovered int                 `xml:"branches-covered,attr"`
+	BranchesValid   int                 `xml:"branches-valid,attr"`
+	Complexity      int                 `xml:"complexity,attr"`
+	Version         string              `xml:"version,attr"`
+	Timestamp       int64               `xml:"timestamp,attr"`
+	Sources         []string            `xml:"sources>source"`
+	Packages        []*coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string            `xml:"name,attr"`
+	LineRate   string            `xml:"line-rate,attr"`
+	BranchRate string            `xml:"branch-rate,attr"`
+	Complexity int               `xml:"complexity,attr"`
+	Classes    []*coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string             `xml:"name,attr"`
+	Filename   string             `xml:"filename,attr"`
+	LineRate   string             `xml:"line-rate,attr"`
+	BranchRate string             `xml:"branch-rate,attr"`
+	Complexity int                `xml:"complexity,attr"`
+	Methods    []*coberturaMethod `xml:"methods>method"`
+	Lines      []*coberturaLine   `xml:"lines>line"`
+}
+
+type coberturaMethod struct {
+	Name       string           `xml:"name,attr"`
+	Signature  string           `xml:"signature,attr"`
+	LineRate   string           `xml:"line-rate,attr"`
+	BranchRate string           `xml:"branch-rate,attr"`
+	Lines      []*coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number            int    `xml:"number,attr"`
+	Hits              uint64 `xml:"hits,attr"`
+	Branch            bool   `xml:"branch,attr"`
+	ConditionCoverage string `xml:"condition-coverage,attr,omitempty"`
+}
+
+func coberturaRate(hit, found int) string {
+	return fmt.Sprintf("%.4f", coverageRate(hit, found))
+}
+
+func writeCoverageCobertura(w io.Writer, files []*shared.CoverageFile) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	s := summarizeCoverage(files...)
+	report := &coberturaCoverage{
+		LineRate:        coberturaRate(s.LinesHit, s.LinesFound),
+		BranchRate:      coberturaRate(s.BranchesHit, s.BranchesFound),
+		LinesCovered:    s.LinesHit,
+		LinesValid:      s.LinesFound,
+		BranchesCovered: s.BranchesHit,
+		BranchesValid:   s.BranchesFound,
+		Version:         version,
+		Timestamp:       time.Now().UnixMilli(),
+		Sources:         []string{cwd},
+	}
+
+	// Files are grouped into the package of the directory
+	packages := make(map[string][]*shared.CoverageFile)
+	for _, f := range files {
+		dir := path.Dir(f.File)
+		packages[dir] = append(packages[dir], f)
+	}
+	for _, dir := range slices.Sorted(maps.Keys(packages)) {
+		pkgFiles := packages[dir]
+		ps := summarizeCoverage(pkgFiles...)
+		pkg := &coberturaPackage{
+			Name:       dir,
+			LineRate:   coberturaRate(ps.LinesHit, ps.LinesFound),
+			BranchRate: coberturaRate(ps.BranchesHit, ps.BranchesFound),
+		}
+		for _, f := range pkgFiles {
+			pkg.Classes = append(pkg.Classes, coberturaFileClass(f))
+		}
+		report.Packages = append(report.Packages, pkg)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+func coberturaFileClass(f *shared.CoverageFile) *coberturaClass {
+	s := summarizeCoverage(f)
+	class := &coberturaClass{
+		Name:       f.File,
+		Filename:   f.File,
+		LineRate:   coberturaRate(s.LinesHit, s.LinesFound),
+		BranchRate: coberturaRate(s.BranchesHit, s.BranchesFound),
+	}
+
+	// Branches are reported on the line of the branching node
+	type condition struct{ hit, found int }
+	conditions := make(map[int]*condition)
+	for _, b := range f.Branches {
+		if _, ok := conditions[b.Line]; !ok {
+			conditions[b.Line] = &condition{}
+		}
+		conditions[b.Line].found++
+		if b.Hits > 0 {
+			conditions[b.Line].hit++
+		}
+	}
+	for _, l := range f.Lines {
+		line := &coberturaLine{Number: l.Line, Hits: l.Hits}
+		if c, ok := conditions[l.Line]; ok {
+			line.Branch = true
+			line.ConditionCoverage = fmt.Sprintf("%d%% (%d/%d)", c.hit*100/c.found, c.hit, c.found)
+		}
+		class.Lines = append(class.Lines, line)
+	}
+	for _, sub := range f.Subroutines {
+		rate := coberturaRate(0, 1)
+		if sub.Hits > 0 {
+			rate = coberturaRate(1, 1)
+		}
+		class.Methods = append(class.Methods, &coberturaMethod{
+			Name:       sub.Name,
+			LineRate:   rate,
+			BranchRate: rate,
+			Lines:      []*coberturaLine{{Number: sub.Line, Hits: sub.Hits}},
+		})
+	}
+	return class
+}
+
+// HTML report view models
+type htmlCoverageReport struct {
+	Summary htmlCoverageSummary
+	Files   []*htmlCoverageFile
+}
+
+type htmlCoverageSummary struct {
+	Lines       string
+	Branches    string
+	Subroutines string
+}
+
+type htmlCoverageFile struct {
+	ID      string
+	Name    string
+	Summary htmlCoverageSummary
+	Lines   []*htmlCoverageLine
+	NoSrc   bool
+}
+
+type htmlCoverageLine struct {
+	Number   int
+	Code     string
+	Hits     string
+	Class    string // covered, uncovered, partial or empty
+	Branches string
+}
+
+func htmlCoverageSummaryOf(files ...*shared.CoverageFile) htmlCoverageSummary {
+	s := summarizeCoverage(files...)
+	percent := func(hit, found int) string {
+		return fmt.Sprintf("%s%% (%d/%d)", printScore(coverageRate(hit, found)*100), hit, found)
+	}
+	return htmlCoverageSummary{
+		Lines:       percent(s.LinesHit, s.LinesFound),
+		Branches:    percent(s.BranchesHit, s.BranchesFound),
+		Subroutines: percent(s.SubroutinesHit, s.SubroutinesFound),
+	}
+}
+
+// writeCoverageHTML writes the self-contained HTML report which annotates VCL sources.
+// Sources are read from the file, the source is not shown for remote snippets
+func writeCoverageHTML(w io.Writer, files []*shared.CoverageFile) error {
+	report := &htmlCoverageReport{
+		Summary: htmlCoverageSummaryOf(files...),
+	}
+	for i, f := range files {
+		hf := &htmlCoverageFile{
+			ID:      fmt.Sprintf("file-%d", i),
+			Name:    f.File,
+			Summary: htmlCoverageSummaryOf(f),
+		}
+
+		hits := make(map[int]uint64)
+		for _, l := range f.Lines {
+			hits[l.Line] = l.Hits
+		}
+		branches := make(map[int][]string)
+		partial := make(map[int]bool)
+		for _, b := range f.Branches {
+			mark := "+"
+			if b.Hits == 0 {
+				mark = "-"
+				partial[b.Line] = true
+			}
+			branches[b.Line] = append(branches[b.Line], mark)
+		}
+
+		var source []string
+		if buf, err := os.ReadFile(f.File); err == nil {
+			source = strings.Split(strings.ReplaceAll(string(buf), "\r\n", "\n"), "\n")
+		} else {
+			hf.NoSrc = true
+			for _, l := range f.Lines {
+				for len(source) < l.Line {
+					source = append(source, "")
+				}
+			}
+		}
+
+		for n, code := range source {
+			line := &htmlCoverageLine{Number: n + 1, Code: code}
+			if v, ok := hits[line.Number]; ok {
+				line.Hits = fmt.Sprintf("%dx", v)
+				switch {
+				case v == 0:
+					line.Class = "uncovered"
+				case partial[line.Number]:
+					line.Class = "partial"
+				default:
+					line.Class = "covered"
+				}
+			}
+			if b, ok := branches[line.Number]; ok {
+				line.Branches = "[" + strings.Join(b, " ") + "]"
+			}
+			hf.Lines = append(hf.Lines, line)
+		}
+		report.Files = append(report.Files, hf)
+	}
+	return errors.WithStack(coverageHTMLTemplate.Execute(w, report))
+}
+
+var coverageHTMLTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>falco coverage report</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; margin: 24px; color: #24292f; }
+table { border-collapse: collapse; }
+.summary th, .summary td { border: 1px solid #d0d7de; padding: 4px 12px; text-align: left; }
+.source { width: 100%; font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 12px; }
+.source td { padding: 0 8px; white-space: pre; vertical-align: top; }
+.source td.number, .source td.hits, .source td.branches { color: #57606a; text-align: right; user-select: none; }
+.covered { background: #dafbe1; }
+.uncovered { background: #ffebe9; }
+.partial { background: #fff8c5; }
+</style>
+</head>
+<body>
+<h1>falco coverage report</h1>
+<table class="summary">
+<tr><th>File</th><th>Lines</th><th>Branches</th><th>Subroutines</th></tr>
+<tr><td>All Files</td><td>{{ .Summary.Lines }}</td><td>{{ .Summary.Branches }}</td><td>{{ .Summary.Subroutines }}</td></tr>
+{{- range .Files }}
+<tr><td><a href="#{{ .ID }}">{{ .Name }}</a></td><td>{{ .Summary.Lines }}</td><td>{{ .Summary.Branches }}</td><td>{{ .Summary.Subroutines }}</td></tr>
+{{- end }}
+</table>
+{{- range .Files }}
+<h2 id="{{ .ID }}">{{ .Name }}</h2>
+{{- if .NoSrc }}
+<p>Source is not available.</p>
+{{- end }}
+<table class="source">
+{{- range .Lines }}
+<tr class="{{ .Class }}"><td class="number">{{ .Number }}</td><td class="hits">{{ .Hits }}</td><td class="branches">{{ .Branches }}</td><td>{{ .Code }}</td></tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
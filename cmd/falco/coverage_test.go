@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// coverageFixture is the coverage of the VCL which has the executed vcl_recv subroutine
+// and the unexecuted else branch of if statement at line 3
+func coverageFixture(file string) *shared.CoverageFactory {
+	return &shared.CoverageFactory{
+		Subroutines: shared.CoverageFactoryItem{"sub_1_1": 2},
+		Statements: shared.CoverageFactoryItem{
+			"stmt_3_3": 2,
+			"stmt_4_5": 2,
+			"stmt_6_5": 0,
+		},
+		Branches: shared.CoverageFactoryItem{
+			"branch_3_3_1": 2,
+			"branch_3_3_2": 0,
+		},
+		NodeMap: map[string]token.Token{
+			"sub_1_1":      {File: file, Line: 1, Position: 1},
+			"stmt_3_3":     {File: file, Line: 3, Position: 3},
+			"stmt_4_5":     {File: file, Line: 4, Position: 5},
+			"stmt_6_5":     {File: file, Line: 6, Position: 5},
+			"branch_3_3_1": {File: file, Line: 3, Position: 3},
+			"branch_3_3_2": {File: file, Line: 3, Position: 3},
+		},
+		Names: map[string]string{"sub_1_1": "vcl_recv"},
+	}
+}
+
+func TestCoverageFiles(t *testing.T) {
+	files := coverageFixture("main.vcl").Files()
+	expect := []*shared.CoverageFile{
+		{
+			File: "main.vcl",
+			Lines: []*shared.CoverageLine{
+				{Line: 1, Hits: 2},
+				{Line: 3, Hits: 2},
+				{Line: 4, Hits: 2},
+				{Line: 6, Hits: 0},
+			},
+			Subroutines: []*shared.CoverageSubroutine{
+				{Name: "vcl_recv", Line: 1, Hits: 2},
+			},
+			Branches: []*shared.CoverageBranch{
+				{Line: 3, Block: 0, Branch: 0, Hits: 2},
+				{Line: 3, Block: 0, Branch: 1, Hits: 0},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, files); diff != "" {
+		t.Errorf("Coverage files mismatch, diff=%s", diff)
+	}
+}
+
+func TestWriteCoverageLCOV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCoverageLCOV(&buf, coverageFixture("main.vcl").Files()); err != nil {
+		t.Fatalf("Unexpected write error: %s", err)
+	}
+	expect := `TN:
+SF:main.vcl
+FN:1,vcl_recv
+FNDA:2,vcl_recv
+FNF:1
+FNH:1
+BRDA:3,0,0,2
+BRDA:3,0,1,0
+BRF:2
+BRH:1
+DA:1,2
+DA:3,2
+DA:4,2
+DA:6,0
+LF:4
+LH:3
+end_of_record
+`
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("LCOV mismatch, diff=%s", diff)
+	}
+}
+
+func TestWriteCoverageCobertura(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCoverageCobertura(&buf, coverageFixture("vcl/main.vcl").Files()); err != nil {
+		t.Fatalf("Unexpected write error: %s", err)
+	}
+	var report coberturaCoverage
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Report must be valid XML: %s", err)
+	}
+	if report.LineRate != "0.7500" || report.BranchRate != "0.5000" {
+		t.Errorf("Unexpected rates line=%s branch=%s", report.LineRate, report.BranchRate)
+	}
+	if len(report.Packages) != 1 || report.Packages[0].Name != "vcl" {
+		t.Fatalf("Expected vcl package, got:\n%s", buf.String())
+	}
+	class := report.Packages[0].Classes[0]
+	if class.Filename != "vcl/main.vcl" || class.Methods[0].Name != "vcl_recv" {
+		t.Errorf("Unexpected class, got:\n%s", buf.String())
+	}
+	if diff := cmp.Diff(&coberturaLine{Number: 3, Hits: 2, Branch: true, ConditionCoverage: "50% (1/2)"}, class.Lines[1]); diff != "" {
+		t.Errorf("Branch line mismatch, diff=%s", diff)
+	}
+}
+
+func TestWriteCoverageCoberturaPackages(t *testing.T) {
+	// Sorted file names do not make files in the same directory adjacent
+	var files []*shared.CoverageFile
+	for _, file := range []string{"a/x.vcl", "a/x0/k.vcl", "a/y.vcl"} {
+		files = append(files, coverageFixture(file).Files()...)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCoverageCobertura(&buf, files); err != nil {
+		t.Fatalf("Unexpected write error: %s", err)
+	}
+	var report coberturaCoverage
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Report must be valid XML: %s", err)
+	}
+	packages := make(map[string][]string)
+	for _, pkg := range report.Packages {
+		for _, class := range pkg.Classes {
+			packages[pkg.Name] = append(packages[pkg.Name], class.Filename)
+		}
+	}
+	expect := map[string][]string{
+		"a":    {"a/x.vcl", "a/y.vcl"},
+		"a/x0": {"a/x0/k.vcl"},
+	}
+	if diff := cmp.Diff(expect, packages); diff != "" || len(report.Packages) != 2 {
+		t.Errorf("Packages mismatch, diff=%s", diff)
+	}
+}
+
+func TestWriteCoverageHTML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.vcl")
+	source := `sub vcl_recv {
+  #FASTLY RECV
+  if (req.http.Foo) {
+    set req.http.Bar = "<bar>";
+  } else {
+    set req.http.Bar = "baz";
+  }
+}`
+	if err := os.WriteFile(file, []byte(source), 0o644); err != nil {
+		t.Fatalf("Failed to write VCL: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeCoverageHTML(&buf, coverageFixture(file).Files()); err != nil {
+		t.Fatalf("Unexpected write error: %s", err)
+	}
+	for _, v := range []string{
+		`<td>All Files</td><td>75% (3/4)</td><td>50% (1/2)</td><td>100% (1/1)</td>`,
+		`<tr class="covered"><td class="number">1</td><td class="hits">2x</td>`,
+		`<tr class="partial"><td class="number">3</td><td class="hits">2x</td><td class="branches">[&#43; -]</td>`,
+		`<tr class=""><td class="number">2</td>`,
+		`set req.http.Bar = &#34;&lt;bar&gt;&#34;;`,
+		`<tr class="uncovered"><td class="number">6</td><td class="hits">0x</td>`,
+	} {
+		if !strings.Contains(buf.String(), v) {
+			t.Errorf("Report must contain %q, got:\n%s", v, buf.String())
+		}
+	}
+}
+
+func TestCoverageFormatFromFile(t *testing.T) {
+	tests := map[string]string{
+		"coverage.info": coverageFormatLCOV,
+		"lcov.LCOV":     coverageFormatLCOV,
+		"coverage.xml":  coverageFormatCobertura,
+		"index.html":    coverageFormatHTML,
+		"coverage.json": coverageFormatJSON,
+		"coverage":      coverageFormatJSON,
+	}
+	for file, expect := range tests {
+		if v := coverageFormatFromFile(file); v != expect {
+			t.Errorf("%s: expects %s, got %s", file, expect, v)
+		}
+	}
+}
@@ -13,6 +13,8 @@ func printHelp(cmd string) {
 		printSimulateHelp()
 	case subcommandDAP:
 		printDAPHelp()
+	case subcommandLSP:
+		printLSPHelp()
 	case subcommandStats:
 		printStatsHelp()
 	case subcommandTest:
@@ -51,6 +53,7 @@ Subcommands:
     stats     : Analyze VCL statistics
     simulate  : Run simulator server with provided VCLs
     dap       : Launch DAP server to debug VCLs
+    lsp       : Launch LSP server for editors
     test      : Run local testing for provided VCLs
     console   : Run terminal console
     fmt       : Run formatter for provided VCLs
@@ -109,6 +112,20 @@ Execute this command by using your editor's DAP support.
 	`))
 }
 
+func printLSPHelp() {
+	writeln(white, strings.TrimSpace(`
+Usage:
+    falco lsp [flags]
+
+Flags:
+    -I, --include_path : Add include path
+    -h, --help         : Show this help
+
+This command launches Language Server Protocol server over stdio.
+Execute this command by using your editor's LSP support.
+	`))
+}
+
 func printSimulateHelp() {
 	writeln(white, strings.TrimSpace(`
 Usage:
@@ -126,6 +143,9 @@ Flags:
     --key              : Specify TLS server key file
     --cert             : Specify TLS cert file
     --refresh          : Refresh remote snippet cache
+    --concurrency      : Limit the number of concurrently processed requests
+    --collapse         : Enable request collapsing for concurrent cache misses
+    --shield           : Enable shielding simulation with edge and shield nodes
 
 Local simulator example:
     falco simulate -I . /path/to/vcl/main.vcl
@@ -161,14 +181,20 @@ Flags:
     -h, --help         : Show this help
     -r, --remote       : Connect with Fastly API
     -f, --filter       : Override glob filter to find test files
+    --spec-filter      : Override glob filter to find HTTP spec files
     -w, --watch        : Watch VCL file changes and run test
     -t, --tag          : Provide tag for testing
     -json              : Output results as JSON
+    --format           : Output format, json, junit, tap or github
     -request           : Override request config
     --timeout          : Set timeout to running test
+    -j, --parallel     : Number of test files to run concurrently
     --max_backends     : Override max backends limitation
     --max_acls         : Override max acls limitation
     --coverage         : Report code coverage
+    --coverage-out     : Write coverage report to the file
+    --coverage-format  : Coverage report format, lcov, cobertura, html or json
+    -u, --update-snapshots : Store snapshots of testing.snapshot() instead of comparing
 
 Local testing example:
     falco test -I . -I ./tests /path/to/vcl/main.vcl
@@ -189,6 +215,10 @@ Flags:
     -json              : Output results as JSON (very verbose)
     --generated        : Lint for Fastly generated VCL
     --refresh          : Refresh remote snippet cache
+    --fix              : Apply suggested fixes and write files in place
+    --format           : Output format, json, sarif, checkstyle or github
+    --baseline write   : Record current findings to the baseline file
+    --baseline_file    : Specify baseline file path (default .falco-baseline.json)
 
 Simple linting with very verbose example:
     falco lint -I . -vv /path/to/vcl/main.vcl
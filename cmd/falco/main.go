@@ -25,12 +25,11 @@ import (
 	"github.com/ysugimoto/falco/v2/dap"
 	ife "github.com/ysugimoto/falco/v2/interpreter/function/errors"
 	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/lsp"
 	"github.com/ysugimoto/falco/v2/resolver"
 	"github.com/ysugimoto/falco/v2/snippet"
 	"github.com/ysugimoto/falco/v2/snippet/remote"
 	"github.com/ysugimoto/falco/v2/snippet/terraform"
-	"github.com/ysugimoto/falco/v2/tester"
-	"github.com/ysugimoto/falco/v2/tester/shared"
 	"github.com/ysugimoto/falco/v2/token"
 )
 
@@ -59,6 +58,7 @@ const (
 	subcommandTerraform = "terraform"
 	subcommandSimulate  = "simulate"
 	subcommandDAP       = "dap"
+	subcommandLSP       = "lsp"
 	subcommandStats     = "stats"
 	subcommandTest      = "test"
 	subcommandConsole   = "console"
@@ -142,6 +142,11 @@ func main() {
 			os.Exit(Fail)
 		}
 		os.Exit(Success)
+	case subcommandLSP:
+		if err := lsp.New(c).Run(); err != nil {
+			os.Exit(Fail)
+		}
+		os.Exit(Success)
 	case subcommandFormat:
 		// "fmt" command accepts multiple target files
 		resolvers, err = resolver.NewGlobResolver(c.Commands[1:]...)
@@ -216,6 +221,13 @@ func main() {
 		case subcommandFormat:
 			exitErr = runFormat(runner, v)
 		default:
+			// Apply fixes first, then lint fixed files to report remaining problems
+			if c.Linter.Fix {
+				if exitErr = runFix(runner, v); exitErr != nil {
+					break
+				}
+				runner = NewRunner(c, fetcher)
+			}
 			exitErr = runLint(runner, v)
 		}
 
@@ -231,6 +243,16 @@ func main() {
 }
 
 func runLint(runner *Runner, rslv resolver.Resolver) error {
+	format := runner.config.Linter.Format
+	if format == "" && runner.config.Json {
+		format = formatJSON
+	}
+	report, ok := reporters[format]
+	if format != "" && !ok {
+		writeln(red, "Unsupported output format: %s", format)
+		return ErrExit
+	}
+
 	result, err := runner.Run(rslv)
 	if err != nil {
 		if err != ErrParser {
@@ -239,10 +261,8 @@ func runLint(runner *Runner, rslv resolver.Resolver) error {
 		return ErrExit
 	}
 
-	if runner.config.Json {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(result); err != nil {
+	if report != nil {
+		if err := report(os.Stdout, result); err != nil {
 			writeln(red, err.Error())
 			return ErrExit
 		}
@@ -251,8 +271,12 @@ func runLint(runner *Runner, rslv resolver.Resolver) error {
 	write(red, ":fire:%d errors, ", result.Errors)
 	write(yellow, ":exclamation:%d warnings, ", result.Warnings)
 	writeln(cyan, ":speaker:%d recommendations.", result.Infos)
+	if result.Baselined > 0 {
+		writeln(white, "%d problems in the baseline are not reported.", result.Baselined)
+	}
 
-	if result.Errors > 0 {
+	// Syntax errors are reported in the result on machine readable formats
+	if result.Errors > 0 || (format != formatJSON && len(result.ParseErrors) > 0) {
 		return ErrExit
 	}
 
@@ -275,6 +299,20 @@ func runLint(runner *Runner, rslv resolver.Resolver) error {
 	return nil
 }
 
+func runFix(runner *Runner, rslv resolver.Resolver) error {
+	fixed, err := runner.Fix(rslv)
+	if err != nil {
+		if err != ErrParser {
+			writeln(red, err.Error())
+		}
+		return ErrExit
+	}
+	if !runner.isMachineReadable() {
+		writeln(cyan, "%d problems are fixed.", fixed)
+	}
+	return nil
+}
+
 func runSimulate(runner *Runner, rslv resolver.Resolver) error {
 	if err := runner.Simulate(rslv); err != nil {
 		writeln(red, "Failed to start local simulator: %s", err.Error())
@@ -429,21 +467,31 @@ func printCodeLine(lx *lexer.Lexer, tok token.Token) {
 }
 
 func runTest(runner *Runner, rslv resolver.Resolver) error {
+	format := runner.config.Testing.Format
+	if format == "" && runner.config.Json {
+		format = formatJSON
+	}
+	report, ok := testReporters[format]
+	if format != "" && !ok {
+		writeln(red, "Unsupported output format: %s", format)
+		return ErrExit
+	}
+
 	factory, err := runner.Test(rslv)
 	if err != nil {
 		return ErrExit
 	}
 
-	if runner.config.Json {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(struct {
-			Tests   []*tester.TestResult `json:"tests"`
-			Summary *shared.Counter      `json:"summary"`
-		}{
-			Tests:   factory.Results,
-			Summary: factory.Statistics,
-		}); err != nil {
+	if out := runner.config.Testing.CoverageOut; out != "" && factory.Coverage != nil {
+		if err := writeCoverageReport(out, runner.config.Testing.CoverageFormat, factory.Coverage); err != nil {
+			writeln(red, err.Error())
+			return ErrExit
+		}
+		writeln(white, "Coverage report is written to %s", out)
+	}
+
+	if report != nil {
+		if err := report(os.Stdout, factory); err != nil {
 			writeln(red, err.Error())
 			return ErrExit
 		}
@@ -476,10 +524,10 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 
 			switch {
 			case c.Skip:
-				writeln(yellow, "%s- [VCL_%s] %s%s", indent(1), c.Scope, prefix, c.Name)
+				writeln(yellow, "%s- %s %s%s", indent(1), scopeLabel(c), prefix, c.Name)
 				skippedCount++
 			case c.Error != nil:
-				writeln(redBold, "%s● [VCL_%s] %s%s (%dms)\n", indent(1), c.Scope, prefix, c.Name, c.Time)
+				writeln(redBold, "%s● %s %s%s (%dms)\n", indent(1), scopeLabel(c), prefix, c.Name, c.Time)
 				if len(c.Logs) > 0 {
 					writeln(yellow, "%s[Logs]", indent(2))
 					for i := range c.Logs {
@@ -487,7 +535,7 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 					}
 					writeln(white, "")
 				}
-				writeln(red, "%s%s", indent(2), c.Error.Error())
+				writeln(red, "%s%s", indent(2), strings.ReplaceAll(c.Error.Error(), "\n", "\n"+indent(2)))
 				switch e := c.Error.(type) {
 				case *ife.AssertionError:
 					write(white, "%sActual Value: ", indent(2))
@@ -500,7 +548,7 @@ func runTest(runner *Runner, rslv resolver.Resolver) error {
 				writeln(white, "")
 				failedCount++
 			default:
-				writeln(green, "%s✓ [VCL_%s] %s%s (%dms)", indent(1), c.Scope, prefix, c.Name, c.Time)
+				writeln(green, "%s✓ %s %s%s (%dms)", indent(1), scopeLabel(c), prefix, c.Name, c.Time)
 				if len(c.Logs) > 0 {
 					writeln(yellow, "\n%s[Logs]", indent(2))
 					for i := range c.Logs {
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/linter"
+)
+
+// Lint result output formats
+const (
+	formatJSON       = "json"
+	formatSARIF      = "sarif"
+	formatCheckstyle = "checkstyle"
+	formatGitHub     = "github"
+)
+
+type reporter func(w io.Writer, result *RunnerResult) error
+
+var reporters = map[string]reporter{
+	formatJSON:       reportJSON,
+	formatSARIF:      reportSARIF,
+	formatCheckstyle: reportCheckstyle,
+	formatGitHub:     reportGitHub,
+}
+
+// problem is the common representation of parse errors and lint errors for reporters
+type problem struct {
+	File      string
+	Line      int
+	Column    int
+	Severity  linter.Severity
+	Rule      string
+	Message   string
+	Reference string
+}
+
+// collectProblems flattens the runner result into problems sorted by file and position
+func collectProblems(result *RunnerResult) []problem {
+	var problems []problem
+	for file, errs := range result.ParseErrors {
+		for _, pe := range errs {
+			problems = append(problems, problem{
+				File:     file,
+				Line:     pe.Token.Line,
+				Column:   pe.Token.Position,
+				Severity: linter.ERROR,
+				Message:  pe.Message,
+			})
+		}
+	}
+	for file, errs := range result.LintErrors {
+		for _, le := range errs {
+			problems = append(problems, problem{
+				File:      file,
+				Line:      le.Token.Line,
+				Column:    le.Token.Position,
+				Severity:  le.Severity,
+				Rule:      string(le.Rule),
+				Message:   le.Message,
+				Reference: le.Reference,
+			})
+		}
+	}
+
+	// Lint errors which are reported on the main VCL may not have the file name
+	if result.Vcl != nil {
+		for i := range problems {
+			if problems[i].File == "" {
+				problems[i].File = result.Vcl.File
+			}
+		}
+	}
+
+	slices.SortStableFunc(problems, func(a, b problem) int {
+		return cmp.Or(
+			cmp.Compare(a.File, b.File),
+			cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Column, b.Column),
+		)
+	})
+	return problems
+}
+
+// relativePath returns the slash separated path from working directory.
+// Code scanning services resolve the location from repository root
+func relativePath(file string) string {
+	if !filepath.IsAbs(file) {
+		return filepath.ToSlash(file)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(cwd, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func reportJSON(w io.Writer, result *RunnerResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(result))
+}
+
+// SARIF 2.1.0 log structures, only fields which falco reports are defined
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	HelpUri          string       `json:"helpUri,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func sarifLevel(s linter.Severity) string {
+	switch s {
+	case linter.ERROR:
+		return "error"
+	case linter.WARNING:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+func reportSARIF(w io.Writer, result *RunnerResult) error {
+	driver := sarifDriver{
+		Name:           "falco",
+		Version:        version,
+		InformationUri: "https://github.com/ysugimoto/falco",
+		Rules:          []sarifRule{},
+	}
+	results := []sarifResult{}
+	ruleIndexes := make(map[string]int)
+
+	for _, p := range collectProblems(result) {
+		r := sarifResult{
+			RuleID:  p.Rule,
+			Level:   sarifLevel(p.Severity),
+			Message: sarifMessage{Text: p.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: relativePath(p.File)},
+					Region:           sarifRegion{StartLine: p.Line, StartColumn: p.Column},
+				},
+			}},
+		}
+		if p.Rule != "" {
+			index, ok := ruleIndexes[p.Rule]
+			if !ok {
+				index = len(driver.Rules)
+				ruleIndexes[p.Rule] = index
+				driver.Rules = append(driver.Rules, sarifRule{
+					ID:               p.Rule,
+					ShortDescription: sarifMessage{Text: p.Rule},
+					HelpUri:          p.Reference,
+				})
+			}
+			r.RuleIndex = &index
+		}
+		results = append(results, r)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{Tool: sarifTool{Driver: driver}, Results: results},
+		},
+	}))
+}
+
+// Checkstyle XML structures
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr,omitempty"`
+}
+
+func checkstyleSeverity(s linter.Severity) string {
+	switch s {
+	case linter.ERROR:
+		return "error"
+	case linter.WARNING:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+func reportCheckstyle(w io.Writer, result *RunnerResult) error {
+	files := make(map[string]*checkstyleFile)
+	for _, p := range collectProblems(result) {
+		f, ok := files[p.File]
+		if !ok {
+			f = &checkstyleFile{Name: p.File}
+			files[p.File] = f
+		}
+		message := p.Message
+		if p.Reference != "" {
+			message += " (" + p.Reference + ")"
+		}
+		f.Errors = append(f.Errors, checkstyleError{
+			Line:     p.Line,
+			Column:   p.Column,
+			Severity: checkstyleSeverity(p.Severity),
+			Message:  message,
+			Source:   p.Rule,
+		})
+	}
+
+	report := checkstyleReport{Version: "4.3"}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		report.Files = append(report.Files, *files[name])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+// GitHub Actions workflow commands escaping
+// https://github.com/actions/toolkit/blob/main/packages/core/src/command.ts
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func githubCommand(s linter.Severity) string {
+	switch s {
+	case linter.ERROR:
+		return "error"
+	case linter.WARNING:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+func reportGitHub(w io.Writer, result *RunnerResult) error {
+	for _, p := range collectProblems(result) {
+		title := "falco"
+		if p.Rule != "" {
+			title += " (" + p.Rule + ")"
+		}
+		message := p.Message
+		if p.Reference != "" {
+			message += "\nSee reference documentation: " + p.Reference
+		}
+		if _, err := fmt.Fprintf(w, "::%s file=%s,line=%d,col=%d,title=%s::%s\n",
+			githubCommand(p.Severity),
+			githubPropertyEscaper.Replace(relativePath(p.File)),
+			p.Line,
+			p.Column,
+			githubPropertyEscaper.Replace(title),
+			githubDataEscaper.Replace(message),
+		); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/linter"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// runReporterFixture lints VCL which has an operator/conditional error at line 3
+// and a syntax error at line 10
+func runReporterFixture(t *testing.T, format string) *RunnerResult {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	vcl := `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "max-age=" + 300;
+  return (lookup);
+}
+
+sub vcl_fetch {
+  #FASTLY FETCH
+  set beresp.http.Baz = "baz";
+  set beresp.http.Qux = "qux"
+}`
+	if err := os.WriteFile(main, []byte(vcl), 0o644); err != nil {
+		t.Fatalf("Failed to write VCL: %s", err)
+	}
+
+	c := &config.Config{
+		Linter: &config.LinterConfig{Format: format},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	ret, err := NewRunner(c, nil).Run(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected Run() error: %s", err)
+	}
+	return ret
+}
+
+func TestReportLintResults(t *testing.T) {
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{
+			format: formatSARIF,
+			contains: []string{
+				`"version": "2.1.0"`,
+				`"ruleId": "operator/conditional"`,
+				`"level": "error"`,
+				`"helpUri": "https://`,
+				`"startLine": 3`,
+				`"startLine": 10`,
+			},
+		},
+		{
+			format: formatCheckstyle,
+			contains: []string{
+				`<checkstyle version="4.3">`,
+				`line="3"`,
+				`line="10"`,
+				`severity="error"`,
+				`source="operator/conditional"`,
+			},
+		},
+		{
+			format: formatGitHub,
+			contains: []string{
+				"::error file=",
+				"main.vcl,line=3,col=",
+				"main.vcl,line=10,col=",
+				"title=falco (operator/conditional)::",
+				"%0ASee reference documentation: https://",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := reporters[tt.format](&buf, runReporterFixture(t, tt.format)); err != nil {
+				t.Fatalf("Unexpected report error: %s", err)
+			}
+			for _, v := range tt.contains {
+				if !strings.Contains(buf.String(), v) {
+					t.Errorf("Report must contain %q, got:\n%s", v, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestReportSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportSARIF(&buf, runReporterFixture(t, formatSARIF)); err != nil {
+		t.Fatalf("Unexpected report error: %s", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("Report must be valid JSON: %s", err)
+	}
+
+	type result struct {
+		RuleID string
+		Level  string
+		Line   int
+	}
+	var results []result
+	run := log.Runs[0]
+	for _, r := range run.Results {
+		results = append(results, result{
+			RuleID: r.RuleID,
+			Level:  r.Level,
+			Line:   r.Locations[0].PhysicalLocation.Region.StartLine,
+		})
+		if r.RuleIndex != nil && run.Tool.Driver.Rules[*r.RuleIndex].ID != r.RuleID {
+			t.Errorf("Rule index of %s points to %s", r.RuleID, run.Tool.Driver.Rules[*r.RuleIndex].ID)
+		}
+	}
+	// Syntax error does not have a rule
+	expect := []result{
+		{RuleID: "operator/conditional", Level: "error", Line: 3},
+		{RuleID: "", Level: "error", Line: 10},
+	}
+	if diff := cmp.Diff(expect, results); diff != "" {
+		t.Errorf("SARIF results mismatch, diff=%s", diff)
+	}
+}
+
+func TestReportCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportCheckstyle(&buf, runReporterFixture(t, formatCheckstyle)); err != nil {
+		t.Fatalf("Unexpected report error: %s", err)
+	}
+	var report checkstyleReport
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Report must be valid XML: %s", err)
+	}
+	if len(report.Files) != 1 || len(report.Files[0].Errors) != 2 {
+		t.Errorf("Expected 2 errors in a file, got:\n%s", buf.String())
+	}
+}
+
+func TestGitHubAnnotationEscaping(t *testing.T) {
+	result := &RunnerResult{
+		LintErrors: map[string][]*linter.LintError{
+			"a,b:c.vcl": {
+				{
+					Severity: linter.WARNING,
+					Token:    token.Token{Line: 1, Position: 2},
+					Message:  "100% broken\nline",
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := reportGitHub(&buf, result); err != nil {
+		t.Fatalf("Unexpected report error: %s", err)
+	}
+	expect := "::warning file=a%2Cb%3Ac.vcl,line=1,col=2,title=falco::100%25 broken%0Aline\n"
+	if diff := cmp.Diff(expect, buf.String()); diff != "" {
+		t.Errorf("Annotation mismatch, diff=%s", diff)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"maps"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/ysugimoto/falco/v2/formatter"
 	"github.com/ysugimoto/falco/v2/interpreter"
 	icontext "github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
 	"github.com/ysugimoto/falco/v2/lexer"
 	"github.com/ysugimoto/falco/v2/linter"
 	lcontext "github.com/ysugimoto/falco/v2/linter/context"
@@ -48,8 +50,11 @@ type RunnerResult struct {
 	Warnings int
 	Errors   int
 
+	// Number of findings which are recorded in the baseline file
+	Baselined int
+
 	LintErrors  map[string][]*linter.LintError
-	ParseErrors map[string]*parser.ParseError
+	ParseErrors map[string][]*parser.ParseError
 
 	Vcl *VCL
 }
@@ -73,40 +78,50 @@ const (
 )
 
 type Runner struct {
-	overrides map[string]linter.Severity
+	overrides map[string]map[string]linter.Severity // rule severity overrides keyed by file
 	lexers    map[string]*lexer.Lexer
 	snippets  *snippet.Snippets
 	config    *config.Config
 
 	level       Level
 	lintErrors  map[string][]*linter.LintError
-	parseErrors map[string]*parser.ParseError
+	parseErrors map[string][]*parser.ParseError
+	baseline    *Baseline
 
 	// runner result fields
-	infos    int
-	warnings int
-	errors   int
+	infos     int
+	warnings  int
+	errors    int
+	baselined int
+
+	// Lint result is reported in machine readable format by the reporter
+	reporting bool
 }
 
 // Wrap writeln function in order to prevent to write when json mode turns on
 func (r *Runner) message(c *color.Color, format string, args ...any) {
-	// Suppress output when JSON mode turns on
+	// Suppress output when JSON mode or other machine readable format turns on
 	// This is because JSON only should display JSON string
 	// so any other messages we must not output
-	if r.config.Json {
+	if r.isMachineReadable() {
 		return
 	}
 	write(c, format, args...)
 }
 
+// isMachineReadable returns true when JSON mode turns on or lint result is reported in machine readable format
+func (r *Runner) isMachineReadable() bool {
+	return r.config.Json || r.reporting
+}
+
 func NewRunner(c *config.Config, fetcher snippet.Fetcher) *Runner {
 	r := &Runner{
 		level:       LevelError,
-		overrides:   make(map[string]linter.Severity),
+		overrides:   make(map[string]map[string]linter.Severity),
 		lexers:      make(map[string]*lexer.Lexer),
 		config:      c,
 		lintErrors:  make(map[string][]*linter.LintError),
-		parseErrors: make(map[string]*parser.ParseError),
+		parseErrors: make(map[string][]*parser.ParseError),
 	}
 
 	// If fetch interface is provided, communicate with it
@@ -140,19 +155,16 @@ func NewRunner(c *config.Config, fetcher snippet.Fetcher) *Runner {
 		r.level = LevelWarning
 	}
 
-	// Override linter rules
-	for key, value := range c.Linter.Rules {
-		switch strings.ToUpper(value) {
-		case "ERROR":
-			r.overrides[key] = linter.ERROR
-		case "WARNING":
-			r.overrides[key] = linter.WARNING
-		case "INFO":
-			r.overrides[key] = linter.INFO
-		case "IGNORE":
-			r.overrides[key] = linter.IGNORE
-		default:
-			r.message(yellow, "Level for rule %s has invalid value %s, skipping.\n", key, value)
+	// Validate linter rule levels, overrides are resolved for each file on reporting
+	rules := []map[string]string{c.Linter.Rules}
+	for _, o := range c.Linter.Overrides {
+		rules = append(rules, o.Rules)
+	}
+	for _, rule := range rules {
+		for key, value := range rule {
+			if _, ok := parseSeverity(value); !ok {
+				r.message(yellow, "Level for rule %s has invalid value %s, skipping.\n", key, value)
+			}
 		}
 	}
 
@@ -160,6 +172,7 @@ func NewRunner(c *config.Config, fetcher snippet.Fetcher) *Runner {
 }
 
 func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
+	r.reporting = r.config.Linter.Format != ""
 	options := []lcontext.Option{lcontext.WithResolver(rslv)}
 	// If remote snippets exists, prepare parse and prepend to main VCL
 	if r.snippets != nil {
@@ -171,17 +184,37 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 		return nil, err
 	}
 
+	if mode := r.config.Linter.Baseline; mode != "" && mode != baselineModeWrite {
+		return nil, errors.New(fmt.Sprintf("Unsupported baseline mode: %s", mode))
+	}
+	if file := r.config.Linter.BaselineFile; file != "" {
+		if r.config.Linter.Baseline == baselineModeWrite {
+			r.baseline = newBaseline(file)
+		} else if r.baseline, err = loadBaseline(file); err != nil {
+			return nil, err
+		}
+	}
+
 	// Note: this context is not Go context, our linter context :)
 	ctx := lcontext.New(options...)
 	vcl, err := r.run(ctx, main, RunModeLint)
-	if err != nil && !r.config.Json {
+	if err != nil && !r.isMachineReadable() {
 		return nil, err
 	}
 
+	// Partial findings must not be recorded when the VCL has syntax errors
+	if r.config.Linter.Baseline == baselineModeWrite && r.baseline != nil && err == nil {
+		if err := r.baseline.Write(); err != nil {
+			return nil, err
+		}
+		r.message(cyan, "Baseline is written to %s with %d findings.\n", r.baseline.path, r.baseline.Len())
+	}
+
 	return &RunnerResult{
 		Infos:       r.infos,
 		Warnings:    r.warnings,
 		Errors:      r.errors,
+		Baselined:   r.baselined,
 		LintErrors:  r.lintErrors,
 		ParseErrors: r.parseErrors,
 		Vcl:         vcl,
@@ -189,9 +222,11 @@ func (r *Runner) Run(rslv resolver.Resolver) (*RunnerResult, error) {
 }
 
 func (r *Runner) run(ctx *lcontext.Context, main *resolver.VCL, mode RunMode) (*VCL, error) {
-	vcl, err := r.parseVCL(main.Name, main.Data)
-	if err != nil {
-		return nil, err
+	// Parser recovers from syntax errors so continue to lint the partial AST
+	// in order to report as many problems as possible
+	vcl, parseErr := r.parseVCL(main.Name, main.Data)
+	if vcl == nil {
+		return nil, parseErr
 	}
 
 	// If remote snippets exists, prepare parse and prepend to main VCL
@@ -202,8 +237,10 @@ func (r *Runner) run(ctx *lcontext.Context, main *resolver.VCL, mode RunMode) (*
 		}
 		for _, snip := range snippets {
 			s, err := r.parseVCL(snip.Name, snip.Data)
-			if err != nil {
+			if s == nil {
 				return nil, err
+			} else if err != nil {
+				parseErr = err
 			}
 			vcl.Statements = append(s.Statements, vcl.Statements...)
 		}
@@ -216,74 +253,138 @@ func (r *Runner) run(ctx *lcontext.Context, main *resolver.VCL, mode RunMode) (*
 
 	// If runner is running as stat mode, prevent to output lint result
 	if mode&RunModeStat > 0 {
-		return nil, nil
+		return nil, parseErr
 	}
 
 	// Checking Fatal error, it means parse error occurs on included submodule
 	if lt.FatalError != nil {
-		if pe, ok := lt.FatalError.Error.(*parser.ParseError); ok {
-			var file string
-			if pe.Token.File != "" {
-				file = "in " + pe.Token.File + " "
-			}
-			// Nothing to print to stdout if JSON mode is enabled, exit early.
-			if r.config.Json {
-				r.parseErrors[pe.Token.File] = pe
-			} else {
-				r.printParseError(lt.FatalError.Lexer, file, pe)
-			}
+		parseErrors := lt.FatalError.ParseErrors
+		if pe, ok := lt.FatalError.Error.(*parser.ParseError); ok && len(parseErrors) == 0 {
+			parseErrors = []*parser.ParseError{pe}
 		}
-		return nil, ErrParser
+		r.reportParseErrors(lt.FatalError.Lexer, parseErrors)
+		parseErr = ErrParser
 	}
 
 	if len(lt.Errors) > 0 {
 		for _, le := range lt.Errors {
 			// check severity with overrides
-			severity := le.Severity
-			if v, ok := r.overrides[string(le.Rule)]; ok {
-				severity = v
+			severity := r.severity(le)
+
+			// Findings in the baseline are not reported
+			if severity != linter.IGNORE && r.isBaselined(main.Name, le) {
+				r.baselined++
+				continue
 			}
 
-			// Store all but ignored linter errors
-			if r.config.Json && severity != linter.IGNORE {
+			// Store all but ignored linter errors with overridden severity
+			if r.isMachineReadable() && severity != linter.IGNORE {
+				le.Severity = severity
 				r.lintErrors[le.Token.File] = append(r.lintErrors[le.Token.File], le)
 			}
 			r.printLinterError(r.lexers[main.Name], severity, le)
 		}
 	}
 
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
 	return &VCL{
 		File: main.Name,
 		AST:  vcl,
 	}, nil
 }
 
+// severity returns the severity of the linter error which is overridden by the rules for the file
+func (r *Runner) severity(le *linter.LintError) linter.Severity {
+	overrides, ok := r.overrides[le.Token.File]
+	if !ok {
+		overrides = make(map[string]linter.Severity)
+		for key, value := range r.config.Linter.ForFile(le.Token.File).Rules {
+			if v, ok := parseSeverity(value); ok {
+				overrides[key] = v
+			}
+		}
+		r.overrides[le.Token.File] = overrides
+	}
+	if v, ok := overrides[string(le.Rule)]; ok {
+		return v
+	}
+	return le.Severity
+}
+
+func parseSeverity(level string) (linter.Severity, bool) {
+	switch strings.ToUpper(level) {
+	case "ERROR":
+		return linter.ERROR, true
+	case "WARNING":
+		return linter.WARNING, true
+	case "INFO":
+		return linter.INFO, true
+	case "IGNORE":
+		return linter.IGNORE, true
+	}
+	return "", false
+}
+
+// isBaselined records the finding on baseline write mode, or consumes the finding recorded in the baseline.
+// Returns true if the finding should not be reported
+func (r *Runner) isBaselined(mainFile string, le *linter.LintError) bool {
+	if r.baseline == nil {
+		return false
+	}
+	file := le.Token.File
+	if file == "" {
+		file = mainFile
+	}
+	key := r.baseline.key(r.lexers[file], file, le)
+	if r.config.Linter.Baseline == baselineModeWrite {
+		r.baseline.Add(key)
+		return true
+	}
+	return r.baseline.Suppress(key)
+}
+
+// parseVCL parses the VCL and reports all syntax errors.
+// The partial AST is returned with ErrParser when the parser could recover from syntax errors.
 func (r *Runner) parseVCL(name, code string) (*ast.VCL, error) {
 	lx := lexer.NewFromString(code, lexer.WithFile(name))
 	p := parser.New(lx)
 	vcl, err := p.ParseVCLOrSnippet()
+	lx.NewLine()
 	if err != nil {
-		lx.NewLine()
-		pe, ok := errors.Cause(err).(*parser.ParseError)
-		if ok {
-			var file string
-			if pe.Token.File != "" {
-				file = "in " + pe.Token.File + " "
-			}
-			// Nothing to print to stdout if JSON mode is enabled, exit early.
-			if r.config.Json {
-				r.parseErrors[pe.Token.File] = pe
-			}
-			r.printParseError(lx, file, pe)
+		r.reportParseErrors(lx, p.Errors())
+		if vcl == nil {
+			return nil, ErrParser
 		}
-		return nil, ErrParser
+		r.lexers[name] = lx
+		return vcl, ErrParser
 	}
 
-	lx.NewLine()
 	r.lexers[name] = lx
 	return vcl, nil
 }
 
+// reportParseErrors stores and prints all syntax errors.
+// Lexer is switched to the one of the file which the error occurred in if exists
+func (r *Runner) reportParseErrors(lx *lexer.Lexer, parseErrors []*parser.ParseError) {
+	for _, pe := range parseErrors {
+		var file string
+		if pe.Token.File != "" {
+			file = "in " + pe.Token.File + " "
+		}
+		if r.isMachineReadable() {
+			r.parseErrors[pe.Token.File] = append(r.parseErrors[pe.Token.File], pe)
+		}
+		if v, ok := r.lexers[pe.Token.File]; ok {
+			r.printParseError(v, file, pe)
+		} else {
+			r.printParseError(lx, file, pe)
+		}
+	}
+}
+
 func (r *Runner) printParseError(lx *lexer.Lexer, file string, err *parser.ParseError) {
 	r.message(red, ":boom: %s\n%sat line %d, position %d\n", err.Message, file, err.Token.Line, err.Token.Position)
 
@@ -413,6 +514,7 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 		icontext.WithMaxBackends(r.config.OverrideMaxBackends),
 		icontext.WithMaxAcls(r.config.OverrideMaxAcls),
 		icontext.WithActualResponse(sc.IsProxyResponse),
+		icontext.WithRequestCollapsing(sc.IsCollapsing),
 		icontext.WithTLServer(isTLS),
 	}
 
@@ -425,10 +527,27 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	if r.config.OverrideBackends != nil {
 		options = append(options, icontext.WithOverrideBackends(r.config.OverrideBackends))
 	}
+	if r.config.MockBackends != nil {
+		mocks, err := icontext.NewMockBackends(r.config.MockBackends)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		options = append(options, icontext.WithMockBackends(mocks))
+	}
 	// If simulator configuration has edge dictionaries, inject them
 	if sc.OverrideEdgeDictionaries != nil {
 		options = append(options, icontext.WithInjectEdgeDictionaries(sc.OverrideEdgeDictionaries))
 	}
+	// If simulator configuration has logging endpoints, route log lines to their sinks
+	if sc.LoggingEndpoints != nil {
+		r.validateLoggingEndpoints(sc.LoggingEndpoints)
+		sinks, err := logging.NewSinks(sc.LoggingEndpoints)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		defer sinks.Close() // nolint:errcheck
+		options = append(options, icontext.WithLoggingSinks(sinks))
+	}
 
 	// Factory override variables.
 	// The order is important, should do yaml -> cli order because cli could override yaml configuration
@@ -449,6 +568,13 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 		options = append(options, icontext.WithOverrideVariables(overrides))
 	}
 
+	// In shielding mode, shield node runs in process with its own cache,
+	// and shield directors on the edge node send requests to it
+	if sc.IsShielding {
+		shield := interpreter.NewShieldNode(options...)
+		options = append(options, icontext.WithShield(shield.ConcurrentHandler(sc.MaxConcurrency)))
+	}
+
 	i := interpreter.New(options...)
 
 	if sc.IsDebug {
@@ -460,8 +586,9 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	// root handler directly: an http.ServeMux would path.Clean-301 requests
 	// with `//`, `/./`, or `/../`, hiding those raw paths from VCL. Real Fastly
 	// preserves them in req.url / req.url.path, so the simulator must too.
+	// Requests are processed concurrently, each of them has its own execution context.
 	s := &http.Server{
-		Handler: i,
+		Handler: i.ConcurrentHandler(sc.MaxConcurrency),
 		Addr:    fmt.Sprintf(":%d", sc.Port),
 	}
 
@@ -479,6 +606,18 @@ func (r *Runner) Simulate(rslv resolver.Resolver) error {
 	return nil
 }
 
+// Warn logging endpoints which are not defined in the service when they are fetched from remote or terraform
+func (r *Runner) validateLoggingEndpoints(endpoints map[string]*config.LoggingEndpoint) {
+	if r.snippets == nil || len(r.snippets.LoggingEndpoints) == 0 {
+		return
+	}
+	for name := range endpoints {
+		if _, ok := r.snippets.LoggingEndpoints[name]; !ok {
+			writeln(yellow, "Logging endpoint %s is not defined in the service, log lines will be discarded", name)
+		}
+	}
+}
+
 func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	tc := r.config.Testing
 	options := []icontext.Option{
@@ -498,6 +637,13 @@ func (r *Runner) Test(rslv resolver.Resolver) (*tester.TestFactory, error) {
 	if tc.OverrideEdgeDictionaries != nil {
 		options = append(options, icontext.WithInjectEdgeDictionaries(tc.OverrideEdgeDictionaries))
 	}
+	if r.config.MockBackends != nil {
+		mocks, err := icontext.NewMockBackends(r.config.MockBackends)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		options = append(options, icontext.WithMockBackends(mocks))
+	}
 
 	// Factory override variables.
 	// The order is imporotant, should do yaml -> cli order because cli could override yaml configuration
@@ -577,3 +723,73 @@ func (r *Runner) Format(rslv resolver.Resolver) error {
 	}
 	return nil
 }
+
+// Fix applies suggested fixes of lint errors to the AST and writes formatted VCL files back in place.
+// Returns the number of applied fixes
+func (r *Runner) Fix(rslv resolver.Resolver) (int, error) {
+	r.reporting = r.config.Linter.Format != ""
+	if _, ok := rslv.(*resolver.FileResolver); !ok {
+		return 0, errors.New("Fix is only supported for local VCL files")
+	}
+	main, err := rslv.MainVCL()
+	if err != nil {
+		return 0, err
+	}
+	vcl, err := r.parseVCL(main.Name, main.Data)
+	if err != nil {
+		return 0, err
+	}
+
+	options := []lcontext.Option{lcontext.WithResolver(rslv)}
+	if r.snippets != nil {
+		options = append(options, lcontext.WithSnippets(r.snippets))
+	}
+	lt := linter.New(r.config.Linter)
+	lt.Lint(vcl, lcontext.New(options...))
+	maps.Copy(r.lexers, lt.Lexers())
+
+	// Partial AST must not be written back
+	if lt.FatalError != nil {
+		r.reportParseErrors(lt.FatalError.Lexer, lt.FatalError.ParseErrors)
+		return 0, ErrParser
+	}
+
+	files := map[string]*ast.VCL{main.Name: vcl}
+	maps.Copy(files, lt.Modules())
+
+	fixed := make(map[string]int)
+	for _, le := range lt.Errors {
+		if le.Fix == nil {
+			continue
+		}
+		if r.severity(le) == linter.IGNORE {
+			continue
+		}
+		file := le.Token.File
+		if file == "" {
+			file = main.Name
+		}
+		// Remote snippets and statement snippets could not be written back
+		v, ok := files[file]
+		if !ok || v.IsSnippet || strings.HasPrefix(file, "snippet::") {
+			continue
+		}
+		if le.Fix.Apply(v) {
+			fixed[file]++
+		}
+	}
+
+	var total int
+	for _, file := range slices.Sorted(maps.Keys(fixed)) {
+		formatted, err := io.ReadAll(formatter.New(r.config.Format).Format(files[file]))
+		if err != nil {
+			return total, errors.WithStack(err)
+		}
+		if err := os.WriteFile(file, formatted, 0o644); err != nil {
+			return total, errors.WithStack(err)
+		}
+		r.message(cyan, "Fixed %d problems in %s.\n", fixed[file], file)
+		total += fixed[file]
+	}
+	return total, nil
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/ysugimoto/falco/v2/config"
 	"github.com/ysugimoto/falco/v2/interpreter"
 	icontext "github.com/ysugimoto/falco/v2/interpreter/context"
@@ -55,7 +56,7 @@ func loadRepoExampleTestMetadata() []RepoExampleTestMetadata {
 			name:     "example 4",
 			fileName: "../../examples/linter/default04.vcl",
 			errors:   0,
-			warnings: 0,
+			warnings: 1,
 			infos:    1,
 		},
 		{
@@ -166,6 +167,123 @@ func TestResolveWithDuplicateDeclarations(t *testing.T) {
 	}
 }
 
+func TestReportAllParseErrors(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	files := map[string]string{
+		main: `include "module";
+
+sub vcl_recv {
+  set req.http.Foo = ;
+  set req.http.Bar = "bar"
+  return (lookup);
+}`,
+		filepath.Join(dir, "module.vcl"): `sub module_recv {
+  unset req.http.Foo
+}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write VCL: %s", err)
+		}
+	}
+
+	c := &config.Config{
+		Json:   true,
+		Linter: &config.LinterConfig{},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	ret, err := NewRunner(c, nil).Run(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected Run() error: %s", err)
+	}
+
+	lines := map[string][]int{}
+	for file, errs := range ret.ParseErrors {
+		for _, pe := range errs {
+			lines[filepath.Base(file)] = append(lines[filepath.Base(file)], pe.Token.Line)
+		}
+	}
+	expect := map[string][]int{
+		"main.vcl":   {4, 5},
+		"module.vcl": {2},
+	}
+	if diff := cmp.Diff(expect, lines); diff != "" {
+		t.Errorf("Parse errors mismatch, diff=%s", diff)
+	}
+}
+
+func TestFixWritesFilesInPlace(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	module := filepath.Join(dir, "module.vcl")
+	files := map[string]string{
+		main: `include "module";
+
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.unused STRING;
+  call module_recv;
+  return;
+}`,
+		module: `sub module_recv {
+  set req.url = boltsort.sort(req.url);
+}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write VCL: %s", err)
+		}
+	}
+
+	c := &config.Config{
+		Linter: &config.LinterConfig{Fix: true},
+		Format: &config.FormatConfig{IndentWidth: 2, IndentStyle: config.IndentStyleSpace, TrailingCommentWidth: 1, LineWidth: 120},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	fixed, err := NewRunner(c, nil).Fix(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected Fix() error: %s", err)
+	}
+	if fixed != 3 {
+		t.Errorf("Expected 3 fixes, got %d", fixed)
+	}
+
+	expects := map[string][]string{
+		main:   {"return lookup;"},
+		module: {"querystring.sort(req.url)"},
+	}
+	for name, contains := range expects {
+		buf, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Failed to read VCL: %s", err)
+		}
+		for _, v := range contains {
+			if !bytes.Contains(buf, []byte(v)) {
+				t.Errorf("%s must contain %q, got:\n%s", filepath.Base(name), v, buf)
+			}
+		}
+		if bytes.Contains(buf, []byte("var.unused")) {
+			t.Errorf("Unused variable must be removed from %s", filepath.Base(name))
+		}
+	}
+
+	// Fixed files do not have any problems
+	ret, err := NewRunner(c, nil).Run(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected Run() error: %s", err)
+	}
+	if ret.Errors+ret.Warnings+ret.Infos != 0 {
+		t.Errorf("Expected no lint problems after fix, got %+v", ret.LintErrors)
+	}
+}
+
 func TestResolveModulesWithVCLExtension(t *testing.T) {
 	fileName := "../../snippet/terraform/data/terraform-modules-extension.json"
 	rslv, f := loadFromTfJson(fileName, t)
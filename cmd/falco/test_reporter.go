@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	ife "github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/tester"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+)
+
+// Test result output formats, json and github are shared with lint result formats
+const (
+	formatJUnit = "junit"
+	formatTAP   = "tap"
+)
+
+type testReporter func(w io.Writer, factory *tester.TestFactory) error
+
+var testReporters = map[string]testReporter{
+	formatJSON:   reportTestJSON,
+	formatJUnit:  reportTestJUnit,
+	formatTAP:    reportTestTAP,
+	formatGitHub: reportTestGitHub,
+}
+
+// testFailure is the common representation of failed test case for reporters
+type testFailure struct {
+	Message   string
+	Actual    string // actual value of assertion, empty for other errors
+	File      string
+	Line      int
+	Column    int
+	Assertion bool
+}
+
+// failureOf returns the failure detail of the test case, file is used when the error does not have the location
+func failureOf(file string, c *tester.TestCase) *testFailure {
+	if c.Error == nil {
+		return nil
+	}
+
+	f := &testFailure{
+		Message: c.Error.Error(),
+		File:    file,
+	}
+	switch e := c.Error.(type) {
+	case *ife.AssertionError:
+		f.Message = e.Message
+		f.Assertion = true
+		if e.Actual != nil {
+			f.Actual = e.Actual.String()
+		}
+		if e.Token.File != "" {
+			f.File = e.Token.File
+		}
+		f.Line, f.Column = e.Token.Line, e.Token.Position
+	case *ife.TestingError:
+		f.Message = e.Message
+		if e.Token.File != "" {
+			f.File = e.Token.File
+		}
+		f.Line, f.Column = e.Token.Line, e.Token.Position
+	case *tester.SpecError:
+		f.Assertion = true
+	}
+	f.File = relativePath(f.File)
+	return f
+}
+
+// scopeLabel returns the scope label of the test case like "[VCL_RECV]", or "[HTTP]" for spec files
+func scopeLabel(c *tester.TestCase) string {
+	if c.Scope == tester.SpecScope {
+		return "[" + c.Scope + "]"
+	}
+	return "[VCL_" + c.Scope + "]"
+}
+
+// testCaseName returns the display name of the test case like "[VCL_RECV] group › name"
+func testCaseName(c *tester.TestCase) string {
+	name := c.Name
+	if c.Group != "" {
+		name = c.Group + " › " + name
+	}
+	return scopeLabel(c) + " " + name
+}
+
+func reportTestJSON(w io.Writer, factory *tester.TestFactory) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return errors.WithStack(enc.Encode(struct {
+		Tests   []*tester.TestResult `json:"tests"`
+		Summary *shared.Counter      `json:"summary"`
+	}{
+		Tests:   factory.Results,
+		Summary: factory.Statistics,
+	}))
+}
+
+// JUnit XML structures which are commonly accepted by CI services
+// https://github.com/testmoapp/junitxml
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *struct{}     `xml:"skipped"`
+	Failure   *junitFailure `xml:"failure"`
+	Error     *junitFailure `xml:"error"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// junitTime formats milliseconds as seconds
+func junitTime(msec int64) string {
+	return fmt.Sprintf("%.3f", float64(msec)/1000)
+}
+
+func reportTestJUnit(w io.Writer, factory *tester.TestFactory) error {
+	root := &junitTestSuites{Name: "falco"}
+	var total int64
+	for _, r := range factory.Results {
+		file := relativePath(r.Filename)
+		suite := &junitTestSuite{Name: file}
+		var elapsed int64
+		for _, c := range r.Cases {
+			elapsed += c.Time
+			tc := &junitTestCase{
+				Name:      testCaseName(c),
+				ClassName: file,
+				File:      file,
+				Time:      junitTime(c.Time),
+				SystemOut: strings.Join(c.Logs, "\n"),
+			}
+			if c.Group != "" {
+				tc.ClassName = file + "." + c.Group
+			}
+			suite.Tests++
+
+			switch f := failureOf(r.Filename, c); {
+			case c.Skip:
+				tc.Skipped = &struct{}{}
+				suite.Skipped++
+			case f != nil:
+				tc.Line = f.Line
+				body := f.File
+				if f.Line > 0 {
+					body += fmt.Sprintf(":%d:%d", f.Line, f.Column)
+				}
+				if f.Actual != "" {
+					body += "\nActual Value: " + f.Actual
+				}
+				if f.Assertion {
+					tc.Failure = &junitFailure{Message: f.Message, Type: "AssertionError", Body: body}
+					suite.Failures++
+				} else {
+					tc.Error = &junitFailure{Message: f.Message, Type: "TestingError", Body: body}
+					suite.Errors++
+				}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+		suite.Time = junitTime(elapsed)
+		total += elapsed
+
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Errors += suite.Errors
+		root.Skipped += suite.Skipped
+		root.Suites = append(root.Suites, suite)
+	}
+	root.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.WithStack(err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return errors.WithStack(err)
+}
+
+// reportTestTAP reports results in TAP version 13 with YAML diagnostics for failures
+// https://testanything.org/tap-version-13-specification.html
+func reportTestTAP(w io.Writer, factory *tester.TestFactory) error {
+	var lines []string
+	var count int
+	for _, r := range factory.Results {
+		file := relativePath(r.Filename)
+		for _, c := range r.Cases {
+			count++
+			description := strings.ReplaceAll(file+" › "+testCaseName(c), "#", `\#`)
+
+			switch f := failureOf(r.Filename, c); {
+			case c.Skip:
+				lines = append(lines, fmt.Sprintf("ok %d - %s # SKIP", count, description))
+			case f != nil:
+				severity := "error"
+				if f.Assertion {
+					severity = "fail"
+				}
+				lines = append(lines,
+					fmt.Sprintf("not ok %d - %s", count, description),
+					"  ---",
+					fmt.Sprintf("  message: %q", f.Message),
+					fmt.Sprintf("  severity: %s", severity),
+				)
+				if f.Actual != "" {
+					lines = append(lines, fmt.Sprintf("  actual: %q", f.Actual))
+				}
+				lines = append(lines,
+					"  at:",
+					fmt.Sprintf("    file: %q", f.File),
+					fmt.Sprintf("    line: %d", f.Line),
+					fmt.Sprintf("    column: %d", f.Column),
+					fmt.Sprintf("  duration_ms: %d", c.Time),
+					"  ...",
+				)
+			default:
+				lines = append(lines, fmt.Sprintf("ok %d - %s # time=%dms", count, description, c.Time))
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", count); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// reportTestGitHub groups test results per file in the workflow log,
+// and annotates failures and skipped tests
+func reportTestGitHub(w io.Writer, factory *tester.TestFactory) error {
+	var annotations []string
+	for _, r := range factory.Results {
+		file := relativePath(r.Filename)
+		if _, err := fmt.Fprintf(w, "::group::%s\n", githubDataEscaper.Replace(file)); err != nil {
+			return errors.WithStack(err)
+		}
+		for _, c := range r.Cases {
+			name := testCaseName(c)
+			var status string
+			switch f := failureOf(r.Filename, c); {
+			case c.Skip:
+				status = "SKIP"
+				annotations = append(annotations, fmt.Sprintf("::notice file=%s,title=%s::%s",
+					githubPropertyEscaper.Replace(file),
+					githubPropertyEscaper.Replace("falco test skipped"),
+					githubDataEscaper.Replace(name+" is skipped"),
+				))
+			case f != nil:
+				status = "FAIL"
+				message := f.Message
+				if f.Actual != "" {
+					message += "\nActual Value: " + f.Actual
+				}
+				location := githubPropertyEscaper.Replace(f.File)
+				if f.Line > 0 {
+					location += fmt.Sprintf(",line=%d,col=%d", f.Line, f.Column)
+				}
+				annotations = append(annotations, fmt.Sprintf("::error file=%s,title=%s::%s",
+					location,
+					githubPropertyEscaper.Replace("falco test failed: "+name),
+					githubDataEscaper.Replace(message),
+				))
+			default:
+				status = "PASS"
+			}
+			if _, err := fmt.Fprintf(w, "%s %s (%dms)\n", status, githubDataEscaper.Replace(name), c.Time); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if _, err := io.WriteString(w, "::endgroup::\n"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	for _, a := range annotations {
+		if _, err := io.WriteString(w, a+"\n"); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/tester"
+)
+
+// runTestReporterFixture runs the test file which has passed, failed and skipped test cases.
+// The assertion fails at line 11 of the test file
+func runTestReporterFixture(t *testing.T) *tester.TestFactory {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	if err := os.WriteFile(main, []byte(`sub vcl_recv {
+  #FASTLY RECV
+  set req.http.X-Foo = "foo";
+  return (lookup);
+}`), 0o644); err != nil {
+		t.Fatalf("Failed to write VCL: %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.test.vcl"), []byte(`// @suite: passed suite
+sub test_passed_recv {
+  testing.call_subroutine("vcl_recv");
+  assert.equal(req.http.X-Foo, "foo");
+}
+
+describe group {
+  // @suite: failed suite
+  sub test_failed_recv {
+    testing.call_subroutine("vcl_recv");
+    assert.equal(req.http.X-Foo, "bar");
+  }
+}
+
+// @skip
+sub test_skipped_recv {
+  assert.true(false);
+}`), 0o644); err != nil {
+		t.Fatalf("Failed to write test VCL: %s", err)
+	}
+
+	c := &config.Config{
+		Linter:   &config.LinterConfig{},
+		Testing:  &config.TestConfig{Filter: "*.test.vcl"},
+		Commands: config.Commands{"test", main},
+	}
+	resolvers, err := resolver.NewFileResolvers(main, c.IncludePaths)
+	if err != nil {
+		t.Fatalf("Unexpected runner creation error: %s", err)
+	}
+	factory, err := NewRunner(c, nil).Test(resolvers[0])
+	if err != nil {
+		t.Fatalf("Unexpected Test() error: %s", err)
+	}
+	return factory
+}
+
+func TestReportTestResults(t *testing.T) {
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{
+			format: formatJSON,
+			contains: []string{
+				`"name": "failed suite"`,
+				`"line": 11`,
+				`"skips": 1`,
+			},
+		},
+		{
+			format: formatTAP,
+			contains: []string{
+				"TAP version 13\n1..3\n",
+				"ok 1 - ",
+				"main.test.vcl › [VCL_RECV] passed suite # time=",
+				"not ok 2 - ",
+				"main.test.vcl › [VCL_RECV] group › failed suite\n",
+				"  severity: fail\n",
+				"  actual: \"foo\"\n",
+				"    line: 11\n    column: 5\n",
+				"ok 3 - ",
+				"main.test.vcl › [VCL_RECV] test_skipped_recv # SKIP\n",
+			},
+		},
+		{
+			format: formatGitHub,
+			contains: []string{
+				"::group::",
+				"PASS [VCL_RECV] passed suite (",
+				"FAIL [VCL_RECV] group › failed suite (",
+				"SKIP [VCL_RECV] test_skipped_recv (",
+				"::endgroup::\n",
+				"main.test.vcl,line=11,col=5,title=falco test failed%3A [VCL_RECV] group › failed suite::",
+				"%0AActual Value: foo",
+				"::notice file=",
+			},
+		},
+	}
+
+	factory := runTestReporterFixture(t)
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := testReporters[tt.format](&buf, factory); err != nil {
+				t.Fatalf("Unexpected report error: %s", err)
+			}
+			for _, v := range tt.contains {
+				if !strings.Contains(buf.String(), v) {
+					t.Errorf("Report must contain %q, got:\n%s", v, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestReportTestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := reportTestJUnit(&buf, runTestReporterFixture(t)); err != nil {
+		t.Fatalf("Unexpected report error: %s", err)
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Report must be valid XML: %s", err)
+	}
+
+	type testCase struct {
+		Name    string
+		Line    int
+		Skipped bool
+		Failure string
+	}
+	var cases []testCase
+	for _, s := range report.Suites {
+		if !strings.HasSuffix(s.Name, "main.test.vcl") {
+			t.Errorf("Suite name must be the test file, got %s", s.Name)
+		}
+		for _, c := range s.Cases {
+			tc := testCase{Name: c.Name, Line: c.Line, Skipped: c.Skipped != nil}
+			if c.Failure != nil {
+				tc.Failure = c.Failure.Type + ": " + c.Failure.Message
+			}
+			cases = append(cases, tc)
+		}
+	}
+	expect := []testCase{
+		{Name: "[VCL_RECV] passed suite"},
+		{Name: "[VCL_RECV] group › failed suite", Line: 11, Failure: `AssertionError: Assertion error: expect=bar, actual=foo`},
+		{Name: "[VCL_RECV] test_skipped_recv", Skipped: true},
+	}
+	if diff := cmp.Diff(expect, cases); diff != "" {
+		t.Errorf("JUnit test cases mismatch, diff=%s", diff)
+	}
+	if report.Tests != 3 || report.Failures != 1 || report.Skipped != 1 || report.Errors != 0 {
+		t.Errorf("Unexpected summary, got:\n%s", buf.String())
+	}
+}
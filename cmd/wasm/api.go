@@ -132,10 +132,21 @@ func lint(_ js.Value, args []js.Value) any {
 	lx := lexer.NewFromString(vcl)
 	p := parser.New(lx)
 	ast, err := p.ParseVCLOrSnippet()
-	if err != nil {
+	if ast == nil {
 		return toJS(LintResult{Error: "Parse error: " + err.Error()})
 	}
 
+	// Report all syntax errors and continue to lint the partial AST
+	var lintErrors []LintError
+	for _, pe := range p.Errors() {
+		lintErrors = append(lintErrors, LintError{
+			Severity: "error",
+			Message:  pe.Message,
+			Line:     pe.Token.Line,
+			Position: pe.Token.Position,
+		})
+	}
+
 	// Create linter context with scope if specified
 	ctx := context.New()
 	if opts.Scope != "" {
@@ -154,7 +165,6 @@ func lint(_ js.Value, args []js.Value) any {
 	l.Lint(ast, ctx)
 
 	// Collect errors
-	var lintErrors []LintError
 	if l.FatalError != nil {
 		line, pos := 1, 1
 		var parseErr *parser.ParseError
@@ -171,13 +181,24 @@ func lint(_ js.Value, args []js.Value) any {
 	}
 
 	for _, le := range l.Errors {
-		lintErrors = append(lintErrors, LintError{
+		e := LintError{
 			Severity: strings.ToLower(string(le.Severity)),
 			Message:  le.Message,
 			Line:     le.Token.Line,
 			Position: le.Token.Position,
 			Rule:     string(le.Rule),
-		})
+		}
+		if le.Fix != nil {
+			e.Fix = &Fix{
+				Description: le.Fix.Description,
+				Line:        le.Fix.Line,
+				Position:    le.Fix.Position,
+				EndLine:     le.Fix.EndLine,
+				EndPosition: le.Fix.EndPosition,
+				Text:        le.Fix.Text,
+			}
+		}
+		lintErrors = append(lintErrors, e)
 	}
 
 	return toJS(LintResult{Errors: lintErrors})
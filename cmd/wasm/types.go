@@ -30,6 +30,18 @@ type LintError struct {
 	Line     int    `json:"line"`
 	Position int    `json:"position"`
 	Rule     string `json:"rule,omitempty"`
+	Fix      *Fix   `json:"fix,omitempty"`
+}
+
+// Fix is a suggested edit which replaces the source range with the text.
+// Positions are 1-based and the end of range is exclusive.
+type Fix struct {
+	Description string `json:"description"`
+	Line        int    `json:"line"`
+	Position    int    `json:"position"`
+	EndLine     int    `json:"endLine"`
+	EndPosition int    `json:"endPosition"`
+	Text        string `json:"text"`
 }
 
 // LintResult is the response from lint().
@@ -14,13 +14,21 @@ func (c Commands) At(n int) string {
 }
 
 var needValueOptions = map[string]struct{}{
-	"-I":             {},
-	"--include_path": {},
-	"-t":             {},
-	"--transformer":  {},
-	"-f":             {},
-	"--filter":       {},
-	"--generated":    {},
+	"-I":                {},
+	"--include_path":    {},
+	"-t":                {},
+	"--transformer":     {},
+	"-f":                {},
+	"--filter":          {},
+	"--generated":       {},
+	"--format":          {},
+	"--baseline":        {},
+	"--baseline_file":   {},
+	"--coverage-out":    {},
+	"--coverage-format": {},
+	"--spec-filter":     {},
+	"-j":                {},
+	"--parallel":        {},
 }
 
 func parseCommands(args []string) Commands {
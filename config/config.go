@@ -27,8 +27,28 @@ type OverrideBackend struct {
 	Unhealthy bool   `yaml:"unhealthy" default:"false"`
 }
 
+// Mocked origin response which is served instead of sending the request to the actual backend
+type MockBackend struct {
+	Backend string            `yaml:"backend"` // glob pattern of backend name, empty matches all backends
+	Method  string            `yaml:"method"`  // empty matches all methods
+	Path    string            `yaml:"path"`    // glob pattern of the request path, empty matches all paths
+	Status  int               `yaml:"status"`  // 200 if not specified
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	Latency string            `yaml:"latency"` // duration string to delay the response e.g 100ms
+}
+
 type EdgeDictionary map[string]string
 
+// Local sink of the logging endpoint which receives log statement lines in the simulator
+type LoggingEndpoint struct {
+	Type    string `yaml:"type"`    // file, stdout, syslog or http
+	Path    string `yaml:"path"`    // file path for file sink
+	Network string `yaml:"network"` // udp or tcp for syslog sink, default is udp
+	Address string `yaml:"address"` // listener address for syslog sink
+	URL     string `yaml:"url"`     // collector URL for http sink
+}
+
 // Linter configuration
 type LinterConfig struct {
 	VerboseLevel            string              `yaml:"verbose"`
@@ -38,6 +58,11 @@ type LinterConfig struct {
 	EnforceSubroutineScopes map[string][]string `yaml:"enforce_subroutine_scopes"`
 	IgnoreSubroutines       []string            `yaml:"ignore_subroutines"`
 	IsGenerated             bool                `cli:"generated"`
+	Fix                     bool                `cli:"fix"`      // Enable only in CLI option
+	Format                  string              `yaml:"format"`  // json, sarif, checkstyle or github
+	Baseline                string              `cli:"baseline"` // "write" records current findings, enable only in CLI option
+	BaselineFile            string              `cli:"baseline_file" yaml:"baseline_file" default:".falco-baseline.json"`
+	Overrides               []*LinterOverride   `yaml:"overrides"` // Per-file configurations, see ForFile
 }
 
 // Simulator configuration
@@ -45,6 +70,9 @@ type SimulatorConfig struct {
 	Port            int      `cli:"p,port" yaml:"port" default:"3124"`
 	IsDebug         bool     `cli:"debug"` // Enable only in CLI option
 	IsProxyResponse bool     `cli:"proxy"` // Enable only in CLI option
+	MaxConcurrency  int      `cli:"concurrency" yaml:"max_concurrency"`
+	IsCollapsing    bool     `cli:"collapse" yaml:"request_collapsing"`
+	IsShielding     bool     `cli:"shield" yaml:"shielding"`
 	IncludePaths    []string // Copy from root field
 
 	// HTTPS related configuration. If both fields are specified, simulator will serve with HTTPS
@@ -54,6 +82,9 @@ type SimulatorConfig struct {
 	// Inject Edge Dictionary items
 	OverrideEdgeDictionaries map[string]EdgeDictionary `yaml:"edge_dictionary"`
 
+	// Local sinks for logging endpoints, keyed by the endpoint name
+	LoggingEndpoints map[string]*LoggingEndpoint `yaml:"logging_endpoints"`
+
 	// Override Request configuration
 	OverrideRequest *RequestConfig
 
@@ -68,13 +99,20 @@ type SimulatorConfig struct {
 // Testing configuration
 type TestConfig struct {
 	Timeout      int      `cli:"timeout" yaml:"timeout"`
+	Parallel     int      `cli:"j,parallel" yaml:"parallel"` // number of test files which run concurrently
 	Filter       string   `cli:"f,filter" default:"*.test.vcl"`
+	SpecFilter   string   `cli:"spec-filter" yaml:"spec_filter" default:"*.spec.{yaml,yml,json}"`
 	Tags         []string `cli:"t,tag"`
 	IncludePaths []string // Copy from root field
 	OverrideHost string   `yaml:"host" cli:"host"`
 	Watch        bool     `cli:"w,watch"`      // Enable only in CLI option
 	Coverage     bool     `cli:"coverage"`     // Enable only in CLI option
 	CoverageOut  string   `cli:"coverage-out"` // Enable only in CLI option
+	// lcov, cobertura, html or json, guessed from the extension of coverage-out file if empty
+	CoverageFormat string `cli:"coverage-format"` // Enable only in CLI option
+	Format         string `yaml:"format"`         // json, junit, tap or github
+	// Store snapshots of testing.snapshot() instead of comparing
+	UpdateSnapshots bool `cli:"u,update-snapshots"` // Enable only in CLI option
 
 	// Override Request configuration
 	OverrideRequest *RequestConfig
@@ -129,6 +167,8 @@ type Config struct {
 	Json         bool     `cli:"json"`
 	Request      string   `cli:"request"`
 	Refresh      bool     `cli:"refresh"`
+	// Output format of the running subcommand, enable only in CLI option
+	OutputFormat string `cli:"format"`
 
 	// Remote options, only provided via environment variable
 	FastlyServiceID string `env:"FASTLY_SERVICE_ID"`
@@ -140,6 +180,9 @@ type Config struct {
 	// Override Origin fetching URL
 	OverrideBackends map[string]*OverrideBackend `yaml:"override_backends"`
 
+	// Mock origin responses for the simulator and testing
+	MockBackends []*MockBackend `yaml:"mock_backends"`
+
 	// Override resource limits
 	OverrideMaxBackends int `cli:"max_backends" yaml:"max_backends"`
 	OverrideMaxAcls     int `cli:"mac_acls" yaml:"max_acls"`
@@ -158,7 +201,8 @@ type Config struct {
 
 func New(args []string) (*Config, error) {
 	var options []twist.Option
-	if file, err := findConfigFile(); err != nil {
+	file, err := findConfigFile()
+	if err != nil {
 		return nil, errors.WithStack(err)
 	} else if file != "" {
 		options = append(options, twist.WithYaml(file))
@@ -174,6 +218,9 @@ func New(args []string) (*Config, error) {
 		return nil, errors.WithStack(err)
 	}
 	c.Commands = parseCommands(args)
+	if file != "" {
+		c.Linter.resolveOverrides(filepath.Dir(file))
+	}
 
 	// Merge verbose level
 	switch c.Linter.VerboseLevel {
@@ -183,6 +230,19 @@ func New(args []string) (*Config, error) {
 		c.Linter.VerboseInfo = true
 	}
 
+	// Output format option is applied to the running subcommand only
+	if c.OutputFormat != "" {
+		command := c.Commands.At(0)
+		if command == "terraform" {
+			command = c.Commands.At(1)
+		}
+		if command == "test" {
+			c.Testing.Format = c.OutputFormat
+		} else {
+			c.Linter.Format = c.OutputFormat
+		}
+	}
+
 	// Load request configuration if provided
 	if c.Request != "" {
 		if rc, err := LoadRequestConfig(c.Request); err == nil {
@@ -191,6 +251,11 @@ func New(args []string) (*Config, error) {
 		}
 	}
 
+	// Writing coverage report needs coverage measurement
+	if c.Testing.CoverageOut != "" {
+		c.Testing.Coverage = true
+	}
+
 	// Copy common fields
 	c.Simulator.IncludePaths = c.IncludePaths
 	c.Testing.IncludePaths = c.IncludePaths
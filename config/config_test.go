@@ -13,6 +13,8 @@ func TestParseCommand(t *testing.T) {
 		"-I",
 		".",
 		"-v",
+		"--format",
+		"sarif",
 		"foo",
 	}
 	c := parseCommands(args)
@@ -57,6 +59,7 @@ func TestConfigFromCLI(t *testing.T) {
 			VerboseWarning:    true,
 			VerboseInfo:       true,
 			IgnoreSubroutines: []string{"vcl_pipe"},
+			BaselineFile:      ".falco-baseline.json",
 		},
 		Simulator: &SimulatorConfig{
 			Port:            3124,
@@ -65,6 +68,7 @@ func TestConfigFromCLI(t *testing.T) {
 		},
 		Testing: &TestConfig{
 			Filter:          "*.test.vcl",
+			SpecFilter:      "*.spec.{yaml,yml,json}",
 			IncludePaths:    []string{"."},
 			Tags:            []string{"foo", "bar"},
 			OverrideRequest: &RequestConfig{},
@@ -112,3 +116,116 @@ func TestConfigFromEnv(t *testing.T) {
 		t.Errorf("Unmatched FastlyApiKey field, expect=%s, got=%s", "example_api_key", c.FastlyApiKey)
 	}
 }
+
+func TestLinterConfigForFile(t *testing.T) {
+	c := &LinterConfig{
+		Rules:             map[string]string{"unused/declaration": "WARNING"},
+		IgnoreSubroutines: []string{"vcl_pipe"},
+		Overrides: []*LinterOverride{
+			{
+				Files: []string{"/repo/vendor/**/*.vcl"},
+				Rules: map[string]string{"unused/declaration": "IGNORE"},
+			},
+			{
+				Files:                   []string{"/repo/generated/*.vcl", "/repo/**/snippet_*.vcl"},
+				Rules:                   map[string]string{"subroutine/boilerplate-macro": "IGNORE"},
+				EnforceSubroutineScopes: map[string][]string{"custom_recv": {"recv"}},
+				IgnoreSubroutines:       []string{"generated_sub"},
+			},
+		},
+	}
+
+	tests := []struct {
+		file   string
+		expect *LinterConfig
+	}{
+		{
+			file:   "/repo/main.vcl",
+			expect: c,
+		},
+		{
+			file:   "",
+			expect: c,
+		},
+		{
+			file: "/repo/vendor/fastly/module.vcl",
+			expect: &LinterConfig{
+				Rules:             map[string]string{"unused/declaration": "IGNORE"},
+				IgnoreSubroutines: []string{"vcl_pipe"},
+				Overrides:         c.Overrides,
+			},
+		},
+		{
+			file: "/repo/vendor/snippet_recv.vcl",
+			expect: &LinterConfig{
+				Rules: map[string]string{
+					"unused/declaration":           "IGNORE",
+					"subroutine/boilerplate-macro": "IGNORE",
+				},
+				EnforceSubroutineScopes: map[string][]string{"custom_recv": {"recv"}},
+				IgnoreSubroutines:       []string{"vcl_pipe", "generated_sub"},
+				Overrides:               c.Overrides,
+			},
+		},
+		{
+			file:   "/repo/generated/nested/main.vcl",
+			expect: c,
+		},
+	}
+
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.expect, c.ForFile(tt.file)); diff != "" {
+			t.Errorf("Unmatched config for %s, diff=%s", tt.file, diff)
+		}
+	}
+	// Base config must not be modified by merging
+	if diff := cmp.Diff(map[string]string{"unused/declaration": "WARNING"}, c.Rules); diff != "" {
+		t.Errorf("Base rules are modified, diff=%s", diff)
+	}
+}
+
+func TestResolveLinterOverrides(t *testing.T) {
+	c := &LinterConfig{
+		Overrides: []*LinterOverride{
+			{Files: []string{"vendor/**", "/abs/*.vcl"}},
+		},
+	}
+	c.resolveOverrides("/repo")
+	if diff := cmp.Diff([]string{"/repo/vendor/**", "/abs/*.vcl"}, c.Overrides[0].Files); diff != "" {
+		t.Errorf("Unmatched resolved patterns, diff=%s", diff)
+	}
+	if !c.Overrides[0].Match("/repo/vendor/a/b/c.vcl") {
+		t.Errorf("Expected to match nested vendor file")
+	}
+}
+
+func TestOutputFormatForSubcommand(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		linter string
+		test   string
+	}{
+		{name: "lint", args: []string{"lint", "--format", "sarif", "main.vcl"}, linter: "sarif"},
+		{name: "vcl file", args: []string{"main.vcl", "--format", "sarif"}, linter: "sarif"},
+		{name: "test", args: []string{"test", "--format", "junit", "main.vcl"}, test: "junit"},
+		{name: "terraform test", args: []string{"terraform", "test", "--format", "junit"}, test: "junit"},
+		{name: "stats", args: []string{"stats", "main.vcl"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := New(tt.args)
+			if err != nil {
+				t.Errorf("Failed to initialize config: %s", err)
+				return
+			}
+			if c.Linter.Format != tt.linter {
+				t.Errorf("Linter format unmatch, expect=%q, actual=%q", tt.linter, c.Linter.Format)
+			}
+			if c.Testing.Format != tt.test {
+				t.Errorf("Testing format unmatch, expect=%q, actual=%q", tt.test, c.Testing.Format)
+			}
+		})
+	}
+}
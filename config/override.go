@@ -0,0 +1,109 @@
+package config
+
+import (
+	"maps"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Linter configuration which is applied to the files matching the glob patterns.
+// Patterns support "**" to match any number of directories and are resolved
+// from the directory of the configuration file.
+type LinterOverride struct {
+	Files                   []string            `yaml:"files"`
+	Rules                   map[string]string   `yaml:"rules"`
+	EnforceSubroutineScopes map[string][]string `yaml:"enforce_subroutine_scopes"`
+	IgnoreSubroutines       []string            `yaml:"ignore_subroutines"`
+}
+
+// Match returns true if the file matches any of glob patterns
+func (o *LinterOverride) Match(file string) bool {
+	file = filepath.ToSlash(file)
+	for _, pattern := range o.Files {
+		if matchGlob(filepath.ToSlash(pattern), file) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForFile returns the effective linter configuration for the file.
+// Overrides are merged in declared order so the later override wins for the same rule or subroutine.
+// The receiver is returned as it is when no override matches.
+func (c *LinterConfig) ForFile(file string) *LinterConfig {
+	if file == "" || len(c.Overrides) == 0 {
+		return c
+	}
+
+	var merged *LinterConfig
+	for _, o := range c.Overrides {
+		if !o.Match(file) {
+			continue
+		}
+		if merged == nil {
+			clone := *c
+			clone.Rules = maps.Clone(c.Rules)
+			clone.EnforceSubroutineScopes = maps.Clone(c.EnforceSubroutineScopes)
+			clone.IgnoreSubroutines = append([]string{}, c.IgnoreSubroutines...)
+			merged = &clone
+		}
+		if len(o.Rules) > 0 && merged.Rules == nil {
+			merged.Rules = make(map[string]string)
+		}
+		maps.Copy(merged.Rules, o.Rules)
+		if len(o.EnforceSubroutineScopes) > 0 && merged.EnforceSubroutineScopes == nil {
+			merged.EnforceSubroutineScopes = make(map[string][]string)
+		}
+		maps.Copy(merged.EnforceSubroutineScopes, o.EnforceSubroutineScopes)
+		merged.IgnoreSubroutines = append(merged.IgnoreSubroutines, o.IgnoreSubroutines...)
+	}
+
+	if merged == nil {
+		return c
+	}
+	return merged
+}
+
+// resolveOverrides makes relative glob patterns absolute from the directory of the configuration file
+// because the linter reports absolute file paths
+func (c *LinterConfig) resolveOverrides(dir string) {
+	for _, o := range c.Overrides {
+		for i, pattern := range o.Files {
+			if !filepath.IsAbs(pattern) {
+				o.Files[i] = filepath.Join(dir, pattern)
+			}
+		}
+	}
+}
+
+// matchGlob matches slash separated file path with the pattern which may contain "**" segment
+func matchGlob(pattern, file string) bool {
+	patterns := strings.Split(pattern, "/")
+	segments := strings.Split(file, "/")
+
+	var match func(p, s int) bool
+	match = func(p, s int) bool {
+		for p < len(patterns) {
+			if patterns[p] == "**" {
+				// "**" matches zero or more directories
+				for i := s; i <= len(segments); i++ {
+					if match(p+1, i) {
+						return true
+					}
+				}
+				return false
+			}
+			if s >= len(segments) {
+				return false
+			}
+			if ok, err := path.Match(patterns[p], segments[s]); err != nil || !ok {
+				return false
+			}
+			p++
+			s++
+		}
+		return s == len(segments)
+	}
+	return match(0, 0)
+}
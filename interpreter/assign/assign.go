@@ -9,7 +9,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
-	"go.elara.ws/pcre"
+	"github.com/ysugimoto/falco/v2/regex"
 )
 
 // Fastly has various assignment operators and required correspond types for each operator
@@ -222,6 +222,10 @@ func Assign(left, right value.Value) error {
 		case value.BackendType: // BACKEND = BACKEND
 			rv := value.Unwrap[*value.Backend](right)
 			lv.Value = rv.Value
+			lv.Director = rv.Director
+			if rv.Healthy != nil {
+				lv.Healthy = rv.Healthy
+			}
 		default:
 			return errors.WithStack(fmt.Errorf("invalid assignment for BACKEND type, got %s", right.Type()))
 		}
@@ -263,7 +267,7 @@ func Assign(left, right value.Value) error {
 			if !rv.IsLiteral() {
 				return errors.WithStack(fmt.Errorf("string value must be a literal for REGEX assignment"))
 			}
-			_, err := pcre.Compile(rv.Value)
+			_, err := regex.Compile(rv.Value)
 			if err != nil {
 				return errors.WithStack(fmt.Errorf("failed to compile regular expression from string: %s, error: %s", rv.Value, err.Error()))
 			}
@@ -3,6 +3,7 @@ package cache
 
 import (
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,7 +11,8 @@ import (
 )
 
 const (
-	LocalDatacenterString = "cache-localsimulator-FALCO"
+	LocalDatacenterString  = "cache-localsimulator-FALCO"
+	ShieldDatacenterString = "cache-shieldsimulator-SHIELD"
 )
 
 type CacheItem struct {
@@ -20,54 +22,302 @@ type CacheItem struct {
 	Hits      int
 	LastUsed  time.Duration
 
+	// Surrogate keys which the object is tagged with, for purging by key
+	SurrogateKeys []string
+
+	// Hit-for-pass marker, the request which finds this object goes to pass
+	// see: https://www.fastly.com/documentation/guides/concepts/edge-state/cache/request-collapsing/
+	HitForPass bool
+
+	// ESI is processed on delivery of the object which is marked in vcl_fetch
+	ESI bool
+
+	// Stale object could be served within these durations after the object has been expired
+	// see: https://www.fastly.com/documentation/guides/concepts/edge-state/cache/stale/
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+
 	// private
 	requestedTime time.Time
+	mu            sync.Mutex
+	revalidating  bool
+	// stored item pointer when this item is a snapshot which is returned from Cache.Get()
+	stored *CacheItem
 }
 
 func (i *CacheItem) Update(d time.Duration) {
 	i.Expires = i.EntryTime.Add(d)
+	if i.stored != nil {
+		i.stored.mu.Lock()
+		i.stored.Expires = i.Expires
+		i.stored.mu.Unlock()
+	}
+}
+
+// TTL returns the whole lifetime of the object
+func (i *CacheItem) TTL() time.Duration {
+	return i.Expires.Sub(i.EntryTime)
+}
+
+// IsStale returns true if the object has been expired
+func (i *CacheItem) IsStale() bool {
+	return time.Now().After(i.Expires)
+}
+
+// CanServeWhileRevalidate returns true if the stale object could be served while revalidating.
+// maxStale is the limitation which is specified by req.max_stale_while_revalidate.
+func (i *CacheItem) CanServeWhileRevalidate(maxStale time.Duration) bool {
+	return time.Now().Before(i.Expires.Add(min(i.StaleWhileRevalidate, maxStale)))
+}
+
+// CanServeIfError returns true if the stale object could be served on origin error.
+// maxStale is the limitation which is specified by req.max_stale_if_error.
+func (i *CacheItem) CanServeIfError(maxStale time.Duration) bool {
+	return time.Now().Before(i.Expires.Add(min(i.StaleIfError, maxStale)))
+}
+
+// StartRevalidation marks the stored object as revalidating.
+// It returns false if the object is already being revalidated by other request.
+func (i *CacheItem) StartRevalidation() bool {
+	item := i
+	if i.stored != nil {
+		item = i.stored
+	}
+	item.mu.Lock()
+	defer item.mu.Unlock()
+
+	if item.revalidating {
+		return false
+	}
+	item.revalidating = true
+	return true
+}
+
+// FinishRevalidation unmarks revalidating flag for the stored object
+func (i *CacheItem) FinishRevalidation() {
+	item := i
+	if i.stored != nil {
+		item = i.stored
+	}
+	item.mu.Lock()
+	defer item.mu.Unlock()
+
+	item.revalidating = false
+}
+
+// Stale object is kept in the storage until both of stale durations have elapsed
+func (i *CacheItem) isEvictable() bool {
+	return time.Now().After(i.Expires.Add(max(i.StaleWhileRevalidate, i.StaleIfError)))
 }
 
 type Cache struct {
-	storage sync.Map
+	mu      sync.RWMutex
+	storage map[string]*CacheItem
+	// Surrogate-Key index, map of surrogate key to the set of request hashes
+	keys map[string]map[string]struct{}
+	// Busy objects which are being fetched from the origin, the channel is closed on release
+	busy map[string]chan struct{}
 }
 
 func New() *Cache {
-	return &Cache{}
+	return &Cache{
+		storage: make(map[string]*CacheItem),
+		keys:    make(map[string]map[string]struct{}),
+		busy:    make(map[string]chan struct{}),
+	}
+}
+
+// Acquire marks the object of the hash as busy, which means the caller fetches the object from the origin.
+// Returns nil when the caller acquired the object, otherwise returns the channel
+// which is closed when the other request releases the busy object.
+func (c *Cache) Acquire(hash string) <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if wait, ok := c.busy[hash]; ok {
+		return wait
+	}
+	c.busy[hash] = make(chan struct{})
+	return nil
+}
+
+// Release unmarks the busy object and wakes up all waiting requests
+func (c *Cache) Release(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if wait, ok := c.busy[hash]; ok {
+		close(wait)
+		delete(c.busy, hash)
+	}
 }
 
 func (c *Cache) Set(hash string, item *CacheItem) {
 	item.requestedTime = item.EntryTime
-	c.storage.Store(hash, item)
+	item.SurrogateKeys = parseSurrogateKeys(item.Response)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop the index of the object which will be replaced
+	if old, ok := c.storage[hash]; ok {
+		c.unindex(hash, old)
+	}
+	c.storage[hash] = item
+	for _, key := range item.SurrogateKeys {
+		if _, ok := c.keys[key]; !ok {
+			c.keys[key] = make(map[string]struct{})
+		}
+		c.keys[key][hash] = struct{}{}
+	}
 }
 
 func (c *Cache) Get(hash string) *CacheItem {
-	// Load and cast to *CacheItem
-	v, ok := c.storage.Load(hash)
+	c.mu.RLock()
+	item, ok := c.storage[hash]
+	c.mu.RUnlock()
 	if !ok {
 		return nil
 	}
-	item, ok := v.(*CacheItem)
-	if !ok {
-		return nil
-	}
-	// Check expiration
-	if time.Now().After(item.Expires) {
-		c.storage.Delete(hash)
+
+	item.mu.Lock()
+	// Check expiration, note that stale object is returned
+	// because it may be served as stale-while-revalidate or stale-if-error
+	if item.isEvictable() {
+		// Release the item lock before deleting because purge locks the cache and then the item
+		item.mu.Unlock()
+		c.delete(hash, item)
 		return nil
 	}
+	defer item.mu.Unlock()
 
 	// Update cache state - increment Hit count, update last used time
 	item.Hits++
 	item.LastUsed = time.Since(item.requestedTime)
 	item.requestedTime = time.Now()
-	return item
+
+	// Return the snapshot of the stored item because the stored one may be updated
+	// by other requests which are processed concurrently
+	return &CacheItem{
+		Response:      item.Response,
+		Expires:       item.Expires,
+		EntryTime:     item.EntryTime,
+		Hits:          item.Hits,
+		LastUsed:      item.LastUsed,
+		SurrogateKeys: item.SurrogateKeys,
+		HitForPass:    item.HitForPass,
+		ESI:           item.ESI,
+		requestedTime: item.requestedTime,
+		stored:        item,
+
+		StaleWhileRevalidate: item.StaleWhileRevalidate,
+		StaleIfError:         item.StaleIfError,
+	}
+}
+
+// Purge purges the object which is stored by the request hash.
+// When soft is true, the object is marked as stale instead of being evicted
+// so that it could still be served as stale-while-revalidate or stale-if-error.
+// Returns true if the object exists.
+func (c *Cache) Purge(hash string, soft bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.storage[hash]
+	if !ok {
+		return false
+	}
+	c.purge(hash, item, soft)
+	return true
 }
 
-// Fastly follows its own cache freshness rules
-// see: https://developer.fastly.com/learning/concepts/cache-freshness/
-var unCacheableStatusCodes = []int{200, 203, 300, 301, 302, 404, 410}
+// PurgeKey purges all objects which are tagged with the surrogate key.
+// Returns the number of purged objects.
+func (c *Cache) PurgeKey(key string, soft bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	hashes, ok := c.keys[key]
+	if !ok {
+		return 0
+	}
+	var purged int
+	for hash := range hashes {
+		if item, ok := c.storage[hash]; ok {
+			c.purge(hash, item, soft)
+			purged++
+		}
+	}
+	return purged
+}
 
-func IsCacheableStatusCode(statusCode int) bool {
-	return slices.Contains(unCacheableStatusCodes, statusCode)
+// PurgeAll purges all objects in the cache.
+// Note that Fastly does not support soft purge for purge-all, objects are always evicted.
+// Returns the number of purged objects.
+func (c *Cache) PurgeAll() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := len(c.storage)
+	c.storage = make(map[string]*CacheItem)
+	c.keys = make(map[string]map[string]struct{})
+	return purged
+}
+
+// Caller must hold the write lock of the cache
+func (c *Cache) purge(hash string, item *CacheItem, soft bool) {
+	if !soft {
+		delete(c.storage, hash)
+		c.unindex(hash, item)
+		return
+	}
+	item.mu.Lock()
+	defer item.mu.Unlock()
+	// Make the object stale, and keep the stale object in the storage
+	if now := time.Now(); item.Expires.After(now) {
+		item.Expires = now
+	}
+}
+
+// Delete the object only if the stored item is the same one
+// because the object may be replaced by other request while the lock is released
+func (c *Cache) delete(hash string, item *CacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored, ok := c.storage[hash]; ok && stored == item {
+		delete(c.storage, hash)
+		c.unindex(hash, item)
+	}
+}
+
+// Caller must hold the write lock of the cache
+func (c *Cache) unindex(hash string, item *CacheItem) {
+	for _, key := range item.SurrogateKeys {
+		hashes, ok := c.keys[key]
+		if !ok {
+			continue
+		}
+		delete(hashes, hash)
+		if len(hashes) == 0 {
+			delete(c.keys, key)
+		}
+	}
+}
+
+// Surrogate-Key header value is space separated keys
+// see: https://www.fastly.com/documentation/reference/http/http-headers/Surrogate-Key/
+func parseSurrogateKeys(resp *http.Response) []string {
+	if resp == nil || resp.Response == nil {
+		return nil
+	}
+	var keys []string
+	for _, v := range resp.Header.Values("Surrogate-Key") {
+		for _, key := range strings.Fields(v) {
+			if !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
 }
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	ghttp "net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+)
+
+// Fastly follows its own cache freshness rules
+// see: https://developer.fastly.com/learning/concepts/cache-freshness/
+var cacheableStatusCodes = []int{200, 203, 300, 301, 302, 404, 410}
+
+func IsCacheableStatusCode(statusCode int) bool {
+	return slices.Contains(cacheableStatusCodes, statusCode)
+}
+
+// DefaultTTL is applied when the response does not have any freshness information
+const DefaultTTL = 2 * time.Minute
+
+// CacheControl represents directives of Cache-Control or Surrogate-Control header.
+// Directive names are stored as lower case and values are unquoted.
+// see: https://www.rfc-editor.org/rfc/rfc9111#section-5.2
+type CacheControl map[string]string
+
+func ParseCacheControl(values []string) CacheControl {
+	cc := CacheControl{}
+	for _, v := range values {
+		for _, directive := range strings.Split(v, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			key, val, _ := strings.Cut(directive, "=")
+			key = strings.ToLower(strings.TrimSpace(key))
+			// First occurrence wins when the same directive is duplicated
+			if _, ok := cc[key]; ok {
+				continue
+			}
+			cc[key] = strings.Trim(strings.TrimSpace(val), `"`)
+		}
+	}
+	return cc
+}
+
+// Has returns true if the directive is present
+func (c CacheControl) Has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+// Seconds returns the delta-seconds value of the directive.
+// The second return value is false when the directive is not present or has invalid value.
+func (c CacheControl) Seconds(directive string) (time.Duration, bool) {
+	v, ok := c[directive]
+	if !ok {
+		return 0, false
+	}
+	sec, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || sec < 0 {
+		return 0, false
+	}
+	return time.Duration(sec) * time.Second, true
+}
+
+// DetermineTTL returns the object TTL following Fastly's freshness rules. The precedence is:
+// 1. max-age directive in Surrogate-Control header
+// 2. s-maxage directive in Cache-Control header
+// 3. max-age directive in Cache-Control header
+// 4. Expires header, relative to Date header if present
+// 5. Default TTL
+// see: https://www.fastly.com/documentation/guides/concepts/edge-state/cache/cache-freshness/
+func DetermineTTL(resp *http.Response) time.Duration {
+	if ttl, ok := ParseCacheControl(resp.Header.Values("Surrogate-Control")).Seconds("max-age"); ok {
+		return ttl
+	}
+	cc := ParseCacheControl(resp.Header.Values("Cache-Control"))
+	if ttl, ok := cc.Seconds("s-maxage"); ok {
+		return ttl
+	}
+	if ttl, ok := cc.Seconds("max-age"); ok {
+		return ttl
+	}
+	if v := resp.Header.Get("Expires"); v != "" {
+		expires, err := ghttp.ParseTime(v)
+		// Invalid Expires value, like "0", means already expired
+		if err != nil {
+			return 0
+		}
+		now := time.Now()
+		if date, err := ghttp.ParseTime(resp.Header.Get("Date")); err == nil {
+			now = date
+		}
+		return max(expires.Sub(now), 0)
+	}
+	return DefaultTTL
+}
+
+// DetermineStaleTTL returns stale-while-revalidate and stale-if-error durations
+// which are specified in Surrogate-Control or Cache-Control header.
+// Surrogate-Control takes precedence over Cache-Control.
+// see: https://www.fastly.com/documentation/guides/concepts/edge-state/cache/stale/
+func DetermineStaleTTL(resp *http.Response) (time.Duration, time.Duration) {
+	var swr, sie time.Duration
+	for _, name := range []string{"Cache-Control", "Surrogate-Control"} {
+		cc := ParseCacheControl(resp.Header.Values(name))
+		if v, ok := cc.Seconds("stale-while-revalidate"); ok {
+			swr = v
+		}
+		if v, ok := cc.Seconds("stale-if-error"); ok {
+			sie = v
+		}
+	}
+	return swr, sie
+}
+
+// IsCacheable returns true if the response could be stored in the cache.
+// Fastly does not cache the response which has uncacheable status code,
+// private or no-store directive in Cache-Control header, or Set-Cookie header.
+func IsCacheable(resp *http.Response) bool {
+	if !IsCacheableStatusCode(resp.StatusCode) {
+		return false
+	}
+	cc := ParseCacheControl(resp.Header.Values("Cache-Control"))
+	if cc.Has("private") || cc.Has("no-store") {
+		return false
+	}
+	return resp.Header.Get("Set-Cookie") == ""
+}
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	ghttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+)
+
+func makeResponse(status int, headers map[string]string) *http.Response {
+	resp := &ghttp.Response{
+		StatusCode: status,
+		Header:     ghttp.Header{},
+	}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return http.WrapResponse(resp)
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := ParseCacheControl([]string{`public, Max-Age="600"`, "s-maxage=10, max-age=30, no-transform"})
+
+	if !cc.Has("public") || !cc.Has("no-transform") {
+		t.Errorf("Valueless directives must be parsed: %v", cc)
+	}
+	if v, ok := cc.Seconds("max-age"); !ok || v != 600*time.Second {
+		t.Errorf("max-age mismatch, expect=600s, got=%s", v)
+	}
+	if v, ok := cc.Seconds("s-maxage"); !ok || v != 10*time.Second {
+		t.Errorf("s-maxage mismatch, expect=10s, got=%s", v)
+	}
+	if _, ok := ParseCacheControl([]string{"max-age=-1"}).Seconds("max-age"); ok {
+		t.Errorf("Negative delta-seconds must be invalid")
+	}
+}
+
+func TestDetermineTTL(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		expect  time.Duration
+	}{
+		{
+			name:    "max-age which is not the first directive",
+			headers: map[string]string{"Cache-Control": "public, max-age=600"},
+			expect:  600 * time.Second,
+		},
+		{
+			name:    "s-maxage takes precedence over max-age",
+			headers: map[string]string{"Cache-Control": "max-age=600, s-maxage=300"},
+			expect:  300 * time.Second,
+		},
+		{
+			name: "Surrogate-Control takes precedence over Cache-Control",
+			headers: map[string]string{
+				"Surrogate-Control": "max-age=3600",
+				"Cache-Control":     "s-maxage=300",
+			},
+			expect: 3600 * time.Second,
+		},
+		{
+			name: "Cache-Control takes precedence over Expires",
+			headers: map[string]string{
+				"Cache-Control": "max-age=60",
+				"Expires":       now.Add(time.Hour).Format(ghttp.TimeFormat),
+			},
+			expect: 60 * time.Second,
+		},
+		{
+			name: "Expires relative to Date",
+			headers: map[string]string{
+				"Date":    now.Format(ghttp.TimeFormat),
+				"Expires": now.Add(time.Hour).Format(ghttp.TimeFormat),
+			},
+			expect: time.Hour,
+		},
+		{
+			name:    "invalid Expires",
+			headers: map[string]string{"Expires": "0"},
+			expect:  0,
+		},
+		{
+			name:    "private does not have freshness information",
+			headers: map[string]string{"Cache-Control": "private"},
+			expect:  DefaultTTL,
+		},
+		{
+			name:    "no freshness information",
+			headers: map[string]string{},
+			expect:  DefaultTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ttl := DetermineTTL(makeResponse(200, tt.headers)); ttl != tt.expect {
+				t.Errorf("TTL mismatch, expect=%s, got=%s", tt.expect, ttl)
+			}
+		})
+	}
+}
+
+func TestDetermineStaleTTL(t *testing.T) {
+	swr, sie := DetermineStaleTTL(makeResponse(200, map[string]string{
+		"Cache-Control":     "max-age=60, stale-while-revalidate=30, stale-if-error=86400",
+		"Surrogate-Control": "stale-while-revalidate=10",
+	}))
+	if swr != 10*time.Second {
+		t.Errorf("stale-while-revalidate mismatch, expect=10s, got=%s", swr)
+	}
+	if sie != 86400*time.Second {
+		t.Errorf("stale-if-error mismatch, expect=86400s, got=%s", sie)
+	}
+}
+
+func TestIsCacheable(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		expect  bool
+	}{
+		{name: "cacheable status", status: 200, expect: true},
+		{name: "uncacheable status", status: 500, expect: false},
+		{name: "public", status: 200, headers: map[string]string{"Cache-Control": "public, max-age=600"}, expect: true},
+		{name: "private", status: 200, headers: map[string]string{"Cache-Control": "private"}, expect: false},
+		{name: "no-store", status: 404, headers: map[string]string{"Cache-Control": "max-age=60, no-store"}, expect: false},
+		{name: "Set-Cookie", status: 200, headers: map[string]string{"Set-Cookie": "session=foo"}, expect: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if v := IsCacheable(makeResponse(tt.status, tt.headers)); v != tt.expect {
+				t.Errorf("Cacheable mismatch, expect=%t, got=%t", tt.expect, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestRequestCollapsing(t *testing.T) {
+	// Origin server records the number of requests and the max number of requests in flight
+	var count, inflight, maxInflight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		name        string
+		vcl         string
+		collapsing  bool
+		expectCount int32
+		// expected state of the request which is sent after concurrent requests
+		expectState string
+		serialized  bool
+	}{
+		{
+			name:        "concurrent misses are collapsed into a single fetch",
+			vcl:         `sub vcl_recv { return (lookup); }`,
+			collapsing:  true,
+			expectCount: 1,
+			expectState: "HIT",
+		},
+		{
+			name:        "concurrent misses are fetched independently without collapsing",
+			vcl:         `sub vcl_recv { return (lookup); }`,
+			collapsing:  false,
+			expectCount: 4,
+			expectState: "HIT",
+		},
+		{
+			name: "req.hash_ignore_busy skips waiting for the busy object",
+			vcl: `
+sub vcl_recv {
+	set req.hash_ignore_busy = true;
+	return (lookup);
+}`,
+			collapsing:  true,
+			expectCount: 4,
+			expectState: "HIT",
+		},
+		{
+			name: "hit-for-pass marker lets waiting requests pass",
+			vcl: `
+sub vcl_recv { return (lookup); }
+sub vcl_fetch {
+	set beresp.ttl = 60s;
+	return (pass);
+}`,
+			collapsing:  true,
+			expectCount: 5,
+			expectState: "HITPASS",
+		},
+		{
+			name: "uncacheable response serializes waiting requests",
+			vcl: `
+sub vcl_recv { return (lookup); }
+sub vcl_fetch {
+	set beresp.cacheable = false;
+}`,
+			collapsing:  true,
+			expectCount: 5,
+			expectState: "MISS",
+			serialized:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count.Store(0)
+			maxInflight.Store(0)
+			ip := New(
+				context.WithResolver(resolver.NewStaticResolver("main", defaultBackend(parsed)+tt.vcl)),
+				context.WithActualResponse(true),
+				context.WithRequestCollapsing(tt.collapsing),
+			)
+			handler := ip.ConcurrentHandler(0)
+			send := func() *http.Response {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+				return rec.Result()
+			}
+
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if resp := send(); resp.StatusCode != http.StatusOK {
+						t.Errorf("Unexpected HTTP status from interpreter %d", resp.StatusCode)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if v := send().Header.Get("X-Cache"); v != tt.expectState {
+				t.Errorf("State mismatch, expect=%s, got=%s", tt.expectState, v)
+			}
+			if n := count.Load(); n != tt.expectCount {
+				t.Errorf("Origin request count mismatch, expect=%d, got=%d", tt.expectCount, n)
+			}
+			if n := maxInflight.Load(); tt.serialized && n != 1 {
+				t.Errorf("Origin requests must be serialized, max inflight=%d", n)
+			}
+		})
+	}
+}
@@ -3,12 +3,14 @@ package context
 import (
 	"fmt"
 	"math/rand"
+	ghttp "net/http"
 	"time"
 
 	"github.com/ysugimoto/falco/v2/ast"
 	"github.com/ysugimoto/falco/v2/config"
 	"github.com/ysugimoto/falco/v2/interpreter/cache"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
 	"github.com/ysugimoto/falco/v2/resolver"
 	"github.com/ysugimoto/falco/v2/snippet"
@@ -58,26 +60,46 @@ type Context struct {
 	SubroutineFunctions map[string]*ast.SubroutineDeclaration
 	OriginalHost        string
 	IsActualResponse    bool
+	IsRequestCollapsing bool
+	IsShieldNode        bool          // true if the interpreter runs as the shield node in shielding mode
+	Shield              ghttp.Handler // shield node handler which shield director sends the request to
+	LoggingSinks        logging.Sinks // local sinks which log lines with the logging endpoint prefix are routed to
 
 	OverrideMaxBackends    int
 	OverrideMaxAcls        int
 	OverrideRequest        *config.RequestConfig
 	OverrideBackends       map[string]*config.OverrideBackend
+	MockBackends           []*MockBackend // the first matched mock responds instead of the backend
 	InjectEdgeDictionaries map[string]config.EdgeDictionary
 
 	// Mocking subroutines map
 	MockedSubroutines            map[string]*ast.SubroutineDeclaration
 	MockedFunctioncalSubroutines map[string]*ast.SubroutineDeclaration
 
+	// Requests which are sent to the origins, recorded for testing only when recording is enabled
+	IsOriginRequestRecording bool
+	OriginRequests           []*OriginRequest
+
 	Request          *http.Request
 	BackendRequest   *http.Request
 	BackendResponse  *http.Response
 	Object           *http.Response
 	Response         *http.Response
 	Scope            Scope
-	RequestEndTime   time.Time
+	RequestEndTime   time.Time // set when the response has been delivered, before vcl_log
 	RequestStartTime time.Time
+	FirstByteTime    time.Time // set when the response starts to be delivered after vcl_deliver
 	CacheHitItem     *cache.CacheItem
+	// Stale cache object which could be delivered by deliver_stale state on origin error
+	StaleCacheItem *cache.CacheItem
+	// True if the request is a background fetch to revalidate a stale object
+	IsBackgroundFetch bool
+	// True if the backend request is sent through vcl_pass, the response is never cached
+	IsPassRequest bool
+	// Concrete backend which the backend request is sent to, the director determines it
+	FetchBackend *value.Backend
+	// Number of times the request has visited this service before this node, counted from Fastly-FF header
+	FastlyFFVisits int
 
 	// RequestWorkspaceBytes tracks how much of the per-request workspace has been
 	// consumed by assembling request headers. Fastly never reclaims it within a
@@ -148,6 +170,7 @@ type Context struct {
 	BackendResponseStatus               *value.Integer
 	BackendResponseTTL                  *value.RTime
 	ObjectGrace                         *value.RTime
+	ObjectStaleWhileRevalidate          *value.RTime
 	ObjectTTL                           *value.RTime
 	ObjectStatus                        *value.Integer
 	ObjectResponse                      *value.String
@@ -182,6 +205,8 @@ type Context struct {
 	// However, Fastly document says the esi will be triggered when esi statement is executed in FETCH directive.
 	// see: https://developer.fastly.com/reference/vcl/statements/esi/
 	TriggerESI bool
+	// URL of the top level request, only set in ESI subrequest
+	TopURL string
 
 	// Marker that return status is called.
 	// This field is used for ensuring some state machine subroutine returns new state via return statement.
@@ -220,7 +245,7 @@ func New(options ...Option) *Context {
 		Stale:                           &value.Boolean{},
 		StaleIsError:                    &value.Boolean{},
 		StaleIsRevalidating:             &value.Boolean{},
-		StaleContents:                   &value.String{},
+		StaleContents:                   &value.String{IsNotSet: true},
 		FastlyError:                     &value.String{},
 		ClientGeoIpOverride:             &value.String{},
 		ClientSocketCongestionAlgorithm: &value.String{Value: "cubic"},
@@ -230,7 +255,7 @@ func New(options ...Option) *Context {
 		EsiAllowInsideCData:             &value.Boolean{},
 		EnableRangeOnPass:               &value.Boolean{},
 		EnableSegmentedCaching:          &value.Boolean{},
-		EnableSSI:                       &value.Boolean{},
+		EnableSSI:                       &value.Boolean{Value: true},
 		HashAlwaysMiss:                  &value.Boolean{},
 		HashIgnoreBusy:                  &value.Boolean{},
 		SegmentedCacheingBlockSize:      &value.Integer{},
@@ -280,6 +305,7 @@ func New(options ...Option) *Context {
 		BackendResponseStatus:               &value.Integer{},
 		BackendResponseTTL:                  &value.RTime{},
 		ObjectGrace:                         &value.RTime{},
+		ObjectStaleWhileRevalidate:          &value.RTime{},
 		ObjectTTL:                           &value.RTime{},
 		ObjectStatus:                        &value.Integer{Value: 500},
 		ObjectResponse:                      &value.String{IsNotSet: true},
@@ -300,3 +326,13 @@ func New(options ...Option) *Context {
 
 	return ctx
 }
+
+// OriginRequest is the record of the backend request as seen by the origin
+type OriginRequest struct {
+	Backend string
+	Method  string
+	URL     string
+	Header  ghttp.Header
+	Body    string
+	Mocked  bool // true if the request is responded by the mock backend
+}
@@ -0,0 +1,63 @@
+package context
+
+import (
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/config"
+)
+
+// MockBackend is the mocked origin response whose glob patterns are compiled in advance
+type MockBackend struct {
+	*config.MockBackend
+	backend glob.Glob // nil matches all backends
+	path    glob.Glob // nil matches all paths
+}
+
+// NewMockBackend compiles glob patterns of backend name and request path
+func NewMockBackend(m *config.MockBackend) (*MockBackend, error) {
+	mock := &MockBackend{MockBackend: m}
+	if m.Backend != "" {
+		p, err := glob.Compile(m.Backend)
+		if err != nil {
+			return nil, errors.Errorf("Invalid glob pattern is provided: %s, %s", m.Backend, err)
+		}
+		mock.backend = p
+	}
+	if m.Path != "" {
+		p, err := glob.Compile(m.Path, '/')
+		if err != nil {
+			return nil, errors.Errorf("Invalid glob pattern is provided: %s, %s", m.Path, err)
+		}
+		mock.path = p
+	}
+	return mock, nil
+}
+
+// NewMockBackends compiles mocks in the configuration, the order is kept because the first matched mock responds
+func NewMockBackends(mocks []*config.MockBackend) ([]*MockBackend, error) {
+	compiled := make([]*MockBackend, len(mocks))
+	for i, m := range mocks {
+		mock, err := NewMockBackend(m)
+		if err != nil {
+			return nil, err
+		}
+		compiled[i] = mock
+	}
+	return compiled, nil
+}
+
+// Match returns true if the mock matches the backend name, method and path of the request
+func (m *MockBackend) Match(backendName, method, path string) bool {
+	if m.Method != "" && !strings.EqualFold(m.Method, method) {
+		return false
+	}
+	if m.backend != nil && !m.backend.Match(backendName) {
+		return false
+	}
+	if m.path != nil && !m.path.Match(path) {
+		return false
+	}
+	return true
+}
@@ -1,9 +1,11 @@
 package context
 
 import (
+	ghttp "net/http"
 	"time"
 
 	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
 	"github.com/ysugimoto/falco/v2/resolver"
 	"github.com/ysugimoto/falco/v2/snippet"
@@ -48,6 +50,18 @@ func WithOverrideBackends(ov map[string]*config.OverrideBackend) Option {
 	}
 }
 
+func WithMockBackends(mocks []*MockBackend) Option {
+	return func(c *Context) {
+		c.MockBackends = mocks
+	}
+}
+
+func WithOriginRequestRecording(is bool) Option {
+	return func(c *Context) {
+		c.IsOriginRequestRecording = is
+	}
+}
+
 func WithOverrideHost(host string) Option {
 	return func(c *Context) {
 		c.OriginalHost = host
@@ -66,6 +80,30 @@ func WithActualResponse(is bool) Option {
 	}
 }
 
+func WithRequestCollapsing(is bool) Option {
+	return func(c *Context) {
+		c.IsRequestCollapsing = is
+	}
+}
+
+func WithShieldNode(is bool) Option {
+	return func(c *Context) {
+		c.IsShieldNode = is
+	}
+}
+
+func WithShield(shield ghttp.Handler) Option {
+	return func(c *Context) {
+		c.Shield = shield
+	}
+}
+
+func WithLoggingSinks(sinks logging.Sinks) Option {
+	return func(c *Context) {
+		c.LoggingSinks = sinks
+	}
+}
+
 func WithOverrideVariables(variables map[string]any) Option {
 	return func(c *Context) {
 		for k, v := range variables {
@@ -339,6 +339,11 @@ func (i *Interpreter) createMarker(t shared.CoverageType, node ast.Node, suffix
 	if len(suffix) > 0 {
 		s = "_" + strings.Join(suffix, "_")
 	}
+	// Nodes in the included files may have the same position as the main VCL,
+	// so the file name is added to distinguish them from the nodes in the main VCL
+	if tok.File != "" && tok.File != i.mainFile {
+		s += "@" + tok.File
+	}
 
 	var id string
 	switch t {
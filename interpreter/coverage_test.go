@@ -100,6 +100,10 @@ sub instrument2 {
 					"stmt_3_2": {Type: token.SET, Literal: "set", Line: 3, Position: 2},
 					"stmt_6_2": {Type: token.SET, Literal: "set", Line: 6, Position: 2},
 				},
+				Names: map[string]string{
+					"sub_2_1": "instrument1",
+					"sub_5_1": "instrument2",
+				},
 			},
 		},
 	}
@@ -215,6 +219,9 @@ sub instrument {
 					"branch_11_3_1": {Type: token.IF, Literal: "if", Line: 11, Position: 3},
 					"branch_11_3_2": {Type: token.IF, Literal: "if", Line: 11, Position: 3},
 				},
+				Names: map[string]string{
+					"sub_2_1": "instrument",
+				},
 			},
 		},
 	}
@@ -329,6 +336,9 @@ sub instrument {
 					"branch_11_2":  {Type: token.CASE, Literal: "case", Line: 11, Position: 2},
 					"branch_14_2":  {Type: token.DEFAULT, Literal: "default", Line: 14, Position: 2},
 				},
+				Names: map[string]string{
+					"sub_2_1": "instrument",
+				},
 			},
 		},
 	}
@@ -378,8 +388,30 @@ sub instrument {
 					"branch_4_14_true":  {Type: token.IF, Literal: "if", Line: 4, Position: 14},
 					"branch_4_14_false": {Type: token.IF, Literal: "if", Line: 4, Position: 14},
 				},
+				Names: map[string]string{
+					"sub_2_1": "instrument",
+				},
 			},
 		},
 	}
 	assertInstrument(t, tests)
 }
+
+func TestCoverageMarkerKey(t *testing.T) {
+	c := shared.NewCoverage()
+	ip := &Interpreter{
+		ctx:      context.New(context.WithCoverage(c)),
+		mainFile: "main.vcl",
+	}
+	for _, file := range []string{"main.vcl", "include.vcl"} {
+		ip.createMarker(shared.CoverageTypeStatement, &ast.EsiStatement{
+			Meta: &ast.Meta{Token: token.Token{File: file, Line: 3, Position: 2}},
+		})
+	}
+
+	// Keys of the main VCL nodes have only the position
+	expect := shared.CoverageFactoryItem{"stmt_3_2": 0, "stmt_3_2@include.vcl": 0}
+	if diff := cmp.Diff(expect, c.Factory().Statements); diff != "" {
+		t.Errorf("coverage keys mismatch, diff=%s", diff)
+	}
+}
@@ -34,3 +34,13 @@ func (d DefaultDebugger) Message(msg string) {
 func (d DefaultDebugger) Log(stmt *ast.LogStatement, value string) {
 	fmt.Fprintln(os.Stderr, value)
 }
+
+// Debugger for the background fetch which is not bound to any client request.
+// It never stops on the breakpoint but messages are passed to the underlying debugger.
+type backgroundDebugger struct {
+	Debugger
+}
+
+func (d backgroundDebugger) Run(node ast.Node) DebugState {
+	return DebugPass
+}
@@ -236,6 +236,9 @@ func (i *Interpreter) createDirectorRequest(ctx *context.Context, dc *value.Dire
 		backend, err = i.directorBackendClient(dc)
 	case value.DIRECTORTYPE_CHASH:
 		backend, err = i.directorBackendConsistentHash(dc)
+	case value.DIRECTORTYPE_SHIELD:
+		// Shield director sends the request to the shield node instead of the backend
+		return i.createShieldRequest(ctx, dc)
 	default:
 		return nil, exception.System("Unexpected director type '%s' provided", dc.Type)
 	}
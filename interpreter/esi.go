@@ -2,23 +2,33 @@ package interpreter
 
 import (
 	"bytes"
-	"context"
+	gocontext "context"
+	"fmt"
 	"io"
+	ghttp "net/http"
 	"net/url"
-	"path/filepath"
-	"regexp"
-	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/esi"
 	"github.com/ysugimoto/falco/v2/interpreter/exception"
-	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/limits"
 )
 
-var (
-	esiIncludeRegex = regexp.MustCompile(`<esi:include\s*src=['"]([^'"]+)['"]\s*/?\s*>`)
-	esiRemoveStart  = []byte("<esi:remove>")
-	esiRemoveEnd    = []byte("</esi:remove>")
-)
+// ESI subrequest timeout
+const esiIncludeTimeout = 10 * time.Second
+
+// ESI is processed for the object which is marked by esi statement or beresp.do_esi in vcl_fetch,
+// including the cached object, unless req.esi is disabled.
+func (i *Interpreter) shouldProcessESI() bool {
+	if !i.ctx.EnableSSI.Value {
+		return false
+	}
+	if i.ctx.CacheHitItem != nil {
+		return i.ctx.CacheHitItem.ESI
+	}
+	return i.ctx.TriggerESI
+}
 
 func (i *Interpreter) executeESI() error {
 	resp := i.ctx.Response
@@ -28,100 +38,112 @@ func (i *Interpreter) executeESI() error {
 
 	var respBody bytes.Buffer
 	if _, err := respBody.ReadFrom(resp.Body); err != nil {
-		return err
+		return errors.WithStack(err)
+	}
+	nodes, err := esi.Parse(respBody.Bytes(), i.ctx.EsiAllowInsideCData.Value)
+	if err != nil {
+		return exception.Runtime(nil, "Failed to parse ESI: %s", err)
 	}
 
-	body := respBody.Bytes()
-	req := i.ctx.Request
-	ctx := i.ctx.Request.Context()
 	var parsed []byte
-	for {
-		match := esiIncludeRegex.FindSubmatchIndex(body)
-		if len(match) == 0 {
-			break
+	for _, node := range nodes {
+		switch node.Type {
+		case esi.TextNode:
+			parsed = append(parsed, node.Text...)
+			continue
+		case esi.UnsupportedNode:
+			i.Debugger.Message(fmt.Sprintf("ESI tag <esi:%s> is not supported on Fastly, output as it is", node.Name))
+			parsed = append(parsed, node.Text...)
+			continue
 		}
-		previous := body[0:match[0]]
-		src := body[match[2]:match[3]]
-		body = body[match[1]:]
-		parsed = append(parsed, previous...)
-
-		// resolve inclusion
-		cloned := req.Clone(ctx)
-		partial, err := executeEsiInclude(ctx, cloned, src)
+		partial, err := i.executeESIInclude(node)
 		if err != nil {
-			// If ESI inclusion failed, find <esi:remove> tag and use its nodeText
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				break
-			}
-			body = body[index+len(esiRemoveStart):]
-
-			// Find </esi:remove> tag
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
-			}
-			parsed = append(parsed, body[0:index]...)
-			body = body[index+len(esiRemoveEnd):]
-		} else {
-			parsed = append(parsed, partial...)
-			// If ESI inclusion succeeded, find <esi:remove>...</esi:remove> tag and remove it
-			index := bytes.Index(body, esiRemoveStart)
-			if index == -1 {
-				continue
-			}
-			body = body[index+len(esiRemoveStart):]
-			index = bytes.Index(body, esiRemoveEnd)
-			if index == -1 {
-				return exception.Runtime(nil, "Syntax error: does not seem to close </esi:remove>")
-			}
-			body = body[index+len(esiRemoveEnd):]
+			return errors.WithStack(err)
 		}
+		parsed = append(parsed, partial...)
 	}
 
-	// remaining bytes
-	if len(body) > 0 {
-		parsed = append(parsed, body...)
-	}
 	resp.Body = io.NopCloser(bytes.NewReader(parsed))
+	resp.ContentLength = int64(len(parsed))
+	resp.Header.Del("Content-Length")
 	return nil
 }
 
-func executeEsiInclude(ctx context.Context, req *http.Request, includeUrl []byte) ([]byte, error) {
-	ctx, timeout := context.WithTimeout(ctx, 10*time.Second)
-	defer timeout()
+// Include source is tried at first, and then alt is tried if the source could not be included.
+// The response is included whatever the status code is, like Fastly does.
+func (i *Interpreter) executeESIInclude(node *esi.Node) ([]byte, error) {
+	level := i.ctx.ESILevel.Value + 1
+	if level > limits.MaxESIDepth {
+		return nil, exception.Runtime(nil, "ESI include depth exceeds the limit of %d", limits.MaxESIDepth)
+	}
+
+	partial, err := i.processESISubrequest(node.Src, level)
+	if err != nil && node.Alt != "" {
+		i.Debugger.Message(fmt.Sprintf("ESI include %s failed, try alt %s: %s", node.Src, node.Alt, err))
+		partial, err = i.processESISubrequest(node.Alt, level)
+	}
+	if err != nil {
+		if node.ContinueOnError {
+			i.Debugger.Message(fmt.Sprintf("ESI include %s failed, continue: %s", node.Src, err))
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	return partial, nil
+}
 
-	if err := resolveIncludeURL(req, string(includeUrl)); err != nil {
-		return nil, err
+// processESISubrequest runs whole lifecycle for the include source on the forked interpreter.
+// Subrequest has the headers of the parent request, and req.esi_level, req.topurl are set.
+func (i *Interpreter) processESISubrequest(src string, level int64) ([]byte, error) {
+	*i.esiIncludes++
+	if *i.esiIncludes > limits.MaxESIIncludes {
+		return nil, exception.Runtime(nil, "ESI includes exceed the limit of %d", limits.MaxESIIncludes)
 	}
 
-	resp, err := http.SendRequest(req.WithContext(ctx))
+	ref, err := url.Parse(src)
 	if err != nil {
-		return nil, err
+		return nil, exception.Runtime(nil, "Invalid ESI include src %s: %s", src, err)
 	}
-	defer resp.Body.Close()
+	ctx, timeout := gocontext.WithTimeout(i.ctx.Request.Context(), esiIncludeTimeout)
+	defer timeout()
+
+	req := i.request.Clone(ctx)
+	req.Method = ghttp.MethodGet
+	req.Body = ghttp.NoBody
+	req.ContentLength = 0
+	req.URL = i.ctx.Request.URL.ResolveReference(ref)
+	if ref.Host != "" {
+		req.Host = ref.Host
+	}
+	req.RequestURI = req.URL.RequestURI()
+
+	i.Debugger.Message(fmt.Sprintf("ESI subrequest (level %d) started for %s", level, req.URL.String()))
+	ip := i.fork()
+	if err := ip.ProcessInit(req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ip.ctx.ESILevel.Value = level
+	ip.ctx.TopURL = i.topURL()
+	ip.esiIncludes = i.esiIncludes
+	if err := ip.ProcessRecv(); err != nil {
+		return nil, exception.Runtime(nil, "ESI subrequest for %s failed: %s", src, err)
+	}
+	if ip.ctx.Response == nil {
+		return nil, exception.Runtime(nil, "ESI subrequest for %s did not respond", src)
+	}
+
 	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(resp.Body); err != nil {
-		return nil, err
+	if _, err := buf.ReadFrom(ip.ctx.Response.Body); err != nil {
+		return nil, errors.WithStack(err)
 	}
+	i.Debugger.Message(fmt.Sprintf("ESI subrequest (level %d) responds status code %d", level, ip.ctx.Response.StatusCode))
 	return buf.Bytes(), nil
 }
 
-func resolveIncludeURL(req *http.Request, path string) error {
-	switch {
-	case strings.HasPrefix(path, "http://"), strings.HasPrefix(path, "https://"):
-		parsed, err := url.Parse(path)
-		if err != nil {
-			return err
-		}
-		req.URL = parsed
-		req.Header.Set("Host", parsed.Host)
-		return nil
-	case strings.HasPrefix(path, "/"):
-		req.URL.Path = path
-		return nil
-	default:
-		req.URL.Path = filepath.Join(req.URL.Path, path)
-		return nil
+// req.topurl is the URL of the top level request in ESI subrequests
+func (i *Interpreter) topURL() string {
+	if i.ctx.TopURL != "" {
+		return i.ctx.TopURL
 	}
+	return i.ctx.Request.URL.RequestURI()
 }
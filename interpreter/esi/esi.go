@@ -0,0 +1,222 @@
+package esi
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Parse ESI markups which Fastly supports.
+// see: https://www.fastly.com/documentation/guides/full-site-delivery/performance/using-edge-side-includes/
+//
+// - <esi:include src="..." />: replaced with the included response body
+// - <esi:remove>...</esi:remove>: removed with its content
+// - <esi:comment text="..." />: removed
+// - <!--esi ... -->: comment markers are removed and the content is processed
+//
+// Fastly does not support other ESI tags like <esi:choose>, <esi:when>, <esi:otherwise>, <esi:vars> or <esi:try>,
+// so they are parsed as UnsupportedNode and output as it is without evaluating variables and conditions.
+
+var (
+	esiCommentStart = []byte("<!--esi")
+	esiCommentEnd   = []byte("-->")
+	esiTagStart     = []byte("<esi:")
+	cdataStart      = []byte("<![CDATA[")
+	cdataEnd        = []byte("]]>")
+
+	attributeRegex = regexp.MustCompile(`([a-zA-Z_:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+type NodeType int
+
+const (
+	TextNode NodeType = iota
+	IncludeNode
+	UnsupportedNode
+)
+
+// Node is a fragment of the parsed document
+type Node struct {
+	Type NodeType
+	// Raw bytes for TextNode and UnsupportedNode
+	Text []byte
+	// Tag name for UnsupportedNode like "choose"
+	Name string
+	// Attributes for IncludeNode
+	Src             string
+	Alt             string
+	ContinueOnError bool
+}
+
+type parser struct {
+	body             []byte
+	allowInsideCData bool
+	nodes            []*Node
+	text             []byte
+}
+
+// Parse parses ESI document. When allowInsideCData is false, markups in CDATA section are not processed.
+func Parse(body []byte, allowInsideCData bool) ([]*Node, error) {
+	p := &parser{body: body, allowInsideCData: allowInsideCData}
+	if err := p.parse(body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p.flush()
+	return p.nodes, nil
+}
+
+func (p *parser) flush() {
+	if len(p.text) > 0 {
+		p.nodes = append(p.nodes, &Node{Type: TextNode, Text: p.text})
+		p.text = nil
+	}
+}
+
+func (p *parser) parse(body []byte) error {
+	for len(body) > 0 {
+		index := bytes.IndexByte(body, '<')
+		if index == -1 {
+			p.text = append(p.text, body...)
+			return nil
+		}
+		p.text = append(p.text, body[:index]...)
+		body = body[index:]
+
+		var consumed int
+		var err error
+		switch {
+		case !p.allowInsideCData && bytes.HasPrefix(body, cdataStart):
+			consumed = p.parseCData(body)
+		case bytes.HasPrefix(body, esiCommentStart):
+			consumed, err = p.parseComment(body)
+		case bytes.HasPrefix(body, esiTagStart):
+			consumed, err = p.parseTag(body)
+		default:
+			p.text = append(p.text, '<')
+			consumed = 1
+		}
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		body = body[consumed:]
+	}
+	return nil
+}
+
+// CDATA section is output as it is
+func (p *parser) parseCData(body []byte) int {
+	end := bytes.Index(body, cdataEnd)
+	if end == -1 {
+		p.text = append(p.text, body...)
+		return len(body)
+	}
+	end += len(cdataEnd)
+	p.text = append(p.text, body[:end]...)
+	return end
+}
+
+func (p *parser) parseComment(body []byte) (int, error) {
+	end := bytes.Index(body, esiCommentEnd)
+	if end == -1 {
+		return 0, fmt.Errorf("<!--esi is not closed")
+	}
+	if err := p.parse(body[len(esiCommentStart):end]); err != nil {
+		return 0, errors.WithStack(err)
+	}
+	return end + len(esiCommentEnd), nil
+}
+
+func (p *parser) parseTag(body []byte) (int, error) {
+	end, selfClosing := findTagEnd(body)
+	if end == -1 {
+		return 0, fmt.Errorf("ESI tag is not closed")
+	}
+	inner := body[len(esiTagStart):end]
+	name := inner
+	if i := bytes.IndexAny(inner, " \t\r\n/>"); i != -1 {
+		name = inner[:i]
+	}
+	consumed := end + 1
+
+	switch string(name) {
+	case "include":
+		attrs := parseAttributes(inner[len(name):])
+		if attrs["src"] == "" {
+			return 0, fmt.Errorf("<esi:include> must have src attribute")
+		}
+		p.flush()
+		p.nodes = append(p.nodes, &Node{
+			Type:            IncludeNode,
+			Src:             attrs["src"],
+			Alt:             attrs["alt"],
+			ContinueOnError: attrs["onerror"] == "continue",
+		})
+		if !selfClosing {
+			consumed += skipClosingTag(body[consumed:], "include")
+		}
+	case "comment":
+		if !selfClosing {
+			consumed += skipClosingTag(body[consumed:], "comment")
+		}
+	case "remove":
+		if selfClosing {
+			return consumed, nil
+		}
+		closing := []byte("</esi:remove>")
+		index := bytes.Index(body[consumed:], closing)
+		if index == -1 {
+			return 0, fmt.Errorf("<esi:remove> is not closed")
+		}
+		consumed += index + len(closing)
+	default:
+		p.flush()
+		p.nodes = append(p.nodes, &Node{
+			Type: UnsupportedNode,
+			Text: body[:consumed],
+			Name: string(name),
+		})
+	}
+	return consumed, nil
+}
+
+// Find the index of '>' which closes the tag, quoted attribute values may contain '>'
+func findTagEnd(body []byte) (int, bool) {
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		switch c := body[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i, i > 0 && body[i-1] == '/'
+		}
+	}
+	return -1, false
+}
+
+// Skip the closing tag like </esi:include> if it follows the opening tag
+func skipClosingTag(body []byte, name string) int {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	closing := []byte("</esi:" + name + ">")
+	if !bytes.HasPrefix(trimmed, closing) {
+		return 0
+	}
+	return len(body) - len(trimmed) + len(closing)
+}
+
+func parseAttributes(b []byte) map[string]string {
+	attrs := make(map[string]string)
+	for _, m := range attributeRegex.FindAllSubmatch(b, -1) {
+		v := m[2]
+		if v == nil {
+			v = m[3]
+		}
+		attrs[string(m[1])] = string(v)
+	}
+	return attrs
+}
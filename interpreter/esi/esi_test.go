@@ -0,0 +1,118 @@
+package esi
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		allowInsideCData bool
+		expect           []*Node
+		isError          bool
+	}{
+		{
+			name:   "plain text",
+			input:  "<html><body>OK</body></html>",
+			expect: []*Node{{Type: TextNode, Text: []byte("<html><body>OK</body></html>")}},
+		},
+		{
+			name:  "self closing include",
+			input: `<div><esi:include src="/fragment" /></div>`,
+			expect: []*Node{
+				{Type: TextNode, Text: []byte("<div>")},
+				{Type: IncludeNode, Src: "/fragment"},
+				{Type: TextNode, Text: []byte("</div>")},
+			},
+		},
+		{
+			name:  "include with closing tag and attributes",
+			input: `<esi:include src='/a?b=c>d' alt="/alt" onerror="continue"></esi:include>`,
+			expect: []*Node{
+				{Type: IncludeNode, Src: "/a?b=c>d", Alt: "/alt", ContinueOnError: true},
+			},
+		},
+		{
+			name:   "remove and comment",
+			input:  `A<esi:remove><a href="/fragment">link</a></esi:remove>B<esi:comment text="ignored" />C`,
+			expect: []*Node{{Type: TextNode, Text: []byte("ABC")}},
+		},
+		{
+			name:  "esi comment markers",
+			input: `A<!--esi <p><esi:include src="/fragment"/></p> -->B`,
+			expect: []*Node{
+				{Type: TextNode, Text: []byte("A <p>")},
+				{Type: IncludeNode, Src: "/fragment"},
+				{Type: TextNode, Text: []byte("</p> B")},
+			},
+		},
+		{
+			name:  "unsupported tags are output as it is",
+			input: `<esi:choose><esi:when test="$(HTTP_HOST)">$(HTTP_HOST)</esi:when></esi:choose><esi:vars>x</esi:vars>`,
+			expect: []*Node{
+				{Type: UnsupportedNode, Text: []byte(`<esi:choose>`), Name: "choose"},
+				{Type: UnsupportedNode, Text: []byte(`<esi:when test="$(HTTP_HOST)">`), Name: "when"},
+				{Type: TextNode, Text: []byte(`$(HTTP_HOST)</esi:when></esi:choose>`)},
+				{Type: UnsupportedNode, Text: []byte(`<esi:vars>`), Name: "vars"},
+				{Type: TextNode, Text: []byte(`x</esi:vars>`)},
+			},
+		},
+		{
+			name:   "markups inside CDATA are not processed",
+			input:  `<![CDATA[<esi:include src="/fragment"/>]]>`,
+			expect: []*Node{{Type: TextNode, Text: []byte(`<![CDATA[<esi:include src="/fragment"/>]]>`)}},
+		},
+		{
+			name:             "markups inside CDATA are processed when allowed",
+			input:            `<![CDATA[<esi:include src="/fragment"/>]]>`,
+			allowInsideCData: true,
+			expect: []*Node{
+				{Type: TextNode, Text: []byte("<![CDATA[")},
+				{Type: IncludeNode, Src: "/fragment"},
+				{Type: TextNode, Text: []byte("]]>")},
+			},
+		},
+		{
+			name:    "include without src",
+			input:   `<esi:include />`,
+			isError: true,
+		},
+		{
+			name:    "unclosed remove",
+			input:   `<esi:remove>A`,
+			isError: true,
+		},
+		{
+			name:    "unclosed esi comment",
+			input:   `<!--esi A`,
+			isError: true,
+		},
+		{
+			name:    "unclosed tag",
+			input:   `<esi:include src="/fragment"`,
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, err := Parse([]byte(tt.input), tt.allowInsideCData)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			if diff := cmp.Diff(tt.expect, nodes); diff != "" {
+				t.Errorf("Parse result mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package interpreter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestESI(t *testing.T) {
+	var count atomic.Int32
+	pages := map[string]string{
+		"/":         `<p><esi:include src="/fragment" /></p><esi:remove>fallback</esi:remove><esi:comment text="comment" />`,
+		"/fragment": `fragment(<!--esi <esi:include src="nested" /> -->)`,
+		"/nested":   `nested`,
+		"/vars":     `<esi:include src="/vars/level1" />`,
+		"/alt":      `<esi:include src="/broken" alt="/nested" />`,
+		"/continue": `A<esi:include src="/broken" onerror="continue" />B`,
+		"/error":    `A<esi:include src="/broken" />B`,
+		"/broken":   `<esi:remove>`,
+		"/loop":     `<esi:include src="/loop" />`,
+		"/many":     strings.Repeat(`<esi:include src="/nested" />`, 257),
+		"/choose":   `<esi:choose><esi:when test="1">$(HTTP_HOST)</esi:when></esi:choose><esi:include src="/nested" />`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(pages[r.URL.Path])) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+	if (req.url ~ "^/vars/") {
+		set req.http.X-ESI-Level = req.esi_level;
+		error 900;
+	}
+	return (lookup);
+}
+sub vcl_fetch {
+	esi;
+}
+sub vcl_error {
+	if (obj.status == 900) {
+		set obj.status = 200;
+		synthetic "level=" req.http.X-ESI-Level ",top=" req.topurl ",subreq=" if(req.is_esi_subreq, "1", "0");
+		return (deliver);
+	}
+}`
+
+	tests := []struct {
+		name        string
+		path        string
+		expectBody  string
+		expectCount int32
+		isError     bool
+	}{
+		{
+			name:        "nested includes with remove, comment and esi comment markers",
+			path:        "/",
+			expectBody:  "<p>fragment( nested )</p>",
+			expectCount: 3,
+		},
+		{
+			name:       "subrequest variables",
+			path:       "/vars?q=1",
+			expectBody: "level=1,top=/vars?q=1,subreq=1",
+		},
+		{
+			name:       "alt is included when src failed",
+			path:       "/alt",
+			expectBody: "nested",
+		},
+		{
+			name:       "onerror continue",
+			path:       "/continue",
+			expectBody: "AB",
+		},
+		{
+			name:       "unsupported tags are output as it is",
+			path:       "/choose",
+			expectBody: `<esi:choose><esi:when test="1">$(HTTP_HOST)</esi:when></esi:choose>nested`,
+		},
+		{
+			name:    "include failure",
+			path:    "/error",
+			isError: true,
+		},
+		{
+			name:    "include depth limit",
+			path:    "/loop",
+			isError: true,
+		},
+		{
+			name:    "include count limit",
+			path:    "/many",
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := New(
+				context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+				context.WithActualResponse(true),
+			)
+			// Run twice to ensure ESI is processed for the cached object
+			for n := range 2 {
+				count.Store(0)
+				rec := httptest.NewRecorder()
+				ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+tt.path, nil))
+				if tt.isError {
+					if ip.process.Error == nil {
+						t.Errorf("Expected error but got nil")
+					}
+					return
+				}
+				if ip.process.Error != nil {
+					t.Errorf("Unexpected error: %s", ip.process.Error)
+					return
+				}
+				body, _ := io.ReadAll(rec.Result().Body)
+				if string(body) != tt.expectBody {
+					t.Errorf("Body mismatch, expect=%s, got=%s", tt.expectBody, string(body))
+				}
+				if n == 0 && tt.expectCount > 0 && count.Load() != tt.expectCount {
+					t.Errorf("Origin request count mismatch, expect=%d, got=%d", tt.expectCount, count.Load())
+				}
+				if n == 1 && count.Load() != 0 {
+					t.Errorf("Second request must be served from the cache, got %d origin requests", count.Load())
+				}
+			}
+		})
+	}
+}
@@ -271,7 +271,7 @@ func (i *Interpreter) ProcessFunctionCallExpression(exp *ast.FunctionCallExpress
 	}
 
 	// Otherwise, process as builtin function
-	fn, err := function.Exists(i.ctx.Scope, exp.Function.Value)
+	fn, err := function.Lookup(i.functions, i.ctx.Scope, exp.Function.Value)
 	if err != nil {
 		return value.Null, errors.WithStack(err)
 	}
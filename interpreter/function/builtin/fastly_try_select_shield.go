@@ -47,6 +47,10 @@ func Fastly_try_select_shield(ctx *context.Context, args ...value.Value) (value.
 	if shield.Healthy == nil || !shield.Healthy.Load() {
 		return fallback, nil
 	}
+	// The request is already on the shield node
+	if ctx.IsShieldNode {
+		return fallback, nil
+	}
 
 	return shield, nil
 }
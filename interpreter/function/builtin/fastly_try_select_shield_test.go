@@ -71,10 +71,11 @@ func Test_Fastly_try_select_shield(t *testing.T) {
 	fallbackBackend.Healthy.Store(true)
 
 	tests := []struct {
-		name     string
-		shield   *value.Backend
-		fallback *value.Backend
-		expected *value.Backend
+		name         string
+		shield       *value.Backend
+		fallback     *value.Backend
+		expected     *value.Backend
+		isShieldNode bool
 	}{
 		{
 			name:     "healthy shield backend returns shield",
@@ -106,12 +107,19 @@ func Test_Fastly_try_select_shield(t *testing.T) {
 			fallback: fallbackBackend,
 			expected: fallbackBackend,
 		},
+		{
+			name:         "healthy shield backend on the shield node returns fallback",
+			shield:       shieldBackend,
+			fallback:     fallbackBackend,
+			expected:     fallbackBackend,
+			isShieldNode: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			args := []value.Value{tt.shield, tt.fallback}
-			ctx := &context.Context{}
+			ctx := &context.Context{IsShieldNode: tt.isShieldNode}
 			result, err := Fastly_try_select_shield(ctx, args...)
 			if err != nil {
 				t.Errorf("Unexpected error: %s", err)
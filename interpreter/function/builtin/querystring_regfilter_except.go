@@ -7,7 +7,7 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
 	"github.com/ysugimoto/falco/v2/interpreter/function/shared"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
-	regexp "go.elara.ws/pcre"
+	"github.com/ysugimoto/falco/v2/regex"
 )
 
 const Querystring_regfilter_except_Name = "querystring.regfilter_except"
@@ -46,7 +46,7 @@ func Querystring_regfilter_except(ctx *context.Context, args ...value.Value) (va
 		)
 	}
 
-	re, err := regexp.Compile(name.Value)
+	re, err := regex.Compile(name.Value)
 	if err != nil {
 		return value.Null, errors.New(
 			Querystring_regfilter_except_Name, "Invalid regexp pattern: %s, error: %s", name.Value, err.Error(),
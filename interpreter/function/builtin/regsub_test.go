@@ -63,6 +63,14 @@ func Test_Regsub(t *testing.T) {
 			expect:      "foo;bar",
 			literal:     true,
 		},
+		{
+			name:        "pattern which is not supported in Fastly",
+			input:       "foobar",
+			pattern:     "(*pla:foo)bar",
+			replacement: "baz",
+			literal:     true,
+			isError:     true,
+		},
 		{
 			name:        "pattern must be literal",
 			input:       "aaaa",
@@ -6,7 +6,7 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/context"
 	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
-	pcre "go.elara.ws/pcre"
+	"github.com/ysugimoto/falco/v2/regex"
 )
 
 const Regsuball_Name = "regsuball"
@@ -42,7 +42,7 @@ func Regsuball(ctx *context.Context, args ...value.Value) (value.Value, error) {
 	pattern := value.Unwrap[*value.String](args[1])
 	replacement := value.Unwrap[*value.String](args[2])
 
-	re, err := pcre.Compile(pattern.Value)
+	re, err := regex.Compile(pattern.Value)
 	if err != nil {
 		ctx.FastlyError = &value.String{Value: "EREGRECUR"}
 		return &value.String{Value: input.Value}, errors.New(
@@ -17,7 +17,15 @@ type Function struct {
 }
 
 func Exists(scope context.Scope, name string) (*Function, error) {
-	fn, ok := builtinFunctions[name]
+	return Lookup(nil, scope, name)
+}
+
+// Lookup finds the function from injected functions first, and then from builtin functions
+func Lookup(injected map[string]*Function, scope context.Scope, name string) (*Function, error) {
+	fn, ok := injected[name]
+	if !ok {
+		fn, ok = builtinFunctions[name]
+	}
 	if !ok {
 		return nil, errors.WithStack(
 			fmt.Errorf("Function %s is not defined", name),
@@ -30,6 +38,8 @@ func Exists(scope context.Scope, name string) (*Function, error) {
 	return fn, nil
 }
 
+// Inject adds functions to builtin functions globally.
+// Use Interpreter.InjectFunctions to inject functions which are bound to the interpreter
 func Inject(fns map[string]*Function) {
 	// Always override existing functions
 	maps.Copy(builtinFunctions, fns)
@@ -12,13 +12,45 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/variable"
 )
 
-// Implements http.Handler
+// ConcurrentHandler returns http.Handler which processes requests concurrently.
+// Each incoming request is processed on the forked interpreter so that requests never share
+// the execution context, but the cache, ratecounters and penaltyboxes are shared between them.
+// maxConcurrency limits the number of requests which are processed at the same time,
+// and zero or negative value means unlimited.
+func (i *Interpreter) ConcurrentHandler(maxConcurrency int) ghttp.Handler {
+	h := &concurrentHandler{root: i}
+	if maxConcurrency > 0 {
+		h.semaphore = make(chan struct{}, maxConcurrency)
+	}
+	return h
+}
+
+type concurrentHandler struct {
+	root      *Interpreter
+	semaphore chan struct{}
+}
+
+func (h *concurrentHandler) ServeHTTP(w ghttp.ResponseWriter, r *ghttp.Request) {
+	if h.semaphore != nil {
+		select {
+		case h.semaphore <- struct{}{}:
+			defer func() { <-h.semaphore }()
+		case <-r.Context().Done():
+			ghttp.Error(w, r.Context().Err().Error(), ghttp.StatusServiceUnavailable)
+			return
+		}
+	}
+	h.root.fork().ServeHTTP(w, r)
+}
+
+// Implements http.Handler.
+// Note that the interpreter processes a request exclusively because the interpreter holds
+// the execution context. Use ConcurrentHandler() to process requests concurrently.
 func (i *Interpreter) ServeHTTP(w ghttp.ResponseWriter, r *ghttp.Request) {
 	i.Debugger.Message("Request Incoming =========>")
 	defer i.Debugger.Message("<========= Request finished")
-	// Prevent deadlock if simulator is a backend for itself.
-	if strings.Contains(r.Header.Get("Fastly-FF"), variable.FALCO_SERVER_HOSTNAME) {
-		ghttp.Error(w, "loop detected", ghttp.StatusServiceUnavailable)
+	// Fastly purge API does not go through VCL
+	if i.servePurgeAPI(w, r) {
 		return
 	}
 	i.lock.Lock()
@@ -28,6 +60,14 @@ func (i *Interpreter) ServeHTTP(w ghttp.ResponseWriter, r *ghttp.Request) {
 		ghttp.Error(w, err.Error(), ghttp.StatusInternalServerError)
 		return
 	}
+	// Prevent deadlock if simulator is a backend for itself.
+	// Note that the shield node has a different hostname from the edge node in shielding mode.
+	if strings.Contains(r.Header.Get("Fastly-FF"), variable.ServerHostname(i.ctx)) {
+		ghttp.Error(w, "loop detected", ghttp.StatusServiceUnavailable)
+		return
+	}
+	// Busy object must be released even if the request did not reach vcl_fetch
+	defer i.releaseBusyObject()
 
 	handleError := func(err error) {
 		// If debug is true, print with stacktrace
@@ -51,6 +91,7 @@ func (i *Interpreter) ServeHTTP(w ghttp.ResponseWriter, r *ghttp.Request) {
 	}
 
 	i.process.Restarts = i.ctx.Restarts
+	i.process.State = i.ctx.State
 	i.process.Backend = i.ctx.Backend
 
 	switch {
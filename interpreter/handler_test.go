@@ -0,0 +1,99 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestConcurrentHandler(t *testing.T) {
+	// Origin server records the max number of requests in flight
+	var inflight, maxInflight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			m := maxInflight.Load()
+			if n <= m || maxInflight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl := defaultBackend(parsed) + `
+ratecounter rate_counter {}
+sub vcl_recv {
+	declare local var.count INTEGER;
+	set var.count = ratelimit.ratecounter_increment(rate_counter, "client", 1);
+	return (pass);
+}`
+
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		expectInflight func(n int32) bool
+	}{
+		{
+			name:           "requests are processed concurrently",
+			maxConcurrency: 0,
+			expectInflight: func(n int32) bool { return n > 1 },
+		},
+		{
+			name:           "requests are limited by max concurrency",
+			maxConcurrency: 1,
+			expectInflight: func(n int32) bool { return n == 1 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxInflight.Store(0)
+			ip := New(context.WithResolver(resolver.NewStaticResolver("main", vcl)))
+			handler := ip.ConcurrentHandler(tt.maxConcurrency)
+
+			var wg sync.WaitGroup
+			for range 4 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					rec := httptest.NewRecorder()
+					handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+					if rec.Result().StatusCode != http.StatusOK {
+						t.Errorf("Unexpected HTTP status from interpreter %d", rec.Result().StatusCode)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if n := maxInflight.Load(); !tt.expectInflight(n) {
+				t.Errorf("Unexpected max inflight requests: %d", n)
+			}
+			// Ratecounter must be shared between forked interpreters
+			rc, ok := ip.rateCounters["rate_counter"]
+			if !ok {
+				t.Errorf("Ratecounter is not shared with forked interpreters")
+				return
+			}
+			// Use wider window than the bucket slot because 10s window could cross the slot boundary
+			if v := rc.Bucket("client", time.Minute); v != 4 {
+				t.Errorf("Ratecounter bucket mismatch, expect=4, got=%d", v)
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package interpreter
 
 import (
+	gocontext "context"
 	"fmt"
 	"io"
+	"maps"
 	ghttp "net/http"
 	"strings"
 	"sync"
@@ -14,6 +16,7 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/cache"
 	"github.com/ysugimoto/falco/v2/interpreter/context"
 	"github.com/ysugimoto/falco/v2/interpreter/exception"
+	"github.com/ysugimoto/falco/v2/interpreter/function"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
 	"github.com/ysugimoto/falco/v2/interpreter/limitations"
 	"github.com/ysugimoto/falco/v2/interpreter/process"
@@ -35,7 +38,15 @@ type Interpreter struct {
 	cache         *cache.Cache
 	rateCounters  map[string]*value.Ratecounter
 	penaltyBoxes  map[string]*value.Penaltybox
+	sharedLock    *sync.Mutex     // guards rateCounters and penaltyBoxes which are shared with forked interpreters
+	revalidations *sync.WaitGroup // background fetches which are running to revalidate stale objects
+	request       *http.Request   // pristine client request to run background fetch
+	busyHash      string          // hash of the busy object which this request is fetching for request collapsing
+	esiIncludes   *int            // number of ESI includes which are shared with ESI subrequests
+	mainFile      string          // file name of the main VCL
 	callStack     []*ast.SubroutineDeclaration
+	gotoStmt      *ast.GotoStatement            // pending goto statement while GOTO state is unwinding
+	functions     map[string]*function.Function // functions which are injected only for this interpreter
 	Debugger      Debugger
 	IdentResolver func(v string) value.Value
 
@@ -44,18 +55,50 @@ type Interpreter struct {
 
 func New(options ...context.Option) *Interpreter {
 	return &Interpreter{
-		options:      options,
-		cache:        cache.New(),
-		rateCounters: make(map[string]*value.Ratecounter),
-		penaltyBoxes: make(map[string]*value.Penaltybox),
-		callStack:    []*ast.SubroutineDeclaration{},
-		localVars:    variable.LocalVariables{},
-		Debugger:     DefaultDebugger{},
-		TestingState: NONE,
-		process:      process.New(),
+		options:       options,
+		cache:         cache.New(),
+		rateCounters:  make(map[string]*value.Ratecounter),
+		penaltyBoxes:  make(map[string]*value.Penaltybox),
+		sharedLock:    &sync.Mutex{},
+		revalidations: &sync.WaitGroup{},
+		callStack:     []*ast.SubroutineDeclaration{},
+		localVars:     variable.LocalVariables{},
+		Debugger:      DefaultDebugger{},
+		TestingState:  NONE,
+		process:       process.New(),
 	}
 }
 
+// fork returns a new interpreter which has its own execution context
+// (context, process, local variables and call stack) but shares the cache,
+// ratecounters and penaltyboxes with the receiver.
+func (i *Interpreter) fork() *Interpreter {
+	return &Interpreter{
+		options:       i.options,
+		cache:         i.cache,
+		rateCounters:  i.rateCounters,
+		penaltyBoxes:  i.penaltyBoxes,
+		sharedLock:    i.sharedLock,
+		revalidations: i.revalidations,
+		callStack:     []*ast.SubroutineDeclaration{},
+		localVars:     variable.LocalVariables{},
+		Debugger:      i.Debugger,
+		IdentResolver: i.IdentResolver,
+		TestingState:  NONE,
+		process:       process.New(),
+		functions:     i.functions,
+	}
+}
+
+// InjectFunctions adds functions which are callable only in this interpreter.
+// Injected functions take precedence over builtin functions.
+func (i *Interpreter) InjectFunctions(fns map[string]*function.Function) {
+	if i.functions == nil {
+		i.functions = make(map[string]*function.Function)
+	}
+	maps.Copy(i.functions, fns)
+}
+
 func (i *Interpreter) SetScope(scope context.Scope) {
 	i.ctx.Scope = scope
 	switch scope {
@@ -83,6 +126,7 @@ func (i *Interpreter) SetScope(scope context.Scope) {
 func (i *Interpreter) restart() error {
 	i.ctx.Restarts++
 	i.Debugger.Message(fmt.Sprintf("Restarted (%d) time", i.ctx.Restarts))
+	i.releaseBusyObject()
 	i.ctx.BackendRequest = nil
 	i.ctx.BackendResponse = nil
 	i.ctx.Object = nil
@@ -134,6 +178,9 @@ func (i *Interpreter) ProcessInit(r *http.Request) error {
 	ctx.RequestStartTime = time.Now()
 	i.ctx = ctx
 	i.ctx.Request = r
+	i.request = r.Clone(r.Context())
+	i.esiIncludes = new(int)
+	i.mainFile = main.Name
 	r.Header.Set("Host", r.Host)
 	i.chargeInboundRequestWorkspace()
 
@@ -150,6 +197,9 @@ func (i *Interpreter) ProcessInit(r *http.Request) error {
 	// From Fastly spec, when the service receives purge request, HTTP related fields should be:
 	// - method is FASTLYPURGE
 	// - host header is original access based, but fastly_info.host_header value turns to api.fastly.com
+	// Count visits on the previous nodes, the edge node adds Fastly-FF header when the request is sent to the shield node
+	i.ctx.FastlyFFVisits = variable.CountServiceVisits(r.Header.Get("Fastly-FF"))
+
 	i.ctx.IsPurgeRequest = r.Method == "FASTLYPURGE"
 	if i.ctx.IsPurgeRequest {
 		i.ctx.OriginalHost = "api.fastly.com"
@@ -238,27 +288,13 @@ func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
 			// Other custom user subroutine could not be duplicated
 			return exception.Runtime(&t.Token, "Subroutine %s is duplicated", t.Name.Value)
 		case *ast.PenaltyboxDeclaration:
-			pb := i.penaltyBoxes[t.Name.Value]
-			if pb == nil {
-				i.Debugger.Run(stmt)
-				if _, ok := i.ctx.Penaltyboxes[t.Name.Value]; ok {
-					return exception.Runtime(&t.Token, "Penaltybox %s is duplicated", t.Name.Value)
-				}
-				pb = value.NewPenaltybox(t)
-				i.penaltyBoxes[t.Name.Value] = pb
+			if err := i.processPenaltyboxDeclaration(t); err != nil {
+				return errors.WithStack(err)
 			}
-			i.ctx.Penaltyboxes[t.Name.Value] = pb
 		case *ast.RatecounterDeclaration:
-			rc := i.rateCounters[t.Name.Value]
-			if rc == nil {
-				i.Debugger.Run(stmt)
-				if _, ok := i.ctx.Ratecounters[t.Name.Value]; ok {
-					return exception.Runtime(&t.Token, "Ratecounter %s is duplicated", t.Name.Value)
-				}
-				rc = value.NewRatecounter(t)
-				i.rateCounters[t.Name.Value] = rc
+			if err := i.processRatecounterDeclaration(t); err != nil {
+				return errors.WithStack(err)
 			}
-			i.ctx.Ratecounters[t.Name.Value] = rc
 		}
 	}
 
@@ -280,6 +316,42 @@ func (i *Interpreter) ProcessDeclarations(statements []ast.Statement) error {
 	return nil
 }
 
+// Penaltybox and ratecounter instances persist between requests,
+// and they could be declared by requests which are processed concurrently.
+func (i *Interpreter) processPenaltyboxDeclaration(decl *ast.PenaltyboxDeclaration) error {
+	i.sharedLock.Lock()
+	defer i.sharedLock.Unlock()
+
+	pb := i.penaltyBoxes[decl.Name.Value]
+	if pb == nil {
+		i.Debugger.Run(decl)
+		if _, ok := i.ctx.Penaltyboxes[decl.Name.Value]; ok {
+			return exception.Runtime(&decl.Token, "Penaltybox %s is duplicated", decl.Name.Value)
+		}
+		pb = value.NewPenaltybox(decl)
+		i.penaltyBoxes[decl.Name.Value] = pb
+	}
+	i.ctx.Penaltyboxes[decl.Name.Value] = pb
+	return nil
+}
+
+func (i *Interpreter) processRatecounterDeclaration(decl *ast.RatecounterDeclaration) error {
+	i.sharedLock.Lock()
+	defer i.sharedLock.Unlock()
+
+	rc := i.rateCounters[decl.Name.Value]
+	if rc == nil {
+		i.Debugger.Run(decl)
+		if _, ok := i.ctx.Ratecounters[decl.Name.Value]; ok {
+			return exception.Runtime(&decl.Token, "Ratecounter %s is duplicated", decl.Name.Value)
+		}
+		rc = value.NewRatecounter(decl)
+		i.rateCounters[decl.Name.Value] = rc
+	}
+	i.ctx.Ratecounters[decl.Name.Value] = rc
+	return nil
+}
+
 func (i *Interpreter) ProcessBackends(statements []ast.Statement) error {
 	for _, stmt := range statements {
 		t, ok := stmt.(*ast.BackendDeclaration)
@@ -334,7 +406,13 @@ func (i *Interpreter) ProcessRecv() error {
 				`Failed to accept purge request. The vcl_recv subroutine MUST return "lookup" or "pass" state with return statement`,
 			)
 		}
-		// We don't call following state machine subroutines.
+		// Fastly calculates the object hash to purge through vcl_hash subroutine,
+		// and we don't call any other following state machine subroutines.
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> HASH", i.ctx.Scope))
+		if err = i.ProcessHash(); err != nil {
+			return errors.WithStack(err)
+		}
+		i.purgeURL()
 		return nil
 	}
 
@@ -357,18 +435,7 @@ func (i *Interpreter) ProcessRecv() error {
 		if err = i.ProcessHash(); err != nil {
 			return errors.WithStack(err)
 		}
-		if v := i.cache.Get(i.ctx.RequestHash.Value); v != nil {
-			i.process.Cached = true
-			i.ctx.State = "HIT"
-			i.ctx.CacheHitItem = v
-			i.ctx.Object = v.Response.Clone()
-			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
-			err = i.ProcessHit()
-		} else {
-			i.ctx.State = "MISS"
-			i.Debugger.Message(fmt.Sprintf("Move state: %s -> MISS", i.ctx.Scope))
-			err = i.ProcessMiss()
-		}
+		err = i.lookup()
 	default:
 		return exception.Runtime(
 			&sub.GetMeta().Token,
@@ -385,6 +452,93 @@ func (i *Interpreter) ProcessRecv() error {
 	return nil
 }
 
+// lookup looks up the cache object for the request hash and moves to the next state
+func (i *Interpreter) lookup() error {
+	hash := i.ctx.RequestHash.Value
+	for {
+		// Background fetch always goes to the origin to revalidate the stale object
+		var v *cache.CacheItem
+		if !i.ctx.IsBackgroundFetch {
+			v = i.cache.Get(hash)
+		}
+		switch {
+		case v != nil && v.HitForPass && !v.IsStale():
+			// Hit-for-pass marker is found, the request goes to pass without waiting for the busy object
+			i.ctx.State = "HITPASS"
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> PASS", i.ctx.Scope))
+			return i.ProcessPass()
+		case v != nil && v.HitForPass:
+			// Expired hit-for-pass marker is treated as a miss
+		case v != nil && !v.IsStale():
+			i.process.Cached = true
+			i.ctx.State = "HIT"
+			i.ctx.CacheHitItem = v
+			i.ctx.Object = v.Response.Clone()
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
+			return i.ProcessHit()
+		case v != nil && v.CanServeWhileRevalidate(i.ctx.MaxStaleWhileRevalidate.Value):
+			// Serve stale object and revalidate it in background
+			i.process.Cached = true
+			i.ctx.State = "HIT-STALE"
+			i.ctx.CacheHitItem = v
+			i.ctx.Object = v.Response.Clone()
+			i.ctx.StaleContents = &value.String{Value: "1"}
+			i.ctx.Stale.Value = true
+			i.ctx.StaleIsRevalidating.Value = true
+			i.revalidate(v)
+			i.Debugger.Message(fmt.Sprintf("Move state: %s -> HIT", i.ctx.Scope))
+			return i.ProcessHit()
+		}
+
+		// When other request is fetching the same object, wait for it and look up the cache again
+		if wait := i.acquireBusyObject(hash); wait != nil {
+			i.Debugger.Message(fmt.Sprintf("Waiting for the busy object %s", hash))
+			select {
+			case <-wait:
+				continue
+			case <-i.ctx.Request.Context().Done():
+				return errors.WithStack(i.ctx.Request.Context().Err())
+			}
+		}
+
+		// Stale object is kept to be delivered on origin error
+		if v != nil && !v.HitForPass && v.CanServeIfError(i.ctx.MaxStaleIfError.Value) {
+			i.ctx.StaleCacheItem = v
+			i.ctx.StaleContents = &value.String{Value: "1"}
+		}
+		i.ctx.State = "MISS"
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> MISS", i.ctx.Scope))
+		return i.ProcessMiss()
+	}
+}
+
+// acquireBusyObject marks the object as busy in request collapsing mode.
+// Returns the channel to wait if the object is already being fetched by other request.
+// Background fetch and the request which sets req.hash_ignore_busy never wait.
+func (i *Interpreter) acquireBusyObject(hash string) <-chan struct{} {
+	if !i.ctx.IsRequestCollapsing || i.ctx.IsBackgroundFetch || i.busyHash == hash {
+		return nil
+	}
+	if i.ctx.HashIgnoreBusy.Value {
+		return nil
+	}
+	wait := i.cache.Acquire(hash)
+	if wait == nil {
+		i.busyHash = hash
+	}
+	return wait
+}
+
+// releaseBusyObject releases the busy object which this request has acquired,
+// then waiting requests look up the cache again
+func (i *Interpreter) releaseBusyObject() {
+	if i.busyHash == "" {
+		return
+	}
+	i.cache.Release(i.busyHash)
+	i.busyHash = ""
+}
+
 func (i *Interpreter) ProcessHash() error {
 	i.SetScope(context.HashScope)
 
@@ -413,6 +567,7 @@ func (i *Interpreter) ProcessHash() error {
 
 func (i *Interpreter) ProcessMiss() error {
 	i.SetScope(context.MissScope)
+	i.ctx.IsPassRequest = false
 
 	if i.ctx.Backend == nil || (i.ctx.Backend.Value == nil && i.ctx.Backend.Director == nil) {
 		return exception.Runtime(nil, "No backend determined in MISS")
@@ -445,7 +600,7 @@ func (i *Interpreter) ProcessMiss() error {
 	switch state {
 	case DELIVER_STALE:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
-		err = i.ProcessDeliver()
+		err = i.deliverStale()
 	case PASS:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> PASS", i.ctx.Scope))
 		err = i.ProcessPass()
@@ -472,6 +627,11 @@ func (i *Interpreter) ProcessMiss() error {
 func (i *Interpreter) ProcessHit() error {
 	i.SetScope(context.HitScope)
 
+	// Populate cache object related values
+	i.ctx.ObjectTTL = &value.RTime{Value: i.ctx.CacheHitItem.TTL()}
+	i.ctx.ObjectGrace = &value.RTime{Value: i.ctx.CacheHitItem.StaleIfError}
+	i.ctx.ObjectStaleWhileRevalidate = &value.RTime{Value: i.ctx.CacheHitItem.StaleWhileRevalidate}
+
 	// Simulate Fastly statement lifecycle
 	// see: https://developer.fastly.com/learning/vcl/using/#the-vcl-request-lifecycle
 	var err error
@@ -521,6 +681,7 @@ func (i *Interpreter) ProcessHit() error {
 
 func (i *Interpreter) ProcessPass() error {
 	i.SetScope(context.PassScope)
+	i.ctx.IsPassRequest = true
 
 	if i.ctx.Backend == nil {
 		return exception.Runtime(nil, "No backend determined in PASS")
@@ -581,23 +742,26 @@ func (i *Interpreter) ProcessFetch() error {
 
 	// Send request to backend
 	var err error
-	i.ctx.BackendResponse, err = i.sendBackendRequest(i.ctx.Backend)
-	if err != nil {
-		return errors.WithStack(err)
+	if i.ctx.Backend.IsShieldDirector() {
+		i.ctx.BackendResponse, err = i.sendShieldRequest()
+	} else {
+		// The director determines the concrete backend on creating the backend request
+		i.ctx.BackendResponse, err = i.sendBackendRequest(i.ctx.FetchBackend)
 	}
-
-	// Mark request process has ended
-	i.ctx.RequestEndTime = time.Now()
-
-	// Set cacheable strategy
-	isCacheable := cache.IsCacheableStatusCode(i.ctx.BackendResponse.StatusCode)
-	i.ctx.BackendResponseCacheable = &value.Boolean{Value: isCacheable}
-	if isCacheable {
-		i.ctx.BackendResponseTTL = &value.RTime{
-			Value: i.determineCacheTTL(i.ctx.BackendResponse),
+	if err != nil {
+		// When stale object exists, the request moves to vcl_error so that stale object could be delivered
+		// via deliver_stale state. Otherwise, the process ends with an error.
+		if i.ctx.StaleCacheItem == nil || i.ctx.IsBackgroundFetch {
+			return errors.WithStack(err)
 		}
+		i.Debugger.Message(fmt.Sprintf("Backend fetch failed: %s", err))
+		i.ctx.ObjectStatus = &value.Integer{Value: ghttp.StatusServiceUnavailable}
+		i.ctx.ObjectResponse = &value.String{Value: "backend read error"}
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> ERROR", i.ctx.Scope))
+		return i.ProcessError()
 	}
-	// TODO: consider stale-white-revalidate and stale-if-error TTL
+
+	i.setupCacheStrategy()
 
 	// Simulate Fastly statement lifecycle
 	// see: https://developer.fastly.com/learning/vcl/using/#the-vcl-request-lifecycle
@@ -612,10 +776,31 @@ func (i *Interpreter) ProcessFetch() error {
 			state = DELIVER
 		}
 	}
+	// beresp.do_esi could be changed after esi statement
+	i.ctx.TriggerESI = i.ctx.BackendResponseDoESI.Value
+
+	switch {
+	case i.ctx.IsPassRequest:
+		// Response for the pass request is never cached
+	case state == PASS || state == HIT_FOR_PASS:
+		// Returning pass creates hit-for-pass marker so that following requests do not wait for the busy object
+		i.createHitForPass()
+	case state != DELIVER_STALE || i.ctx.StaleCacheItem == nil:
+		// Keep the stale object when it is delivered instead of the backend response
+		i.updateCache()
+	}
+	// Waiting requests are woken up when the response has been fetched
+	i.releaseBusyObject()
+	// Background fetch only revalidates the cache object, never delivers
+	if i.ctx.IsBackgroundFetch {
+		return nil
+	}
 
-	i.updateCache()
 	switch state {
-	case DELIVER, DELIVER_STALE, PASS, HIT_FOR_PASS:
+	case DELIVER_STALE:
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
+		err = i.deliverStale()
+	case DELIVER, PASS, HIT_FOR_PASS:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
 	case ERROR:
@@ -637,6 +822,16 @@ func (i *Interpreter) ProcessFetch() error {
 	return nil
 }
 
+// Set cacheable strategy
+// TTL is determined even if the response is not cacheable because beresp.cacheable could be changed in vcl_fetch
+func (i *Interpreter) setupCacheStrategy() {
+	i.ctx.BackendResponseCacheable = &value.Boolean{Value: cache.IsCacheable(i.ctx.BackendResponse)}
+	i.ctx.BackendResponseTTL = &value.RTime{Value: cache.DetermineTTL(i.ctx.BackendResponse)}
+	swr, sie := cache.DetermineStaleTTL(i.ctx.BackendResponse)
+	i.ctx.BackendResponseStaleWhileRevalidate = &value.RTime{Value: swr}
+	i.ctx.BackendResponseStaleIfError = &value.RTime{Value: sie}
+}
+
 func (i *Interpreter) ProcessError() error {
 	i.SetScope(context.ErrorScope)
 
@@ -677,6 +872,9 @@ func (i *Interpreter) ProcessError() error {
 	case DELIVER:
 		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
 		err = i.ProcessDeliver()
+	case DELIVER_STALE:
+		i.Debugger.Message(fmt.Sprintf("Move state: %s -> DELIVER", i.ctx.Scope))
+		err = i.deliverStale()
 	case RESTART:
 		err = i.restart()
 	default:
@@ -693,6 +891,22 @@ func (i *Interpreter) ProcessError() error {
 	return nil
 }
 
+// Virtual cache node name which is used for X-Served-By header value
+func (i *Interpreter) servedBy() string {
+	if i.ctx.IsShieldNode {
+		return cache.ShieldDatacenterString
+	}
+	return cache.LocalDatacenterString
+}
+
+// Fastly appends own value to the header which is added by the previous node
+func appendFastlyHeader(resp *http.Response, name, v string) {
+	if prev := resp.Header.Get(name); prev != "" {
+		v = prev + ", " + v
+	}
+	resp.Header.Set(name, v)
+}
+
 func (i *Interpreter) ProcessDeliver() error {
 	i.SetScope(context.DeliverScope)
 
@@ -703,19 +917,21 @@ func (i *Interpreter) ProcessDeliver() error {
 	}
 
 	// Add Fastly related server info but values are falco's one.
+	// Values are appended when the response has already passed through the other node like shield.
 	// Note that these headers could be removed in vcl_deliver subroutine
-	i.ctx.Response.Header.Set("X-Served-By", cache.LocalDatacenterString)
-	i.ctx.Response.Header.Set("X-Cache", i.ctx.State)
+	servedBy := i.servedBy()
+	appendFastlyHeader(i.ctx.Response, "X-Served-By", servedBy)
+	appendFastlyHeader(i.ctx.Response, "X-Cache", i.ctx.State)
 	i.ctx.Response.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
 	i.ctx.Response.Header.Set("Server", "Falco")
 	i.ctx.Response.Header.Set("Via", "Falco")
 
 	// Additionally set cache related headers
 	if i.ctx.CacheHitItem != nil {
-		i.ctx.Response.Header.Set("X-Cache-Hits", fmt.Sprint(i.ctx.CacheHitItem.Hits))
+		appendFastlyHeader(i.ctx.Response, "X-Cache-Hits", fmt.Sprint(i.ctx.CacheHitItem.Hits))
 		i.ctx.Response.Header.Set("Age", fmt.Sprintf("%.0f", time.Since(i.ctx.CacheHitItem.EntryTime).Seconds()))
 	} else {
-		i.ctx.Response.Header.Set("X-Cache-Hits", "0")
+		appendFastlyHeader(i.ctx.Response, "X-Cache-Hits", "0")
 	}
 
 	// Simulate Fastly statement lifecycle
@@ -737,8 +953,11 @@ func (i *Interpreter) ProcessDeliver() error {
 	case RESTART:
 		err = i.restart()
 	case LOG, DELIVER:
+		// Response headers are sent to the client at this point
+		i.ctx.FirstByteTime = time.Now()
+
 		// When ESI is triggered in FETCH directive, execute ESI
-		if i.ctx.TriggerESI {
+		if i.shouldProcessESI() {
 			if err := i.executeESI(); err != nil {
 				return errors.WithStack(err)
 			}
@@ -748,7 +967,7 @@ func (i *Interpreter) ProcessDeliver() error {
 		if i.ctx.Request.Header.Get("Fastly-Debug") != "" {
 			i.ctx.Response.Header.Set(
 				"Fastly-Debug-Path",
-				fmt.Sprintf("(D %s 0) (F %s 0)", cache.LocalDatacenterString, cache.LocalDatacenterString),
+				fmt.Sprintf("(D %s 0) (F %s 0)", servedBy, servedBy),
 			)
 			cacheHit := "M"
 			if i.ctx.State == "HIT" {
@@ -756,7 +975,7 @@ func (i *Interpreter) ProcessDeliver() error {
 			}
 			i.ctx.Response.Header.Set(
 				"Fastly-Debug-TTL",
-				fmt.Sprintf("(%s %s %.3f %.3f %d)", cacheHit, cache.LocalDatacenterString, 0.000, 0.000, 0),
+				fmt.Sprintf("(%s %s %.3f %.3f %d)", cacheHit, servedBy, 0.000, 0.000, 0),
 			)
 		}
 
@@ -778,6 +997,8 @@ func (i *Interpreter) ProcessDeliver() error {
 
 func (i *Interpreter) ProcessLog() error {
 	i.SetScope(context.LogScope)
+	// Mark request process has ended, vcl_log runs after the response is delivered
+	i.ctx.RequestEndTime = time.Now()
 
 	if i.ctx.Response == nil {
 		if i.ctx.Object != nil {
@@ -799,34 +1020,59 @@ func (i *Interpreter) ProcessLog() error {
 	return nil
 }
 
-var expiresValueLayout = "Mon, 02 Jan 2006 15:04:05 MST"
+// deliverStale delivers the stale object instead of the response which has been made in the current state.
+// If stale object does not exist, it behaves as deliver state.
+func (i *Interpreter) deliverStale() error {
+	if stale := i.ctx.StaleCacheItem; stale != nil {
+		i.process.Cached = true
+		i.ctx.CacheHitItem = stale
+		i.ctx.Object = stale.Response.Clone()
+		i.ctx.Stale.Value = true
+		i.ctx.StaleIsError.Value = true
+	}
+	return i.ProcessDeliver()
+}
 
-func (i *Interpreter) determineCacheTTL(resp *http.Response) time.Duration {
-	if v := resp.Header.Get("Surrogate-Control"); v != "" {
-		if maxAge, found := strings.CutPrefix(v, "max-age="); found {
-			if dur, err := time.ParseDuration(maxAge + "s"); err == nil {
-				return dur
-			}
-		}
+// revalidate runs background fetch for the stale object on the forked interpreter.
+// Background fetch runs whole lifecycle with the client request but never delivers the response,
+// only stores the fetched object to the cache.
+func (i *Interpreter) revalidate(item *cache.CacheItem) {
+	// Prevent to revalidate the same object concurrently
+	if !item.StartRevalidation() {
+		return
 	}
-	if v := resp.Header.Get("Cache-Control"); v != "" {
-		if sMaxAge, found := strings.CutPrefix(v, "s-maxage="); found {
-			if dur, err := time.ParseDuration(sMaxAge + "s"); err == nil {
-				return dur
-			}
-		}
-		if maxAge, found := strings.CutPrefix(v, "max-age="); found {
-			if dur, err := time.ParseDuration(maxAge + "s"); err == nil {
-				return dur
-			}
+
+	req := i.request.Clone(gocontext.Background())
+	ip := i.fork()
+	ip.Debugger = backgroundDebugger{i.Debugger}
+
+	i.revalidations.Add(1)
+	go func() {
+		defer i.revalidations.Done()
+		defer item.FinishRevalidation()
+
+		ip.Debugger.Message("Background fetch started to revalidate stale object")
+		if err := ip.ProcessInit(req); err != nil {
+			return
 		}
-	}
-	if v := resp.Header.Get("Expires"); v != "" {
-		if d, err := time.Parse(expiresValueLayout, v); err == nil {
-			return time.Until(d)
+		ip.ctx.IsBackgroundFetch = true
+		if err := ip.ProcessRecv(); err != nil {
+			ip.Debugger.Message(fmt.Sprintf("Background fetch failed: %s", err))
 		}
+	}()
+}
+
+// Hit-for-pass marker lives in beresp.ttl
+func (i *Interpreter) createHitForPass() {
+	if i.ctx.BackendResponseTTL.Value <= 0 {
+		return
 	}
-	return time.Duration(2 * time.Minute)
+	now := time.Now()
+	i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
+		Expires:    now.Add(i.ctx.BackendResponseTTL.Value),
+		EntryTime:  now,
+		HitForPass: true,
+	})
 }
 
 func (i *Interpreter) updateCache() {
@@ -837,9 +1083,13 @@ func (i *Interpreter) updateCache() {
 		if i.ctx.BackendResponseTTL.Value.Seconds() > 0 {
 			now := time.Now()
 			i.cache.Set(i.ctx.RequestHash.String(), &cache.CacheItem{
-				Response:  resp,
-				Expires:   now.Add(i.ctx.BackendResponseTTL.Value),
-				EntryTime: now,
+				Response:             resp,
+				Expires:              now.Add(i.ctx.BackendResponseTTL.Value),
+				EntryTime:            now,
+				ESI:                  i.ctx.TriggerESI,
+				StaleWhileRevalidate: i.ctx.BackendResponseStaleWhileRevalidate.Value,
+				// beresp.grace is an alias for beresp.stale_if_error
+				StaleIfError: max(i.ctx.BackendResponseStaleIfError.Value, i.ctx.BackendResponseGrace.Value),
 			})
 		}
 	}
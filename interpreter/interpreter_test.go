@@ -354,3 +354,54 @@ func TestCustomStatusTextPreserved(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendResponseFreshness(t *testing.T) {
+	vcl := `
+sub vcl_fetch {
+	set beresp.http.X-TTL = beresp.ttl;
+	set beresp.http.X-Cacheable = if(beresp.cacheable, "1", "0");
+}`
+
+	tests := []struct {
+		name         string
+		cacheControl string
+		expectTTL    string
+		cacheable    string
+	}{
+		{name: "max-age after other directive", cacheControl: "public, max-age=600", expectTTL: "600.000", cacheable: "1"},
+		{name: "s-maxage takes precedence", cacheControl: "max-age=600, s-maxage=30", expectTTL: "30.000", cacheable: "1"},
+		{name: "private is not cacheable", cacheControl: "private, max-age=600", expectTTL: "600.000", cacheable: "0"},
+		{name: "no-store is not cacheable", cacheControl: "no-store", expectTTL: "120.000", cacheable: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", tt.cacheControl)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("OK")) // nolint:errcheck
+			}))
+			defer server.Close()
+
+			parsed, err := url.Parse(server.URL)
+			if err != nil {
+				t.Errorf("Test server URL parsing error: %s", err)
+				return
+			}
+			ip := New(
+				context.WithResolver(resolver.NewStaticResolver("main", defaultBackend(parsed)+vcl)),
+				context.WithActualResponse(true),
+			)
+			rec := httptest.NewRecorder()
+			ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+
+			resp := rec.Result()
+			if v := resp.Header.Get("X-TTL"); v != tt.expectTTL {
+				t.Errorf("beresp.ttl mismatch, expect=%s, got=%s", tt.expectTTL, v)
+			}
+			if v := resp.Header.Get("X-Cacheable"); v != tt.cacheable {
+				t.Errorf("beresp.cacheable mismatch, expect=%s, got=%s", tt.cacheable, v)
+			}
+		})
+	}
+}
@@ -13,64 +13,14 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/context"
 	"github.com/ysugimoto/falco/v2/interpreter/exception"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
-)
-
-// Units
-const (
-	KB = 1024
-	MB = 1024 * 1024
-)
-
-// Consolidate Fastly's limitation checks
-// See https://docs.fastly.com/en/guides/resource-limits#request-and-response-limits
-const (
-	// Request and Response limitations
-	MaxURLSize                = 8 * KB
-	MaxCookieSize             = 32 * KB
-	MaxRequestHeaderSize      = 69 * KB
-	MaxResponseHeaderSize     = 69 * KB
-	MaxRequestHeaderCount     = 96
-	MaxResponseHeaderCount    = 96
-	MaxRequestBodyPayloadSize = 8 * KB
-
-	// Surrogate key limitations but actually don't check these
-	MaxSurrogateKeySize       = 1 * KB
-	MaxSurrogateKeyHeaderSize = 1 * KB
-
-	// VCL limitations
-	MaxCustomVCLFileSize = 1 * MB
-	MaxVarnishRestarts   = 3
-	MaxLogLineSize       = 16 * KB
-
-	// MaxSubroutineCallTree is the ceiling Fastly enforces on the fully inlined
-	// subroutine call graph. The cost of a subroutine is the sum, over each of
-	// its `call` statements, of one plus the callee's own cost, so nested calls
-	// multiply. Past this, Fastly rejects activation with "Too many sub calls".
-	MaxSubroutineCallTree = 25000
-
-	// MaxRequestWorkspaceSize is the size of the per-request workspace. Request
-	// headers are assembled into this workspace and the previous copy is never
-	// reclaimed, even across restarts, so a VCL that rewrites a header many
-	// times eventually overflows it and Fastly returns "503 Header overflow".
-	MaxRequestWorkspaceSize = 256 * KB
-
-	// BaseRequestWorkspaceOverhead approximates what Fastly has already consumed
-	// before any user VCL runs (internal structures and injected headers). A
-	// production service shows ~8.5KB, varying by POP and connection, so we charge
-	// a conservative 10KB on top of the inbound headers we can see.
-	BaseRequestWorkspaceOverhead = 10 * KB
-
-	// Increasable limitations by contacting Fastly support
-	// These are defaults, you can override by configuration
-	MaxACLCounts     = 1000
-	MaxBackendCounts = 5
+	"github.com/ysugimoto/falco/v2/limits"
 )
 
 func CheckFastlyVCLLimitation(vcl string) error {
-	if len([]byte(vcl)) > MaxCustomVCLFileSize {
+	if len([]byte(vcl)) > limits.MaxCustomVCLFileSize {
 		return exception.System(
 			"Overflow custom VCL file size limitation of %d",
-			MaxCustomVCLFileSize,
+			limits.MaxCustomVCLFileSize,
 		)
 	}
 	return nil
@@ -124,11 +74,11 @@ func CheckFastlyCallTreeLimit(ctx *context.Context) error {
 	}
 	sort.Strings(names)
 	for _, name := range names {
-		if c := cost(name); c > MaxSubroutineCallTree {
+		if c := cost(name); c > limits.MaxSubroutineCallTree {
 			return exception.Runtime(
 				&subroutines[name].GetMeta().Token,
 				"Too many sub calls: subroutine %s expands to %d calls, exceeding the limit of %d",
-				name, c, MaxSubroutineCallTree,
+				name, c, limits.MaxSubroutineCallTree,
 			)
 		}
 	}
@@ -176,14 +126,14 @@ func walkIfCallStatements(stmt *ast.IfStatement, calls *[]*ast.CallStatement) {
 }
 
 func CheckFastlyResourceLimit(ctx *context.Context) error {
-	maxBackends := max(ctx.OverrideMaxBackends, MaxBackendCounts)
+	maxBackends := max(ctx.OverrideMaxBackends, limits.MaxBackendCounts)
 	if len(ctx.Backends) > maxBackends {
 		return exception.System(
 			"Max backend count of %d exceeded. Provide --max_backends option or add configuration file to increase",
 			maxBackends,
 		)
 	}
-	maxAcls := max(ctx.OverrideMaxAcls, MaxACLCounts)
+	maxAcls := max(ctx.OverrideMaxAcls, limits.MaxACLCounts)
 	if len(ctx.Acls) > maxAcls {
 		return exception.System(
 			"Max ACL count of %d exceeded. Provide --max_acls option or add configuration file to increase",
@@ -196,10 +146,10 @@ func CheckFastlyResourceLimit(ctx *context.Context) error {
 
 // Validate limitation for the request
 func CheckFastlyRequestLimit(req *http.Request) error {
-	if len([]byte(req.URL.String())) > MaxURLSize {
+	if len([]byte(req.URL.String())) > limits.MaxURLSize {
 		return exception.System(
 			"URL size is limited under the %d bytes",
-			MaxURLSize,
+			limits.MaxURLSize,
 		)
 	}
 
@@ -208,7 +158,7 @@ func CheckFastlyRequestLimit(req *http.Request) error {
 		cookieSize += len([]byte(c.Raw))
 		// If max cookie size is greater than limitation, remove cookie header
 		// and add overflow header
-		if cookieSize > MaxCookieSize {
+		if cookieSize > limits.MaxCookieSize {
 			req.Header.Del("Cookie")
 			req.Header.Set("Fastly-Cookie-Overflow", "1")
 			break
@@ -220,17 +170,17 @@ func CheckFastlyRequestLimit(req *http.Request) error {
 		headerSize += len(
 			fmt.Appendf([]byte{}, "%s: %s\n", key, strings.Join(values, ", ")),
 		)
-		if headerSize > MaxRequestHeaderSize {
+		if headerSize > limits.MaxRequestHeaderSize {
 			return exception.System(
 				"Overflow request header size limitation of %d bytes",
-				MaxRequestHeaderSize,
+				limits.MaxRequestHeaderSize,
 			)
 		}
 		headerCount++
-		if headerCount > MaxRequestHeaderCount {
+		if headerCount > limits.MaxRequestHeaderCount {
 			return exception.System(
 				"Overflow request header count limitation of %d",
-				MaxRequestHeaderCount,
+				limits.MaxRequestHeaderCount,
 			)
 		}
 	}
@@ -241,7 +191,7 @@ func CheckFastlyRequestLimit(req *http.Request) error {
 		// We don't trust Content-Length header value, check actual body size
 		if _, err := body.ReadFrom(req.Body); err == nil {
 			// If payload size is greater than limitation, truncate the body
-			if len(body.Bytes()) > MaxRequestBodyPayloadSize {
+			if len(body.Bytes()) > limits.MaxRequestBodyPayloadSize {
 				req.Body = io.NopCloser(strings.NewReader(""))
 			} else {
 				// Rewind request body
@@ -260,17 +210,17 @@ func CheckFastlyResponseLimit(resp *http.Response) error {
 		headerSize += len(
 			fmt.Appendf([]byte{}, "%s: %s\n", key, strings.Join(values, ", ")),
 		)
-		if headerSize > MaxResponseHeaderSize {
+		if headerSize > limits.MaxResponseHeaderSize {
 			return exception.System(
 				"Overflow response header size limitation of %d bytes",
-				MaxResponseHeaderSize,
+				limits.MaxResponseHeaderSize,
 			)
 		}
 		headerCount++
-		if headerCount > MaxResponseHeaderCount {
+		if headerCount > limits.MaxResponseHeaderCount {
 			return exception.System(
 				"Overflow response header count limitation of %d",
-				MaxResponseHeaderCount,
+				limits.MaxResponseHeaderCount,
 			)
 		}
 	}
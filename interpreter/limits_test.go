@@ -6,8 +6,8 @@ import (
 	"testing"
 
 	"github.com/ysugimoto/falco/v2/interpreter/context"
-	"github.com/ysugimoto/falco/v2/interpreter/limitations"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
+	"github.com/ysugimoto/falco/v2/limits"
 )
 
 func TestRequestWorkspaceLimit(t *testing.T) {
@@ -60,10 +60,10 @@ func TestRequestWorkspaceAccounting(t *testing.T) {
 	// equals the workspace minus the inbound baseline and the write cost. The
 	// baseline is the fixed overhead plus the only inbound header the test request
 	// carries, Host (localhost).
-	baseline := int64(limitations.BaseRequestWorkspaceOverhead) +
+	baseline := int64(limits.BaseRequestWorkspaceOverhead) +
 		int64(roundUpToPointer(len("Host")+len("localhost")+3))
 	free := func(writeCost int64) int64 {
-		return int64(limitations.MaxRequestWorkspaceSize) - baseline - writeCost
+		return int64(limits.MaxRequestWorkspaceSize) - baseline - writeCost
 	}
 
 	tests := []struct {
@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/config"
+)
+
+// Log line which is sent to the logging endpoint has a prefix like:
+// log "syslog " req.service_id " endpoint_name :: " "message";
+// see: https://www.fastly.com/documentation/guides/integrations/streaming-logs/setting-up-remote-log-streaming/
+var logLinePrefix = regexp.MustCompile(`(?s)^syslog (\S+) (.+?) :: (.*)$`)
+
+// Entry is a log line which is routed to the logging endpoint
+type Entry struct {
+	Time      time.Time `json:"time"`
+	ServiceID string    `json:"service_id"`
+	Endpoint  string    `json:"endpoint"`
+	Message   string    `json:"message"`
+}
+
+// ParseLogLine parses logging endpoint prefix, returns false if the line does not have it
+func ParseLogLine(line string) (*Entry, bool) {
+	m := logLinePrefix.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	return &Entry{
+		Time:      time.Now(),
+		ServiceID: m[1],
+		Endpoint:  m[2],
+		Message:   m[3],
+	}, true
+}
+
+// Sink receives log entries for the logging endpoint.
+// Sink must be safe for concurrent use because the simulator processes requests concurrently.
+type Sink interface {
+	Write(entry *Entry) error
+	Close() error
+}
+
+// Sinks is a set of the sinks keyed by the logging endpoint name
+type Sinks map[string]Sink
+
+// NewSinks creates sinks from the configuration
+func NewSinks(endpoints map[string]*config.LoggingEndpoint) (Sinks, error) {
+	sinks := Sinks{}
+	for name, c := range endpoints {
+		sink, err := NewSink(c)
+		if err != nil {
+			sinks.Close() // nolint:errcheck
+			return nil, errors.WithStack(fmt.Errorf("logging endpoint %s: %w", name, err))
+		}
+		sinks[name] = sink
+	}
+	return sinks, nil
+}
+
+// NewSink creates the sink for the type
+func NewSink(c *config.LoggingEndpoint) (Sink, error) {
+	switch c.Type {
+	case "file":
+		return newFileSink(c.Path)
+	case "stdout":
+		return newStdoutSink(), nil
+	case "syslog":
+		return newSyslogSink(c.Network, c.Address)
+	case "http":
+		return newHTTPSink(c.URL)
+	default:
+		return nil, fmt.Errorf("unknown sink type %q, type must be one of file, stdout, syslog or http", c.Type)
+	}
+}
+
+// Close closes all sinks
+func (s Sinks) Close() error {
+	var errs []error
+	for _, sink := range s {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.WithStack(fmt.Errorf("failed to close sinks: %v", errs))
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/ysugimoto/falco/v2/config"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		expect *Entry
+	}{
+		{
+			line:   "syslog service_id my_endpoint :: message",
+			expect: &Entry{ServiceID: "service_id", Endpoint: "my_endpoint", Message: "message"},
+		},
+		{
+			line:   "syslog service_id endpoint with spaces :: message :: with separator",
+			expect: &Entry{ServiceID: "service_id", Endpoint: "endpoint with spaces", Message: "message :: with separator"},
+		},
+		{
+			line:   "syslog service_id my_endpoint :: ",
+			expect: &Entry{ServiceID: "service_id", Endpoint: "my_endpoint", Message: ""},
+		},
+		{line: "plain message"},
+		{line: "syslog service_id my_endpoint message"},
+	}
+
+	for _, tt := range tests {
+		entry, ok := ParseLogLine(tt.line)
+		if tt.expect == nil {
+			if ok {
+				t.Errorf("Expected not to be parsed for %q", tt.line)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Expected to be parsed for %q", tt.line)
+			continue
+		}
+		if diff := cmp.Diff(tt.expect, entry, cmpopts.IgnoreFields(Entry{}, "Time")); diff != "" {
+			t.Errorf("ParseLogLine result mismatch for %q, diff=%s", tt.line, diff)
+		}
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *config.LoggingEndpoint
+		isError bool
+	}{
+		{name: "stdout", config: &config.LoggingEndpoint{Type: "stdout"}},
+		{name: "file without path", config: &config.LoggingEndpoint{Type: "file"}, isError: true},
+		{name: "syslog with unknown network", config: &config.LoggingEndpoint{Type: "syslog", Network: "unix", Address: "/tmp/log"}, isError: true},
+		{name: "syslog without address", config: &config.LoggingEndpoint{Type: "syslog"}, isError: true},
+		{name: "http without url", config: &config.LoggingEndpoint{Type: "http"}, isError: true},
+		{name: "unknown type", config: &config.LoggingEndpoint{Type: "kafka"}, isError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSink(tt.config)
+			if tt.isError && err == nil {
+				t.Errorf("Expected error but got nil")
+			} else if !tt.isError && err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	entry := &Entry{
+		Time:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		ServiceID: "service_id",
+		Endpoint:  "my_endpoint",
+		Message:   "message",
+	}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "access.log")
+		sink, err := NewSink(&config.LoggingEndpoint{Type: "file", Path: path})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		for range 2 {
+			if err := sink.Write(entry); err != nil {
+				t.Errorf("Unexpected error: %s", err)
+			}
+		}
+		sink.Close() // nolint:errcheck
+		buf, _ := os.ReadFile(path)
+		if string(buf) != "message\nmessage\n" {
+			t.Errorf("File content mismatch, got=%q", string(buf))
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := &stdoutSink{w: &buf}
+		if err := sink.Write(entry); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		var got Entry
+		if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Errorf("Output must be JSON line: %s", err)
+			return
+		}
+		if diff := cmp.Diff(entry, &got); diff != "" {
+			t.Errorf("JSON line mismatch, diff=%s", diff)
+		}
+	})
+
+	t.Run("syslog over udp", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Errorf("Failed to listen: %s", err)
+			return
+		}
+		defer conn.Close()
+
+		sink, err := NewSink(&config.LoggingEndpoint{Type: "syslog", Address: conn.LocalAddr().String()})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		defer sink.Close()
+		if err := sink.Write(entry); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		buf := make([]byte, 1024)
+		conn.SetReadDeadline(time.Now().Add(time.Second)) // nolint:errcheck
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Errorf("Failed to read: %s", err)
+			return
+		}
+		if v := string(buf[:n]); v != "<134>Jan  1 00:00:00 service_id my_endpoint: message" {
+			t.Errorf("Syslog message mismatch, got=%q", v)
+		}
+	})
+
+	t.Run("syslog over tcp", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Errorf("Failed to listen: %s", err)
+			return
+		}
+		defer ln.Close()
+		lines := make(chan string, 2)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				lines <- scanner.Text()
+			}
+		}()
+
+		sink, err := NewSink(&config.LoggingEndpoint{Type: "syslog", Network: "tcp", Address: ln.Addr().String()})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		defer sink.Close()
+		for range 2 {
+			if err := sink.Write(entry); err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+		}
+		for range 2 {
+			select {
+			case v := <-lines:
+				if !strings.HasSuffix(v, "service_id my_endpoint: message") {
+					t.Errorf("Syslog message mismatch, got=%q", v)
+				}
+			case <-time.After(time.Second):
+				t.Errorf("Syslog message is not received")
+				return
+			}
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		var got string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			buf, _ := io.ReadAll(r.Body)
+			got = r.Method + " " + string(buf)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		sink, err := NewSink(&config.LoggingEndpoint{Type: "http", URL: server.URL})
+		if err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if err := sink.Write(entry); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+			return
+		}
+		if got != "POST message" {
+			t.Errorf("Collector request mismatch, got=%q", got)
+		}
+	})
+}
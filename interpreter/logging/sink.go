@@ -0,0 +1,180 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Syslog priority of Fastly log lines, facility local0 and severity info
+const syslogPriority = 134
+
+// fileSink appends messages to the file line by line
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is required for file sink")
+	}
+	fp, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &fileSink{file: fp}, nil
+}
+
+func (s *fileSink) Write(entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := io.WriteString(s.file, entry.Message+"\n")
+	return errors.WithStack(err)
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
+
+// stdoutSink writes entries as JSON lines
+type stdoutSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newStdoutSink() *stdoutSink {
+	return &stdoutSink{w: os.Stdout}
+}
+
+func (s *stdoutSink) Write(entry *Entry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(buf, '\n'))
+	return errors.WithStack(err)
+}
+
+func (s *stdoutSink) Close() error {
+	return nil
+}
+
+// syslogSink sends messages to the syslog listener in RFC 3164 format
+type syslogSink struct {
+	mu      sync.Mutex
+	network string
+	address string
+	conn    net.Conn
+}
+
+func newSyslogSink(network, address string) (*syslogSink, error) {
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("network must be udp or tcp for syslog sink, got %q", network)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("address is required for syslog sink")
+	}
+	return &syslogSink{network: network, address: address}, nil
+}
+
+func (s *syslogSink) Write(entry *Entry) error {
+	line := fmt.Sprintf(
+		"<%d>%s %s %s: %s",
+		syslogPriority,
+		entry.Time.Format(time.Stamp),
+		entry.ServiceID,
+		strings.ReplaceAll(entry.Endpoint, " ", "_"),
+		entry.Message,
+	)
+	// Messages are delimited by newline on the stream
+	if s.network == "tcp" {
+		line += "\n"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Connection is established lazily and reconnected once when the listener has been restarted
+	for retry := 0; ; retry++ {
+		if s.conn == nil {
+			conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			s.conn = conn
+		}
+		_, err := io.WriteString(s.conn, line)
+		if err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+		if retry > 0 {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+func (s *syslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// httpSink posts messages to the collector
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func newHTTPSink(url string) (*httpSink, error) {
+	if url == "" {
+		return nil, fmt.Errorf("url is required for http sink")
+	}
+	return &httpSink{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (s *httpSink) Write(entry *Entry) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewBufferString(entry.Message))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "text/plain")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // nolint:errcheck
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("collector responds status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
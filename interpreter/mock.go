@@ -0,0 +1,87 @@
+package interpreter
+
+import (
+	"bytes"
+	gocontext "context"
+	"io"
+	ghttp "net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/exception"
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+)
+
+// findMockBackend returns the first mock which matches the backend name, method and path of the request
+func findMockBackend(ctx *context.Context, backendName string, req *http.Request) *context.MockBackend {
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	for _, m := range ctx.MockBackends {
+		if m.Match(backendName, req.Method, path) {
+			return m
+		}
+	}
+	return nil
+}
+
+// sendMockBackendRequest responds the canned response of the mock after the latency
+func sendMockBackendRequest(ctx gocontext.Context, m *context.MockBackend, req *http.Request) (*http.Response, error) {
+	if m.Latency != "" {
+		latency, err := time.ParseDuration(m.Latency)
+		if err != nil {
+			return nil, exception.System("Invalid latency is provided for the mock backend: %s, %s", m.Latency, err)
+		}
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return nil, exception.Runtime(nil, "Failed to retrieve backend response: %s", ctx.Err())
+		}
+	}
+
+	status := m.Status
+	if status == 0 {
+		status = ghttp.StatusOK
+	}
+	header := ghttp.Header{}
+	for k, v := range m.Headers {
+		header.Set(k, v)
+	}
+	return http.WrapResponse(&ghttp.Response{
+		StatusCode:    status,
+		Status:        ghttp.StatusText(status),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(m.Body)),
+		ContentLength: int64(len(m.Body)),
+		Trailer:       ghttp.Header{},
+		Request:       req.Request,
+	}), nil
+}
+
+// recordOriginRequest records the backend request as seen by the origin.
+// Request body is read and rewound so that the request could be sent after recording
+func (i *Interpreter) recordOriginRequest(backendName string, req *http.Request, mocked bool) error {
+	var body []byte
+	if req.Body != nil && req.Body != ghttp.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return errors.WithStack(err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	i.ctx.OriginRequests = append(i.ctx.OriginRequests, &context.OriginRequest{
+		Backend: backendName,
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Header:  req.Header.Clone(),
+		Body:    string(body),
+		Mocked:  mocked,
+	})
+	return nil
+}
@@ -0,0 +1,191 @@
+package interpreter
+
+import (
+	"io"
+	ghttp "net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+func TestFindMockBackend(t *testing.T) {
+	mocks := []*config.MockBackend{
+		{Backend: "F_api", Method: "POST", Path: "/v1/*", Status: 201},
+		{Backend: "F_api", Path: "/v1/**", Status: 200},
+		{Backend: "F_*", Status: 503},
+	}
+
+	tests := []struct {
+		name    string
+		backend string
+		method  string
+		url     string
+		expect  *config.MockBackend
+	}{
+		{name: "match method and path", backend: "F_api", method: "POST", url: "/v1/users", expect: mocks[0]},
+		{name: "single star does not match nested path", backend: "F_api", method: "POST", url: "/v1/users/1", expect: mocks[1]},
+		{name: "method mismatch", backend: "F_api", method: "GET", url: "/v1/users", expect: mocks[1]},
+		{name: "match only backend", backend: "F_origin", method: "GET", url: "/", expect: mocks[2]},
+		{name: "no match", backend: "origin", method: "GET", url: "/"},
+	}
+
+	compiled, err := context.NewMockBackends(mocks)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.New(context.WithMockBackends(compiled))
+			req, err := http.NewRequest(tt.method, "http://localhost"+tt.url, ghttp.NoBody)
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			var m *config.MockBackend
+			if mock := findMockBackend(ctx, tt.backend, req); mock != nil {
+				m = mock.MockBackend
+			}
+			if m != tt.expect {
+				t.Errorf("Mock mismatch, expect=%v, got=%v", tt.expect, m)
+			}
+		})
+	}
+
+	t.Run("invalid glob pattern", func(t *testing.T) {
+		if _, err := context.NewMockBackends([]*config.MockBackend{{Path: "/v1/[a"}}); err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func TestSendMockBackendRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		mock         *config.MockBackend
+		fetchTimeout time.Duration
+		expectStatus int
+		expectBody   string
+		isError      bool
+	}{
+		{
+			name:         "canned response",
+			mock:         &config.MockBackend{Status: 404, Body: "not found", Headers: map[string]string{"X-Mock": "1"}},
+			expectStatus: 404,
+			expectBody:   "not found",
+		},
+		{
+			name:         "default status",
+			mock:         &config.MockBackend{Latency: "10ms"},
+			expectStatus: 200,
+		},
+		{
+			name:         "latency exceeds fetch timeout",
+			mock:         &config.MockBackend{Latency: "1s"},
+			fetchTimeout: 10 * time.Millisecond,
+			isError:      true,
+		},
+		{
+			name:    "invalid latency",
+			mock:    &config.MockBackend{Latency: "invalid"},
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := New()
+			mocks, err := context.NewMockBackends([]*config.MockBackend{tt.mock})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			ip.ctx = context.New(
+				context.WithMockBackends(mocks),
+				context.WithOriginRequestRecording(true),
+			)
+			if tt.fetchTimeout > 0 {
+				ip.ctx.FetchTimeout = &value.RTime{Value: tt.fetchTimeout}
+			}
+			req, err := http.NewRequest(ghttp.MethodPost, "http://localhost/path", strings.NewReader("payload"))
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			ip.ctx.Request = req
+
+			backend := newBackend("F_origin",
+				backendProperty("host", &ast.String{Value: "example.com"}),
+			)
+			if ip.ctx.BackendRequest, err = ip.createBackendRequest(ip.ctx, backend); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			ip.ctx.BackendRequest.Header.Set("X-Edge", "falco")
+
+			resp, err := ip.sendBackendRequest(backend)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if resp.StatusCode != tt.expectStatus {
+				t.Errorf("Status mismatch, expect=%d, got=%d", tt.expectStatus, resp.StatusCode)
+			}
+			body, _ := io.ReadAll(resp.Body) // nolint:errcheck
+			if string(body) != tt.expectBody {
+				t.Errorf("Body mismatch, expect=%q, got=%q", tt.expectBody, string(body))
+			}
+			for k, v := range tt.mock.Headers {
+				if resp.Header.Get(k) != v {
+					t.Errorf("Header %s mismatch, expect=%q, got=%q", k, v, resp.Header.Get(k))
+				}
+			}
+
+			expect := []*context.OriginRequest{
+				{
+					Backend: "F_origin",
+					Method:  ghttp.MethodPost,
+					URL:     "http://example.com:80/path",
+					Header:  ip.ctx.BackendRequest.Header.Clone(),
+					Body:    "payload",
+					Mocked:  true,
+				},
+			}
+			if diff := cmp.Diff(expect, ip.ctx.OriginRequests); diff != "" {
+				t.Errorf("Recorded origin requests mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
+
+func TestOriginRequestIsNotRecordedByDefault(t *testing.T) {
+	mocks, err := context.NewMockBackends([]*config.MockBackend{{Status: 200}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	ip := New()
+	ip.ctx = context.New(context.WithMockBackends(mocks))
+	if ip.ctx.Request, err = http.NewRequest(ghttp.MethodPost, "http://localhost/path", strings.NewReader("payload")); err != nil {
+		t.Fatalf("Failed to create request: %s", err)
+	}
+	backend := newBackend("F_origin",
+		backendProperty("host", &ast.String{Value: "example.com"}),
+	)
+	if ip.ctx.BackendRequest, err = ip.createBackendRequest(ip.ctx, backend); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := ip.sendBackendRequest(backend); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(ip.ctx.OriginRequests) > 0 {
+		t.Errorf("Origin requests must not be recorded, got %d requests", len(ip.ctx.OriginRequests))
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/assign"
 	"github.com/ysugimoto/falco/v2/interpreter/context"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
-	pcre "go.elara.ws/pcre"
+	"github.com/ysugimoto/falco/v2/regex"
 )
 
 func Equal(left, right value.Value) (value.Value, error) {
@@ -722,7 +722,7 @@ func Regex(ctx *context.Context, left, right value.Value) (value.Value, error) {
 					fmt.Errorf("right String type must be a literal"),
 				)
 			}
-			re, err := pcre.Compile(rv.Value)
+			re, err := regex.Compile(rv.Value)
 			if err != nil {
 				ctx.FastlyError = &value.String{Value: "EREGRECUR"}
 				return value.Null, errors.WithStack(
@@ -744,7 +744,7 @@ func Regex(ctx *context.Context, left, right value.Value) (value.Value, error) {
 			if rv.Unsatisfiable {
 				return &value.Boolean{Value: false}, nil
 			}
-			re, err := pcre.Compile(rv.Value)
+			re, err := regex.Compile(rv.Value)
 			if err != nil {
 				ctx.FastlyError = &value.String{Value: "EREGRECUR"}
 				return value.Null, errors.WithStack(
@@ -17,6 +17,7 @@ type Process struct {
 	Restarts  int
 	Backend   *value.Backend
 	Cached    bool
+	State     string // cache state of the request, same as fastly_info.state
 	Error     error
 	StartTime int64
 	Response  *http.Response
@@ -83,7 +84,7 @@ func (p *Process) Finalize(resp *http.Response) ([]byte, error) {
 		Logs:          p.Logs,
 		Restarts:      p.Restarts,
 		Backend:       backend,
-		Cached:        false,
+		Cached:        p.Cached,
 		ElapsedTimeUs: time.Now().UnixMicro() - p.StartTime,
 		ElapsedTimeMs: time.Now().UnixMilli() - (p.StartTime / 1000),
 		Error:         errMsg,
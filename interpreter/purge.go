@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	ghttp "net/http"
+	"strings"
+)
+
+const (
+	fastlyAPIHost        = "api.fastly.com"
+	fastlySoftPurgeValue = "1"
+	purgeAcceptanceID    = "falco_purge_acceptance"
+)
+
+// Soft purge is requested by Fastly-Soft-Purge header
+// see: https://www.fastly.com/documentation/guides/full-site-delivery/purging/soft-purges/
+func isSoftPurge(r *ghttp.Request) bool {
+	return r.Header.Get("Fastly-Soft-Purge") == fastlySoftPurgeValue
+}
+
+// Purge the object which is identified by the request hash that is calculated in vcl_hash subroutine
+func (i *Interpreter) purgeURL() {
+	soft := isSoftPurge(i.ctx.Request.Request)
+	if i.cache.Purge(i.ctx.RequestHash.Value, soft) {
+		i.Debugger.Message(fmt.Sprintf("Purged cache object for %s (soft=%t)", i.ctx.RequestHash.Value, soft))
+	}
+}
+
+// servePurgeAPI handles a subset of Fastly purge API which is sent to api.fastly.com host.
+// Returns true if the request is handled as purge API request.
+// see: https://www.fastly.com/documentation/reference/api/purging/
+//
+// Supported endpoints are:
+// - POST /service/{service_id}/purge/{surrogate_key} - purge a single surrogate key
+// - POST /service/{service_id}/purge - purge multiple surrogate keys which are specified in Surrogate-Key header
+// - POST /service/{service_id}/purge_all - purge all objects
+func (i *Interpreter) servePurgeAPI(w ghttp.ResponseWriter, r *ghttp.Request) bool {
+	if r.Host != fastlyAPIHost || r.Method != ghttp.MethodPost {
+		return false
+	}
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(segments) < 3 || segments[0] != "service" {
+		return false
+	}
+
+	soft := isSoftPurge(r)
+	switch {
+	case len(segments) == 3 && segments[2] == "purge_all":
+		purged := i.cache.PurgeAll()
+		i.Debugger.Message(fmt.Sprintf("Purged all %d cache objects", purged))
+		sendPurgeAPIResponse(w, map[string]string{"status": "ok"})
+	case len(segments) == 4 && segments[2] == "purge":
+		purged := i.cache.PurgeKey(segments[3], soft)
+		i.Debugger.Message(fmt.Sprintf("Purged %d cache objects for key %s (soft=%t)", purged, segments[3], soft))
+		sendPurgeAPIResponse(w, map[string]string{"status": "ok", "id": purgeAcceptanceID})
+	case len(segments) == 3 && segments[2] == "purge":
+		keys := strings.Fields(r.Header.Get("Surrogate-Key"))
+		if len(keys) == 0 {
+			ghttp.Error(w, "Surrogate-Key header is required", ghttp.StatusBadRequest)
+			return true
+		}
+		// Batch purge responds the map of surrogate key and purge id
+		ids := make(map[string]string, len(keys))
+		for _, key := range keys {
+			purged := i.cache.PurgeKey(key, soft)
+			i.Debugger.Message(fmt.Sprintf("Purged %d cache objects for key %s (soft=%t)", purged, key, soft))
+			ids[key] = purgeAcceptanceID
+		}
+		sendPurgeAPIResponse(w, ids)
+	default:
+		return false
+	}
+	return true
+}
+
+func sendPurgeAPIResponse(w ghttp.ResponseWriter, body map[string]string) {
+	out, err := json.Marshal(body)
+	if err != nil {
+		ghttp.Error(w, err.Error(), ghttp.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(ghttp.StatusOK)
+	w.Write(out) // nolint:errcheck
+}
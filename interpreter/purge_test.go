@@ -0,0 +1,96 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func sendPurgeRequest(t *testing.T, ip *Interpreter, method, target string, headers map[string]string) {
+	req := httptest.NewRequest(method, target, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, req)
+	if rec.Result().StatusCode != http.StatusOK {
+		t.Errorf("Purge request failed, status=%d", rec.Result().StatusCode)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	vcl := `
+sub vcl_fetch {
+	set beresp.ttl = 60s;
+	set beresp.stale_while_revalidate = 60s;
+	set beresp.http.Surrogate-Key = "key-a key-b";
+}`
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		headers map[string]string
+		expects map[string]string
+	}{
+		{
+			name:    "URL purge",
+			method:  "FASTLYPURGE",
+			target:  "http://localhost",
+			expects: map[string]string{"X-Cache": "MISS", "X-Count": "2"},
+		},
+		{
+			name:    "URL soft purge",
+			method:  "FASTLYPURGE",
+			target:  "http://localhost",
+			headers: map[string]string{"Fastly-Soft-Purge": "1"},
+			expects: map[string]string{"X-Cache": "HIT-STALE", "X-Count": "1"},
+		},
+		{
+			name:    "surrogate key purge",
+			method:  http.MethodPost,
+			target:  "http://api.fastly.com/service/SERVICE_ID/purge/key-b",
+			expects: map[string]string{"X-Cache": "MISS", "X-Count": "2"},
+		},
+		{
+			name:    "surrogate key soft purge",
+			method:  http.MethodPost,
+			target:  "http://api.fastly.com/service/SERVICE_ID/purge/key-a",
+			headers: map[string]string{"Fastly-Soft-Purge": "1"},
+			expects: map[string]string{"X-Cache": "HIT-STALE", "X-Count": "1"},
+		},
+		{
+			name:    "batch surrogate key purge",
+			method:  http.MethodPost,
+			target:  "http://api.fastly.com/service/SERVICE_ID/purge",
+			headers: map[string]string{"Surrogate-Key": "key-c key-a"},
+			expects: map[string]string{"X-Cache": "MISS", "X-Count": "2"},
+		},
+		{
+			name:    "unknown surrogate key purge",
+			method:  http.MethodPost,
+			target:  "http://api.fastly.com/service/SERVICE_ID/purge/key-c",
+			expects: map[string]string{"X-Cache": "HIT", "X-Count": "1"},
+		},
+		{
+			name:    "purge all",
+			method:  http.MethodPost,
+			target:  "http://api.fastly.com/service/SERVICE_ID/purge_all",
+			expects: map[string]string{"X-Cache": "MISS", "X-Count": "2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStaleServer(t, vcl, func(n int32) bool { return false }, func(ip *Interpreter, _ *httptest.Server, _ *atomic.Int32) {
+				assertStaleResponse(t, sendStaleRequest(ip), map[string]string{"X-Cache": "MISS", "X-Count": "1"})
+				assertStaleResponse(t, sendStaleRequest(ip), map[string]string{"X-Cache": "HIT", "X-Count": "1"})
+
+				sendPurgeRequest(t, ip, tt.method, tt.target, tt.headers)
+				assertStaleResponse(t, sendStaleRequest(ip), tt.expects)
+				ip.revalidations.Wait()
+			})
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package interpreter
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/http/httptest"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/exception"
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/limitations"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+// Shield node sees the edge node as the client
+const shieldClientAddr = "127.0.0.1:0"
+
+// NewShieldNode returns the interpreter which runs as the shield node in shielding mode.
+// The shield node has its own cache, and the edge node which is created with context.WithShield() option
+// sends the request to the shield node when the shield director is selected as the backend.
+// The shield node always responds the actual response to the edge node.
+func NewShieldNode(options ...context.Option) *Interpreter {
+	opts := append([]context.Option{}, options...)
+	i := New(append(opts, context.WithShieldNode(true), context.WithActualResponse(true))...)
+	i.Debugger = shieldDebugger{i.Debugger}
+	return i
+}
+
+// Debugger for the shield node, messages are prefixed to distinguish from the edge node
+type shieldDebugger struct {
+	Debugger
+}
+
+func (d shieldDebugger) Message(msg string) {
+	d.Debugger.Message("[shield] " + msg)
+}
+
+func (i *Interpreter) createShieldRequest(ctx *context.Context, dc *value.DirectorConfig) (*http.Request, error) {
+	if ctx.IsShieldNode {
+		return nil, exception.Runtime(
+			nil,
+			"Shield director %s is selected on the shield node. Use fastly.try_select_shield() or check fastly.ff.visits_this_service to select the origin",
+			dc.Name,
+		)
+	}
+	if ctx.Shield == nil {
+		return nil, exception.Runtime(nil, "Shield director %s requires shielding mode, run the simulator with --shield option", dc.Name)
+	}
+	ctx.FetchBackend = ctx.Backend
+
+	url := fmt.Sprintf("%s://%s%s", HTTP_SCHEME, i.ctx.Request.Host, i.ctx.Request.URL.Path)
+	query := i.ctx.Request.URL.Query()
+	if v := query.Encode(); v != "" {
+		url += "?" + v
+	}
+
+	req, err := http.NewRequest(i.ctx.Request.Method, url, i.ctx.Request.Body)
+	if err != nil {
+		return nil, exception.Runtime(nil, "Failed to create shield request: %s", err)
+	}
+	req.Header = i.ctx.Request.Header.Clone()
+	setupFastlyHeaders(ctx, req)
+	return req, nil
+}
+
+// sendShieldRequest sends the backend request to the shield node in process
+func (i *Interpreter) sendShieldRequest() (*http.Response, error) {
+	timeout := 15 * time.Second // 15 seconds as default
+	if i.ctx.FetchTimeout != nil && i.ctx.FetchTimeout.Value > 0 {
+		timeout = i.ctx.FetchTimeout.Value
+	}
+	ctx, to := gocontext.WithTimeout(i.ctx.Request.Context(), timeout)
+	defer to()
+
+	req := i.ctx.BackendRequest.Clone(ctx)
+	if err := limitations.CheckFastlyRequestLimit(req); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// Host header may be changed in VCL, shield node receives it as the request host
+	if v := req.Header.Get("Host"); v != "" {
+		req.Host = v
+	}
+	req.RemoteAddr = shieldClientAddr
+
+	name := i.ctx.FetchBackend.String()
+	i.Debugger.Message(fmt.Sprintf("Fetching shield (%s) %s", name, req.URL.String()))
+
+	rec := httptest.NewRecorder()
+	i.ctx.Shield.ServeHTTP(rec, req.Request)
+	if err := ctx.Err(); err != nil {
+		return nil, exception.Runtime(nil, "Failed to retrieve shield response: %s", err)
+	}
+	resp := rec.Result()
+	i.Debugger.Message(fmt.Sprintf("Shield (%s) responds status code %d", name, resp.StatusCode))
+	return http.WrapResponse(resp), nil
+}
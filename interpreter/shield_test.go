@@ -0,0 +1,200 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestShielding(t *testing.T) {
+	var count atomic.Int32
+	var fastlyFF atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		fastlyFF.Store(r.Header.Get("Fastly-FF"))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+
+	tests := []struct {
+		name string
+		vcl  string
+	}{
+		{
+			name: "select shield by fastly.ff.visits_this_service",
+			vcl: `
+sub vcl_recv {
+	if (fastly.ff.visits_this_service == 0) {
+		set req.backend = ssl_shield_falco;
+	} else {
+		set req.backend = example;
+	}
+	return (lookup);
+}`,
+		},
+		{
+			name: "select shield by fastly.try_select_shield",
+			vcl: `
+sub vcl_recv {
+	set req.backend = fastly.try_select_shield(ssl_shield_falco, example);
+	return (lookup);
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count.Store(0)
+			vcl := defaultBackend(parsed) + `
+director ssl_shield_falco shield { }
+
+sub vcl_deliver {
+	set resp.http.X-Is-Shield = if(req.backend.is_shield, "1", "0");
+	set resp.http.X-Visits = fastly.ff.visits_this_service;
+}` + tt.vcl
+			options := []context.Option{
+				context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+				context.WithActualResponse(true),
+			}
+			shield := NewShieldNode(options...)
+			ip := New(append(options, context.WithShield(shield.ConcurrentHandler(0)))...)
+			send := func() *http.Response {
+				rec := httptest.NewRecorder()
+				ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+				return rec.Result()
+			}
+
+			resp := send()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Unexpected HTTP status from interpreter %d", resp.StatusCode)
+			}
+			if v := resp.Header.Get("X-Cache"); v != "MISS, MISS" {
+				t.Errorf("X-Cache mismatch, expect=MISS, MISS, got=%s", v)
+			}
+			if v := resp.Header.Get("X-Served-By"); v != "cache-shieldsimulator-SHIELD, cache-localsimulator-FALCO" {
+				t.Errorf("X-Served-By mismatch, got=%s", v)
+			}
+			// Shield node delivers the response first, then edge node overrides the value
+			if v := resp.Header.Get("X-Is-Shield"); v != "1" {
+				t.Errorf("req.backend.is_shield must be true on the edge node, got=%s", v)
+			}
+			if v := resp.Header.Get("X-Visits"); v != "0" {
+				t.Errorf("fastly.ff.visits_this_service must be 0 on the edge node, got=%s", v)
+			}
+			ff, _ := fastlyFF.Load().(string)
+			if n := len(strings.Split(ff, ",")); n != 2 {
+				t.Errorf("Origin must receive 2 Fastly-FF entries, got=%s", ff)
+			}
+			if !strings.Contains(ff, "cache-shieldsimulator") || !strings.Contains(ff, "cache-localsimulator") {
+				t.Errorf("Fastly-FF must contain both nodes, got=%s", ff)
+			}
+
+			// Second request hits on the edge node
+			if v := send().Header.Get("X-Cache"); v != "MISS, HIT" {
+				t.Errorf("X-Cache mismatch, expect=MISS, HIT, got=%s", v)
+			}
+			if n := count.Load(); n != 1 {
+				t.Errorf("Origin request count mismatch, expect=1, got=%d", n)
+			}
+		})
+	}
+}
+
+func TestShieldingErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		vcl    string
+		shield bool
+	}{
+		{
+			name: "shield director without shielding mode",
+			vcl: `
+backend example { .host = "localhost"; }
+director ssl_shield_falco shield { }
+sub vcl_recv {
+	set req.backend = ssl_shield_falco;
+	return (lookup);
+}`,
+		},
+		{
+			name: "shield director is selected on the shield node",
+			vcl: `
+backend example { .host = "localhost"; }
+director ssl_shield_falco shield { }
+sub vcl_recv {
+	set req.backend = ssl_shield_falco;
+	return (lookup);
+}`,
+			shield: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := []context.Option{
+				context.WithResolver(resolver.NewStaticResolver("main", tt.vcl)),
+				context.WithActualResponse(true),
+			}
+			if tt.shield {
+				options = append(options, context.WithShield(NewShieldNode(options...)))
+			}
+			ip := New(options...)
+			rec := httptest.NewRecorder()
+			ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+			// Shield node responds 502 to the edge node when it fails to process the request
+			if rec.Code != http.StatusBadGateway {
+				t.Errorf("Unexpected HTTP status from interpreter %d", rec.Code)
+			}
+		})
+	}
+}
+
+func TestDirectorFetch(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+
+	vcl := defaultBackend(parsed) + `
+director example_director random {
+	{ .backend = example; .weight = 1; }
+}
+sub vcl_recv {
+	set req.backend = example_director;
+	return (pass);
+}`
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+	)
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Unexpected HTTP status from interpreter %d", rec.Code)
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("Origin request count mismatch, expect=1, got=%d", n)
+	}
+}
@@ -0,0 +1,184 @@
+package interpreter
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func withStaleServer(t *testing.T, vcl string, failed func(n int32) bool, test func(ip *Interpreter, server *httptest.Server, count *atomic.Int32)) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := count.Add(1)
+		w.Header().Set("X-Count", fmt.Sprint(n))
+		if failed(n) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("NG")) // nolint:errcheck
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK")) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+	vcl = defaultBackend(parsed) + "\nsub vcl_recv { return (lookup); }\n" + vcl
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithActualResponse(true),
+	)
+	test(ip, server, &count)
+}
+
+func sendStaleRequest(ip *Interpreter) *http.Response {
+	rec := httptest.NewRecorder()
+	ip.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost", nil))
+	return rec.Result()
+}
+
+func assertStaleResponse(t *testing.T, resp *http.Response, expects map[string]string) {
+	for key, expect := range expects {
+		if v := resp.Header.Get(key); v != expect {
+			t.Errorf("Header %s mismatch, expect=%s, got=%s", key, expect, v)
+		}
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	vcl := `
+sub vcl_fetch {
+	if (beresp.http.X-Count == "1") {
+		set beresp.ttl = 1ms;
+	} else {
+		set beresp.ttl = 60s;
+	}
+	set beresp.stale_while_revalidate = 60s;
+}
+
+sub vcl_deliver {
+	set resp.http.X-Stale = if(resp.stale, "1", "0");
+	set resp.http.X-Revalidating = if(resp.stale.is_revalidating, "1", "0");
+}`
+
+	withStaleServer(t, vcl, func(n int32) bool { return false }, func(ip *Interpreter, _ *httptest.Server, count *atomic.Int32) {
+		assertStaleResponse(t, sendStaleRequest(ip), map[string]string{
+			"X-Cache": "MISS", "X-Count": "1", "X-Stale": "0", "X-Revalidating": "0",
+		})
+		time.Sleep(10 * time.Millisecond)
+
+		// Stale object is served and revalidated in background
+		assertStaleResponse(t, sendStaleRequest(ip), map[string]string{
+			"X-Cache": "HIT-STALE", "X-Count": "1", "X-Stale": "1", "X-Revalidating": "1",
+		})
+		ip.revalidations.Wait()
+		if n := count.Load(); n != 2 {
+			t.Errorf("Background fetch is not executed, origin request count=%d", n)
+		}
+
+		// Revalidated object is served
+		assertStaleResponse(t, sendStaleRequest(ip), map[string]string{
+			"X-Cache": "HIT", "X-Count": "2", "X-Stale": "0", "X-Revalidating": "0",
+		})
+	})
+}
+
+func TestStaleIfError(t *testing.T) {
+	t.Run("deliver stale object on origin error response", func(t *testing.T) {
+		vcl := `
+sub vcl_fetch {
+	if (beresp.status >= 500 && stale.exists) {
+		return (deliver_stale);
+	}
+	set beresp.ttl = 1ms;
+	set beresp.stale_if_error = 60s;
+}
+
+sub vcl_deliver {
+	set resp.http.X-Stale = if(resp.stale, "1", "0");
+	set resp.http.X-Stale-Error = if(resp.stale.is_error, "1", "0");
+}`
+
+		withStaleServer(t, vcl, func(n int32) bool { return n > 1 }, func(ip *Interpreter, _ *httptest.Server, count *atomic.Int32) {
+			assertStaleResponse(t, sendStaleRequest(ip), map[string]string{
+				"X-Count": "1", "X-Stale": "0", "X-Stale-Error": "0",
+			})
+			time.Sleep(10 * time.Millisecond)
+
+			resp := sendStaleRequest(ip)
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Stale object is not delivered, status=%d", resp.StatusCode)
+			}
+			assertStaleResponse(t, resp, map[string]string{
+				"X-Count": "1", "X-Stale": "1", "X-Stale-Error": "1",
+			})
+			if n := count.Load(); n != 2 {
+				t.Errorf("Origin request count mismatch, expect=2, got=%d", n)
+			}
+		})
+	})
+
+	t.Run("deliver stale object on origin connection failure", func(t *testing.T) {
+		vcl := `
+sub vcl_fetch {
+	set beresp.ttl = 1ms;
+	set beresp.stale_if_error = 60s;
+}
+
+sub vcl_error {
+	if (stale.exists) {
+		return (deliver_stale);
+	}
+}
+
+sub vcl_deliver {
+	set resp.http.X-Stale-Error = if(resp.stale.is_error, "1", "0");
+}`
+
+		withStaleServer(t, vcl, func(n int32) bool { return false }, func(ip *Interpreter, server *httptest.Server, count *atomic.Int32) {
+			assertStaleResponse(t, sendStaleRequest(ip), map[string]string{
+				"X-Count": "1", "X-Stale-Error": "0",
+			})
+			time.Sleep(10 * time.Millisecond)
+			server.Close()
+
+			resp := sendStaleRequest(ip)
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Stale object is not delivered, status=%d", resp.StatusCode)
+			}
+			assertStaleResponse(t, resp, map[string]string{
+				"X-Count": "1", "X-Stale-Error": "1",
+			})
+		})
+	})
+
+	t.Run("stale object is not delivered after stale-if-error period", func(t *testing.T) {
+		vcl := `
+sub vcl_fetch {
+	if (beresp.status >= 500 && stale.exists) {
+		return (deliver_stale);
+	}
+	set beresp.ttl = 1ms;
+	set beresp.stale_if_error = 1ms;
+}`
+
+		withStaleServer(t, vcl, func(n int32) bool { return n > 1 }, func(ip *Interpreter, _ *httptest.Server, count *atomic.Int32) {
+			sendStaleRequest(ip)
+			time.Sleep(10 * time.Millisecond)
+
+			if resp := sendStaleRequest(ip); resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("Unexpected stale object delivery, status=%d", resp.StatusCode)
+			}
+		})
+	})
+}
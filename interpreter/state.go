@@ -19,6 +19,7 @@ const (
 	HIT_FOR_PASS   State = "hit_for_pass" // alias for pass
 	INTERNAL_ERROR State = "_internal_error_"
 	BARE_RETURN    State = "_bare_return_"
+	GOTO           State = "_goto_" // internal state to unwind blocks until goto destination is found
 )
 
 func (s State) String() string {
@@ -47,6 +48,8 @@ func (s State) String() string {
 		return "_internal_error_"
 	case BARE_RETURN:
 		return "_bare_return_"
+	case GOTO:
+		return "_goto_"
 	default:
 		return ""
 	}
@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"fmt"
 	"io"
 	"strings"
 
@@ -11,10 +12,11 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/exception"
 	"github.com/ysugimoto/falco/v2/interpreter/function"
 	fe "github.com/ysugimoto/falco/v2/interpreter/function/errors"
-	"github.com/ysugimoto/falco/v2/interpreter/limitations"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
 	"github.com/ysugimoto/falco/v2/interpreter/operator"
 	"github.com/ysugimoto/falco/v2/interpreter/process"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
+	"github.com/ysugimoto/falco/v2/limits"
 )
 
 // _nopSeekCloser is like io.NopCloser, but for wrapping io.ReadSeeker.
@@ -38,7 +40,10 @@ func (i *Interpreter) ProcessBlockStatement(
 	var err error
 	var debugState = ds
 
-	for _, stmt := range statements {
+	for index := 0; index < len(statements); index++ {
+		stmt := statements[index]
+		var state State
+
 		// Call debugger
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
@@ -83,15 +88,13 @@ func (i *Interpreter) ProcessBlockStatement(
 			}
 			err = i.ProcessSyntheticBase64Statement(t)
 
-		// TODO: implement
-		// case *ast.GotoStatement:
-		// 	err = i.ProcessGotoStatement(t)
-		// case *ast.GotoDestinationStatement:
-		// 	err = i.ProcessGotoDesticationStatement(t)
+		case *ast.GotoStatement:
+			state = i.ProcessGotoStatement(t)
+		case *ast.GotoDestinationStatement:
+			// Nothing to do, goto destination is just a label to jump
 
 		// Probably change status statements
 		case *ast.FunctionCallStatement:
-			var state State
 			// Enable breakpoint if current debug state is step-in
 			if debugState == DebugStepIn {
 				state, err = i.ProcessFunctionCallStatement(t, DebugStepIn)
@@ -103,7 +106,6 @@ func (i *Interpreter) ProcessBlockStatement(
 			}
 
 		case *ast.CallStatement:
-			var state State
 			// Enable breakpoint if current debug state is step-in
 			if debugState == DebugStepIn {
 				state, err = i.ProcessCallStatement(t, DebugStepIn)
@@ -116,25 +118,23 @@ func (i *Interpreter) ProcessBlockStatement(
 
 		case *ast.IfStatement:
 			var val value.Value
-			var state State
 			// If statement has nested block statement so need to pass current isReturnAsValue
 			// and evaluate return value is either value.Value or state
 			val, state, err = i.ProcessIfStatement(t, debugState, isReturnAsValue)
 			if val != value.Null {
 				return val, NONE, DebugPass, err
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, DebugPass, nil
 			}
 
 		case *ast.SwitchStatement:
 			var val value.Value
-			var state State
 			val, state, err = i.ProcessSwitchStatement(t, debugState, isReturnAsValue)
 			if val != value.Null {
 				return val, NONE, DebugPass, err
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, DebugPass, nil
 			}
 
@@ -147,11 +147,11 @@ func (i *Interpreter) ProcessBlockStatement(
 			}
 
 			// If next restart will exceed Fastly restart count limit, raise an exception
-			if i.ctx.Restarts+1 > limitations.MaxVarnishRestarts {
+			if i.ctx.Restarts+1 > limits.MaxVarnishRestarts {
 				return value.Null, NONE, DebugPass, exception.Runtime(
 					&t.Token,
 					"Max restart limit exceeded. Requests are limited to %d restarts",
-					limitations.MaxVarnishRestarts,
+					limits.MaxVarnishRestarts,
 				)
 			}
 
@@ -188,14 +188,15 @@ func (i *Interpreter) ProcessBlockStatement(
 		case *ast.BlockStatement:
 			// nested block statement also need to pass current isReturnAsValue
 			// and evaluate return value is either value.Value or state
-			val, state, _, err := i.ProcessBlockStatement(t.Statements, debugState, isReturnAsValue)
+			var val value.Value
+			val, state, _, err = i.ProcessBlockStatement(t.Statements, debugState, isReturnAsValue)
 			if err != nil {
 				return value.Null, NONE, DebugPass, errors.WithStack(err)
 			}
 			if val != value.Null {
 				return val, NONE, DebugPass, nil
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, DebugPass, nil
 			}
 
@@ -209,6 +210,19 @@ func (i *Interpreter) ProcessBlockStatement(
 		if err != nil {
 			return value.Null, INTERNAL_ERROR, DebugPass, errors.WithStack(err)
 		}
+
+		// Jump to the goto destination if it is placed in this block,
+		// otherwise propagate GOTO state to the outer block
+		if state == GOTO {
+			next, err := i.findGotoDestination(statements, index)
+			if err != nil {
+				return value.Null, NONE, DebugPass, errors.WithStack(err)
+			}
+			if next < 0 {
+				return value.Null, GOTO, DebugPass, nil
+			}
+			index = next - 1
+		}
 	}
 	return value.Null, NONE, DebugPass, nil
 }
@@ -239,6 +253,43 @@ func (i *Interpreter) ProcessReturnStatement(stmt *ast.ReturnStatement) State {
 	return State(stmt.ReturnExpression.String())
 }
 
+// ProcessGotoStatement holds the goto statement as pending and returns GOTO state.
+// The state is propagated to the outer blocks until the block which has the destination is found.
+func (i *Interpreter) ProcessGotoStatement(stmt *ast.GotoStatement) State {
+	i.gotoStmt = stmt
+	return GOTO
+}
+
+// findGotoDestination finds the destination of the pending goto statement in the statements
+// and returns its index, or -1 if the destination is not placed in the statements.
+// Fastly only allows jumping forward so the destination which is placed before
+// the current statement raises an exception.
+// @fiddle: https://fiddle.fastly.dev/fiddle/4814c144
+func (i *Interpreter) findGotoDestination(statements []ast.Statement, current int) (int, error) {
+	name := i.gotoStmt.Destination.Value + ":"
+	for index, stmt := range statements {
+		gd, ok := stmt.(*ast.GotoDestinationStatement)
+		if !ok || gd.Name.Value != name {
+			continue
+		}
+		if index <= current {
+			return -1, exception.Runtime(
+				&i.gotoStmt.GetMeta().Token,
+				"A jump backwards is not allowed. Goto destination %s must be defined after this statement",
+				i.gotoStmt.Destination.Value,
+			)
+		}
+		i.gotoStmt = nil
+		// Rewind to the coverage markers which are put in front of the destination
+		// in order to record the destination as covered
+		for index-1 > current && statements[index-1].GetMeta() == fake {
+			index--
+		}
+		return index, nil
+	}
+	return -1, nil
+}
+
 func (i *Interpreter) ProcessSetStatement(stmt *ast.SetStatement) error {
 	// If set target ident is local variable, do it on specific method
 	if isLocalVariableIdent(stmt.Ident) {
@@ -289,7 +340,7 @@ func (i *Interpreter) ProcessSetStatement(stmt *ast.SetStatement) error {
 // already spent when vcl_recv begins: a fixed overhead plus every inbound header
 // line, each charged the same way a VCL write is.
 func (i *Interpreter) chargeInboundRequestWorkspace() {
-	i.ctx.RequestWorkspaceBytes += limitations.BaseRequestWorkspaceOverhead
+	i.ctx.RequestWorkspaceBytes += limits.BaseRequestWorkspaceOverhead
 	if i.ctx.Request == nil {
 		return
 	}
@@ -311,11 +362,11 @@ func (i *Interpreter) chargeInboundRequestWorkspace() {
 func (i *Interpreter) accountRequestWorkspace(ident *ast.Ident, val value.Value) error {
 	name := requestHeaderName(ident)
 	i.ctx.RequestWorkspaceBytes += roundUpToPointer(len(name) + len([]byte(val.String())) + 3)
-	if i.ctx.RequestWorkspaceBytes > limitations.MaxRequestWorkspaceSize {
+	if i.ctx.RequestWorkspaceBytes > limits.MaxRequestWorkspaceSize {
 		return exception.Runtime(
 			&ident.GetMeta().Token,
 			"Header overflow: request workspace limitation of %d bytes exceeded",
-			limitations.MaxRequestWorkspaceSize,
+			limits.MaxRequestWorkspaceSize,
 		)
 	}
 	return nil
@@ -487,7 +538,9 @@ func (i *Interpreter) ProcessEsiStatement(stmt *ast.EsiStatement) error {
 			"esi statement found but it could only be enable on FETCH directive",
 		)
 	} else {
+		// esi statement is equivalent to set beresp.do_esi = true
 		i.ctx.TriggerESI = true
+		i.ctx.BackendResponseDoESI.Value = true
 	}
 	return nil
 }
@@ -499,19 +552,42 @@ func (i *Interpreter) ProcessLogStatement(stmt *ast.LogStatement) error {
 	}
 
 	line := log.String()
-	if len([]byte(line)) > limitations.MaxLogLineSize {
+	if len([]byte(line)) > limits.MaxLogLineSize {
 		return exception.Runtime(
 			&stmt.GetMeta().Token,
 			"Overflow log line size limitation of %d",
-			limitations.MaxLogLineSize,
+			limits.MaxLogLineSize,
 		)
 	}
 
 	i.process.Logs = append(i.process.Logs, process.NewLog(stmt, i.ctx.Scope, line))
 	i.Debugger.Log(stmt, line)
+
+	// Route the line which has the logging endpoint prefix to the sink
+	if entry, ok := logging.ParseLogLine(line); ok {
+		i.sendLogEntry(entry)
+	}
 	return nil
 }
 
+// Logging endpoint failures never affect the request like Fastly, only report them via debugger
+func (i *Interpreter) sendLogEntry(entry *logging.Entry) {
+	// Validate endpoint name if logging endpoints are fetched from remote or terraform
+	if fs := i.ctx.FastlySnippets; fs != nil && len(fs.LoggingEndpoints) > 0 {
+		if _, ok := fs.LoggingEndpoints[entry.Endpoint]; !ok {
+			i.Debugger.Message(fmt.Sprintf("Logging endpoint %s is not defined in the service, log line is discarded", entry.Endpoint))
+			return
+		}
+	}
+	sink, ok := i.ctx.LoggingSinks[entry.Endpoint]
+	if !ok {
+		return
+	}
+	if err := sink.Write(entry); err != nil {
+		i.Debugger.Message(fmt.Sprintf("Failed to send log line to logging endpoint %s: %s", entry.Endpoint, err))
+	}
+}
+
 func (i *Interpreter) ProcessSyntheticStatement(stmt *ast.SyntheticStatement) error {
 	val, err := i.ProcessExpression(stmt.Value)
 	if err != nil {
@@ -549,7 +625,7 @@ func (i *Interpreter) ProcessFunctionCallStatement(stmt *ast.FunctionCallStateme
 	}
 
 	// Builtin function will not change any state
-	fn, err := function.Exists(i.ctx.Scope, stmt.Function.Value)
+	fn, err := function.Lookup(i.functions, i.ctx.Scope, stmt.Function.Value)
 	if err != nil {
 		return NONE, exception.Runtime(&stmt.GetMeta().Token, "%s", err.Error())
 	}
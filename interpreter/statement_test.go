@@ -2,13 +2,18 @@ package interpreter
 
 import (
 	ghttp "net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ysugimoto/falco/v2/ast"
 	"github.com/ysugimoto/falco/v2/interpreter/context"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/snippet"
 )
 
 func TestDeclareStatement(t *testing.T) {
@@ -929,3 +934,219 @@ func TestSwitchStatement(t *testing.T) {
 		})
 	}
 }
+
+func TestGotoStatement(t *testing.T) {
+	tests := []struct {
+		name       string
+		vcl        string
+		assertions map[string]value.Value
+		isError    bool
+	}{
+		{
+			name: "Jump forward in the same block",
+			vcl: `
+				sub vcl_recv {
+					set req.http.before = "1";
+					goto skip;
+					set req.http.skipped = "1";
+					skip:
+					set req.http.after = "1";
+				}`,
+			assertions: map[string]value.Value{
+				"req.http.before":  &value.String{Value: "1"},
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump from nested block to outer destination",
+			vcl: `
+				sub vcl_recv {
+					if (req.url) {
+						if (req.method == "GET") {
+							goto skip;
+						}
+						set req.http.skipped = "1";
+					}
+					set req.http.skipped = "1";
+					skip:
+					set req.http.after = "1";
+				}`,
+			assertions: map[string]value.Value{
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump from switch case",
+			vcl: `
+				sub vcl_recv {
+					switch (req.method) {
+					case "GET":
+						goto skip;
+						break;
+					default:
+						break;
+					}
+					set req.http.skipped = "1";
+					skip:
+					set req.http.after = "1";
+				}`,
+			assertions: map[string]value.Value{
+				"req.http.skipped": &value.String{IsNotSet: true},
+				"req.http.after":   &value.String{Value: "1"},
+			},
+		},
+		{
+			name: "Jump in functional subroutine",
+			vcl: `
+				sub compute STRING {
+					goto skip;
+					return "skipped";
+					skip:
+					return "jumped";
+				}
+
+				sub vcl_recv {
+					set req.http.result = compute();
+				}`,
+			assertions: map[string]value.Value{
+				"req.http.result": &value.String{Value: "jumped"},
+			},
+		},
+		{
+			name: "Backward jump raises an error",
+			vcl: `
+				sub vcl_recv {
+					back:
+					set req.http.loop = "1";
+					goto back;
+				}`,
+			isError: true,
+		},
+		{
+			name: "Undefined destination raises an error",
+			vcl: `
+				sub vcl_recv {
+					goto undefined;
+					set req.http.after = "1";
+				}`,
+			isError: true,
+		},
+		{
+			name: "Goto could not jump to other subroutine",
+			vcl: `
+				sub jump {
+					goto skip;
+				}
+
+				sub vcl_recv {
+					call jump;
+					skip:
+					set req.http.after = "1";
+				}`,
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertInterpreter(t, tt.vcl, context.RecvScope, tt.assertions, tt.isError)
+		})
+	}
+}
+
+func TestGotoBackwardJump(t *testing.T) {
+	tests := []struct {
+		name string
+		vcl  string
+	}{
+		{
+			name: "destination is placed before goto in the same block",
+			vcl: `
+				sub vcl_recv {
+					back:
+					set req.http.loop = "1";
+					goto back;
+				}`,
+		},
+		{
+			name: "destination is placed before the block which contains goto",
+			vcl: `
+				sub vcl_recv {
+					back:
+					if (req.url) {
+						goto back;
+					}
+				}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := New(context.WithResolver(resolver.NewStaticResolver("main", tt.vcl)))
+			req := httptest.NewRequest(ghttp.MethodGet, "http://localhost", nil)
+			ip.ServeHTTP(httptest.NewRecorder(), req)
+
+			if ip.process.Error == nil {
+				t.Fatalf("Expected error but got nil")
+			}
+			if !strings.Contains(ip.process.Error.Error(), "A jump backwards is not allowed") {
+				t.Errorf("Unexpected error: %s", ip.process.Error)
+			}
+		})
+	}
+}
+
+type recordSink struct {
+	entries []*logging.Entry
+}
+
+func (s *recordSink) Write(entry *logging.Entry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *recordSink) Close() error {
+	return nil
+}
+
+func TestLogStatementSinks(t *testing.T) {
+	vcl := `
+sub vcl_recv {
+	log "plain line";
+	log "syslog " req.service_id " my_endpoint :: routed line";
+	log "syslog " req.service_id " unknown_endpoint :: unknown line";
+}`
+
+	t.Run("route log lines to sinks", func(t *testing.T) {
+		sink := &recordSink{}
+		unknown := &recordSink{}
+		assertInterpreter(t, vcl, context.RecvScope, nil, false,
+			context.WithLoggingSinks(logging.Sinks{"my_endpoint": sink, "unknown_endpoint": unknown}),
+		)
+		if len(sink.entries) != 1 || sink.entries[0].Message != "routed line" {
+			t.Errorf("Unexpected entries: %v", sink.entries)
+		}
+		if len(unknown.entries) != 1 {
+			t.Errorf("Unexpected entries: %v", unknown.entries)
+		}
+	})
+
+	t.Run("discard log lines for undefined endpoints", func(t *testing.T) {
+		sink := &recordSink{}
+		unknown := &recordSink{}
+		assertInterpreter(t, vcl, context.RecvScope, nil, false,
+			context.WithLoggingSinks(logging.Sinks{"my_endpoint": sink, "unknown_endpoint": unknown}),
+			context.WithSnippets(&snippet.Snippets{
+				LoggingEndpoints: snippet.LoggingEndpoints{"my_endpoint": {}},
+			}),
+		)
+		if len(sink.entries) != 1 {
+			t.Errorf("Unexpected entries: %v", sink.entries)
+		}
+		if len(unknown.entries) != 0 {
+			t.Errorf("Log line for undefined endpoint must be discarded: %v", unknown.entries)
+		}
+	})
+}
@@ -62,7 +62,25 @@ func (i *Interpreter) ProcessSubroutine(sub *ast.SubroutineDeclaration, ds Debug
 
 	// Ignore debug status and must return state, not a value
 	_, state, _, err := i.ProcessBlockStatement(statements, ds, false)
-	return state, err
+	if err != nil {
+		return state, err
+	}
+	if state == GOTO {
+		return NONE, errors.WithStack(i.undefinedGotoDestination())
+	}
+	return state, nil
+}
+
+// undefinedGotoDestination reports pending goto statement which could not find its destination
+// in the subroutine. Goto could not jump across subroutines.
+func (i *Interpreter) undefinedGotoDestination() error {
+	stmt := i.gotoStmt
+	i.gotoStmt = nil
+	return exception.Runtime(
+		&stmt.GetMeta().Token,
+		"Goto destination %s is not defined in the subroutine",
+		stmt.Destination.Value,
+	)
 }
 
 // nolint: gocognit, funlen
@@ -98,7 +116,10 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 	var err error
 	var debugState = ds
 
-	for _, stmt := range sub.Block.Statements {
+	for index := 0; index < len(sub.Block.Statements); index++ {
+		stmt := sub.Block.Statements[index]
+		var state State
+
 		// Call debugger
 		if debugState != DebugStepOut {
 			debugState = i.Debugger.Run(stmt)
@@ -130,23 +151,21 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			err = i.ProcessSyntheticStatement(t)
 		case *ast.SyntheticBase64Statement:
 			err = i.ProcessSyntheticBase64Statement(t)
-		// case *ast.GotoStatement:
-		// 	err = i.ProcessGotoStatement(t)
-		// case *ast.GotoDestinationStatement:
-		// 	err = i.ProcessGotoDesticationStatement(t)
+		case *ast.GotoStatement:
+			state = i.ProcessGotoStatement(t)
+		case *ast.GotoDestinationStatement:
+			// Nothing to do, goto destination is just a label to jump
 		// Probably change status statements
 		case *ast.BlockStatement:
 			var val value.Value
-			var state State
 			val, state, _, err = i.ProcessBlockStatement(t.Statements, ds, true)
 			if val != value.Null {
 				return val, NONE, nil
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, nil
 			}
 		case *ast.FunctionCallStatement:
-			var state State
 			// Enable breakpoint if current debug state is step-in
 			if debugState == DebugStepIn {
 				state, err = i.ProcessFunctionCallStatement(t, DebugStepIn)
@@ -157,7 +176,6 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 				return value.Null, state, nil
 			}
 		case *ast.CallStatement:
-			var state State
 			// Enable breakpoint if current debug state is step-in
 			if debugState == DebugStepIn {
 				state, err = i.ProcessCallStatement(t, DebugStepIn)
@@ -169,23 +187,21 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			}
 		case *ast.IfStatement:
 			var val value.Value
-			var state State
 			// If statement inside functional subroutine could return value
 			val, state, err = i.ProcessIfStatement(t, debugState, true)
 			if val != value.Null {
 				return val, NONE, nil
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, nil
 			}
 		case *ast.SwitchStatement:
 			var val value.Value
-			var state State
 			val, state, err = i.ProcessSwitchStatement(t, debugState, true)
 			if val != value.Null {
 				return val, NONE, nil
 			}
-			if state != NONE {
+			if state != NONE && state != GOTO {
 				return value.Null, state, nil
 			}
 		case *ast.RestartStatement:
@@ -193,7 +209,6 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 			return value.Null, RESTART, nil
 		case *ast.ReturnStatement:
 			var val value.Value
-			var state State
 			val, state, err = i.ProcessExpressionReturnStatement(t)
 			if err != nil {
 				return val, state, errors.WithStack(err)
@@ -230,6 +245,17 @@ func (i *Interpreter) ProcessFunctionSubroutine(sub *ast.SubroutineDeclaration,
 		if err != nil {
 			return value.Null, INTERNAL_ERROR, errors.WithStack(err)
 		}
+
+		if state == GOTO {
+			next, err := i.findGotoDestination(sub.Block.Statements, index)
+			if err != nil {
+				return value.Null, INTERNAL_ERROR, errors.WithStack(err)
+			}
+			if next < 0 {
+				return value.Null, INTERNAL_ERROR, errors.WithStack(i.undefinedGotoDestination())
+			}
+			index = next - 1
+		}
 	}
 
 	return value.Null, NONE, exception.Runtime(
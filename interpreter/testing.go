@@ -9,7 +9,9 @@ import (
 	"github.com/pkg/errors"
 	"github.com/ysugimoto/falco/v2/ast"
 	icontext "github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/exception"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/process"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
 )
 
@@ -66,7 +68,33 @@ func (i *Interpreter) TestProcessInit(r *http.Request) error {
 	return nil
 }
 
+// TestFetchBackend sends the current backend request to the backend, or the mock backend if matched,
+// and sets the backend response. This simulates the origin fetch before vcl_fetch in testing
+func (i *Interpreter) TestFetchBackend() error {
+	backend := i.ctx.FetchBackend
+	if backend == nil {
+		backend = i.ctx.Backend
+	}
+	if backend == nil || backend.Value == nil {
+		return exception.System("No backend determined to fetch")
+	}
+	resp, err := i.sendBackendRequest(backend)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	i.ctx.BackendResponse = resp
+	i.setupCacheStrategy()
+	return nil
+}
+
+// Process returns the process of the last request which is served via ServeHTTP, or the running test
+func (i *Interpreter) Process() *process.Process {
+	return i.process
+}
+
 func (i *Interpreter) ProcessTestSubroutine(scope icontext.Scope, sub *ast.SubroutineDeclaration) error {
+	// Record the process for each test
+	i.process = process.New()
 	i.SetScope(scope)
 	if _, err := i.ProcessSubroutine(sub, DebugPass, nil); err != nil {
 		return errors.WithStack(err)
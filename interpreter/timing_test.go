@@ -0,0 +1,102 @@
+package interpreter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/logging"
+	"github.com/ysugimoto/falco/v2/resolver"
+)
+
+func TestLogTimingVariables(t *testing.T) {
+	body := strings.Repeat("a", 3000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body)) // nolint:errcheck
+	}))
+	defer server.Close()
+
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Errorf("Test server URL parsing error: %s", err)
+		return
+	}
+
+	vcl := defaultBackend(parsed) + `
+sub vcl_recv {
+	set req.enable_segmented_caching = true;
+	return (lookup);
+}
+sub vcl_log {
+	log "syslog " req.service_id " timing :: "
+		resp.header_bytes_written " "
+		resp.body_bytes_written " "
+		resp.bytes_written " "
+		req.header_bytes_read " "
+		time.to_first_byte " "
+		time.elapsed.usec " "
+		time.end.msec_frac;
+	log "syslog " req.service_id " segment :: "
+		segmented_caching.is_outer_req " "
+		segmented_caching.total_blocks " "
+		segmented_caching.obj.complete_length " "
+		segmented_caching.client_req.range_low " "
+		segmented_caching.client_req.range_high " "
+		segmented_caching.client_req.is_open_ended;
+}`
+
+	timing := &recordSink{}
+	segment := &recordSink{}
+	ip := New(
+		context.WithResolver(resolver.NewStaticResolver("main", vcl)),
+		context.WithLoggingSinks(logging.Sinks{"timing": timing, "segment": segment}),
+	)
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	req.Header.Set("Range", "bytes=100-")
+	ip.ServeHTTP(httptest.NewRecorder(), req)
+	if ip.process.Error != nil {
+		t.Errorf("Unexpected error: %s", ip.process.Error)
+		return
+	}
+	if len(timing.entries) != 1 || len(segment.entries) != 1 {
+		t.Errorf("vcl_log must be executed once, got timing=%d segment=%d", len(timing.entries), len(segment.entries))
+		return
+	}
+
+	fields := strings.Fields(timing.entries[0].Message)
+	if len(fields) != 7 {
+		t.Errorf("Unexpected log line: %s", timing.entries[0].Message)
+		return
+	}
+	headerBytes, _ := strconv.ParseInt(fields[0], 10, 64)
+	if headerBytes <= int64(len("HTTP/1.1 200 OK\r\n\r\n")) {
+		t.Errorf("resp.header_bytes_written must include status line and headers, got %d", headerBytes)
+	}
+	if fields[1] != "3000" {
+		t.Errorf("resp.body_bytes_written mismatch, expect=3000, got=%s", fields[1])
+	}
+	if fields[2] != strconv.FormatInt(headerBytes+3000, 10) {
+		t.Errorf("resp.bytes_written must be sum of header and body, got %s", fields[2])
+	}
+	if fields[3] == "0" {
+		t.Errorf("req.header_bytes_read must not be zero")
+	}
+	ttfb, _ := strconv.ParseFloat(fields[4], 64)
+	elapsed, _ := strconv.ParseInt(fields[5], 10, 64)
+	// RTIME is stringified with millisecond precision
+	if ttfb < 0 || ttfb*1000000 > float64(elapsed)+1000 {
+		t.Errorf("time.to_first_byte must not exceed time.elapsed, got ttfb=%s elapsed=%s", fields[4], fields[5])
+	}
+	if len(fields[6]) != 3 {
+		t.Errorf("time.end.msec_frac must be padded to 3 digits, got %s", fields[6])
+	}
+
+	if v := segment.entries[0].Message; v != "1 1 3000 100 -1 1" {
+		t.Errorf("Segmented caching variables mismatch, got %s", v)
+	}
+}
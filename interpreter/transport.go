@@ -3,11 +3,9 @@ package interpreter
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
 	"fmt"
 	"io"
+	"net"
 	"time"
 
 	"github.com/gobwas/glob"
@@ -42,15 +40,12 @@ func getOverrideBackend(ctx *icontext.Context, backendName string) (*config.Over
 	return nil, nil
 }
 
-func setupFastlyHeaders(req *http.Request) {
+func setupFastlyHeaders(ctx *icontext.Context, req *http.Request) {
 	// Fastly-FF
 	// https://www.fastly.com/documentation/reference/http/http-headers/Fastly-FF/#format
-	mac := hmac.New(sha256.New, []byte("falco"))
-	mac.Write([]byte(variable.FALCO_VIRTUAL_SERVICE_ID))
-	hash := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	ff := fmt.Sprintf("%s!%s!%s", hash, variable.FALCO_DATACENTER, variable.FALCO_SERVER_HOSTNAME)
-	if req.Header.Get("Fastly-FF") != "" {
-		req.Header.Add("Fastly-FF", ","+ff)
+	ff := variable.FastlyFFEntry(ctx)
+	if v := req.Header.Get("Fastly-FF"); v != "" {
+		req.Header.Set("Fastly-FF", v+","+ff)
 	} else {
 		req.Header.Set("Fastly-FF", ff)
 	}
@@ -58,6 +53,7 @@ func setupFastlyHeaders(req *http.Request) {
 }
 
 func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value.Backend) (*http.Request, error) {
+	ctx.FetchBackend = backend
 	var port string
 	if v, err := i.getBackendProperty(backend.Value.Properties, "port"); err != nil {
 		return nil, errors.WithStack(err)
@@ -97,6 +93,10 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 	var host string
 	if overrideBackend != nil {
 		host = overrideBackend.Host
+		// Port also could be overrided like "localhost:8080"
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
+		}
 	} else {
 		if v, err := i.getBackendProperty(backend.Value.Properties, "host"); err != nil {
 			return nil, errors.WithStack(err)
@@ -118,7 +118,7 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 		}
 	}
 
-	url := fmt.Sprintf("%s://%s:%s%s", scheme, host, port, i.ctx.Request.URL.Path)
+	url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, port), i.ctx.Request.URL.Path)
 	query := i.ctx.Request.URL.Query()
 	if v := query.Encode(); v != "" {
 		url += "?" + v
@@ -129,7 +129,7 @@ func (i *Interpreter) createBackendRequest(ctx *icontext.Context, backend *value
 		return nil, exception.Runtime(nil, "Failed to create backend request: %s", err)
 	}
 	req.Header = i.ctx.Request.Header.Clone()
-	setupFastlyHeaders(req)
+	setupFastlyHeaders(ctx, req)
 
 	hostHeader, err := i.getOriginHostHeader(backend, host)
 	if err != nil {
@@ -207,17 +207,31 @@ func (i *Interpreter) sendBackendRequest(backend *value.Backend) (*http.Response
 		return nil, errors.WithStack(err)
 	}
 
+	mock := findMockBackend(i.ctx, backend.Value.Name.Value, req)
+	if i.ctx.IsOriginRequestRecording {
+		if err := i.recordOriginRequest(backend.Value.Name.Value, req, mock != nil); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
 	// Debug message
 	var suffix string
 	// nolint:errcheck
-	if overrideBackend, _ := getOverrideBackend(i.ctx, backend.Value.Name.Value); overrideBackend != nil {
+	if mock != nil {
+		suffix = " (mocked)"
+	} else if overrideBackend, _ := getOverrideBackend(i.ctx, backend.Value.Name.Value); overrideBackend != nil {
 		suffix = " (overridden by config)"
 	}
 	i.Debugger.Message(
 		fmt.Sprintf("Fetching backend (%s) %s%s", backend.Value.Name.Value, req.URL.String(), suffix),
 	)
 
-	resp, err := http.SendRequest(req)
+	var resp *http.Response
+	if mock != nil {
+		resp, err = sendMockBackendRequest(ctx, mock, req)
+	} else {
+		resp, err = http.SendRequest(req)
+	}
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
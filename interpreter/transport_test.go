@@ -1,11 +1,15 @@
 package interpreter
 
 import (
+	ghttp "net/http"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/config"
 	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/http"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
 )
 
@@ -126,3 +130,53 @@ func TestSendBackendRequestWithInvalidTimeoutType(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateBackendRequestWithOverrideBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		override *config.OverrideBackend
+		expect   string
+	}{
+		{
+			name:     "override host",
+			override: &config.OverrideBackend{Host: "example.org", SSL: true},
+			expect:   "https://example.org:8443/path?foo=bar",
+		},
+		{
+			name:     "override host and port",
+			override: &config.OverrideBackend{Host: "127.0.0.1:8080"},
+			expect:   "http://127.0.0.1:8080/path?foo=bar",
+		},
+		{
+			name:     "override IPv6 host and port",
+			override: &config.OverrideBackend{Host: "[::1]:8080"},
+			expect:   "http://[::1]:8080/path?foo=bar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := New()
+			ip.ctx = context.New(context.WithOverrideBackends(map[string]*config.OverrideBackend{
+				"F_*": tt.override,
+			}))
+			req, err := http.NewRequest(ghttp.MethodGet, "http://localhost/path?foo=bar", ghttp.NoBody)
+			if err != nil {
+				t.Fatalf("Failed to create request: %s", err)
+			}
+			ip.ctx.Request = req
+
+			backend := newBackend("F_origin",
+				backendProperty("host", &ast.String{Value: "example.com"}),
+				backendProperty("port", &ast.String{Value: "8443"}),
+			)
+			bereq, err := ip.createBackendRequest(ip.ctx, backend)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(tt.expect, bereq.URL.String()); diff != "" {
+				t.Errorf("Backend request URL mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
@@ -55,8 +55,9 @@ func calculateRate(entries []rateEntry, window time.Duration) float64 {
 type Ratecounter struct {
 	Decl *ast.RatecounterDeclaration
 
-	// Ratecounter's client count is native map, not a sync.Map.
-	// This means we won't think about race coundition, allows unexpected behavior under the concurrency.
+	// Ratecounter is shared between requests which are processed concurrently in the simulator,
+	// so client count map and last incremented entry are guarded by the mutex.
+	mu      sync.RWMutex
 	Clients map[string][]rateEntry
 
 	// Ratecounter related value like ratecounter.{NAME}.bucket.10s could be accessible after some ratecounter related functions have been called:
@@ -80,6 +81,9 @@ func NewRatecounter(decl *ast.RatecounterDeclaration) *Ratecounter {
 // Increment() increments access entry manually.
 // This function should be called via ratelimit.ratecounter_increment() VCL function
 func (r *Ratecounter) Increment(entry string, delta int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if _, ok := r.Clients[entry]; !ok {
 		r.Clients[entry] = []rateEntry{}
 	}
@@ -93,6 +97,9 @@ func (r *Ratecounter) Increment(entry string, delta int64) {
 // Bucket() returns access count for provided window.
 // This function will be called for specific variables like ratecounter.{NAME}.bucket.10s
 func (r *Ratecounter) Bucket(entry string, window time.Duration) int64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	if r.LastIncremented == nil {
 		return 0
 	}
@@ -106,6 +113,9 @@ func (r *Ratecounter) Bucket(entry string, window time.Duration) int64 {
 // Rate() returns access rate for provided window.
 // This function will be called for specific variables like ratecounter.{NAME}.rate.1s
 func (r *Ratecounter) Rate(entry string, window time.Duration) float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	if r.LastIncremented == nil {
 		return 0
 	}
@@ -116,6 +126,14 @@ func (r *Ratecounter) Rate(entry string, window time.Duration) float64 {
 	return calculateRate(entries, window)
 }
 
+// LastEntry() returns last incremented entry, or nil if the ratecounter has not been incremented yet
+func (r *Ratecounter) LastEntry() *string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.LastIncremented
+}
+
 // Penaltybox implementation
 // holds client IP and expiration, and check client whether burned or not
 type Penaltybox struct {
@@ -300,6 +300,11 @@ func (v *Backend) String() string {
 }
 func (v *Backend) Type() Type      { return BackendType }
 func (v *Backend) IsLiteral() bool { return v.Literal }
+
+// IsShieldDirector returns true if the backend is a shield director, requests are sent to the shield node
+func (v *Backend) IsShieldDirector() bool {
+	return v != nil && v.Director != nil && v.Director.Type == DIRECTORTYPE_SHIELD
+}
 func (v *Backend) Copy() Value {
 	return &Backend{Value: v.Value, Director: v.Director, Literal: v.Literal}
 }
@@ -24,6 +24,7 @@ import (
 	"github.com/ysugimoto/falco/v2/interpreter/exception"
 	"github.com/ysugimoto/falco/v2/interpreter/limitations"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
+	"github.com/ysugimoto/falco/v2/limits"
 )
 
 // Enables to access variables for all scopes
@@ -83,19 +84,46 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		CLIENT_CLASS_MASQUERADING,
 		CLIENT_CLASS_SPAM,
 		CLIENT_PLATFORM_MEDIAPLAYER,
-		REQ_BACKEND_IS_SHIELD,
-		REQ_IS_BACKGROUND_FETCH,
 		REQ_IS_CLUSTERING,
-		REQ_IS_ESI_SUBREQ,
-		RESP_STALE,
-		RESP_STALE_IS_ERROR,
-		RESP_STALE_IS_REVALIDATING,
 		WORKSPACE_OVERFLOWED:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
 		return &value.Boolean{Value: false}, nil
 
+	case REQ_BACKEND_IS_SHIELD:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return &value.Boolean{Value: v.ctx.Backend.IsShieldDirector()}, nil
+
+	case REQ_IS_ESI_SUBREQ:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return &value.Boolean{Value: v.ctx.ESILevel.Value > 0}, nil
+
+	case REQ_IS_BACKGROUND_FETCH:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return &value.Boolean{Value: v.ctx.IsBackgroundFetch}, nil
+	case RESP_STALE:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.Stale, nil
+	case RESP_STALE_IS_ERROR:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.StaleIsError, nil
+	case RESP_STALE_IS_REVALIDATING:
+		if v := lookupOverride(v.ctx, name); v != nil {
+			return v, nil
+		}
+		return v.ctx.StaleIsRevalidating, nil
+
 	case CLIENT_DISPLAY_TOUCHSCREEN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
@@ -345,14 +373,16 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 	case FASTLY_FF_VISITS_THIS_POP:
 		return &value.Integer{Value: 1}, nil
 
-	// Returns common value -- do not consider of clustering
+	// Returns common value -- do not consider of clustering.
+	// Visits on the previous nodes (e.g edge node in shielding mode) are counted from Fastly-FF header.
 	// see: https://developer.fastly.com/reference/vcl/variables/miscellaneous/fastly-ff-visits-this-service/
 	case FASTLY_FF_VISITS_THIS_SERVICE:
+		visits := int64(v.ctx.FastlyFFVisits)
 		switch s {
 		case context.MissScope, context.HitScope, context.FetchScope:
-			return &value.Integer{Value: 1}, nil
+			return &value.Integer{Value: visits + 1}, nil
 		default:
-			return &value.Integer{Value: 0}, nil
+			return &value.Integer{Value: visits}, nil
 		}
 
 	// Returns tentative value -- you may know your customer_id in the contraction :-)
@@ -385,13 +415,7 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		return &value.Integer{Value: -9223372036854775808}, nil
 
 	case REQ_HEADER_BYTES_READ:
-		var headerBytes int64
-		// FIXME: Do we need to include total byte header LF bytes?
-		for k, v := range req.Header {
-			// add ":" character that header separator character
-			headerBytes += int64(len(k) + 1 + len(strings.Join(v, ";")))
-		}
-		return &value.Integer{Value: headerBytes}, nil
+		return &value.Integer{Value: requestHeaderBytes(req.Request)}, nil
 	case REQ_RESTARTS:
 		return &value.Integer{Value: int64(v.ctx.Restarts)}, nil
 
@@ -422,13 +446,13 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		free := max(int64(limitations.MaxRequestWorkspaceSize-v.ctx.RequestWorkspaceBytes), 0)
+		free := max(int64(limits.MaxRequestWorkspaceSize-v.ctx.RequestWorkspaceBytes), 0)
 		return &value.Integer{Value: free}, nil
 	case WORKSPACE_BYTES_TOTAL:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: int64(limitations.MaxRequestWorkspaceSize)}, nil
+		return &value.Integer{Value: int64(limits.MaxRequestWorkspaceSize)}, nil
 
 	// backend.src_ip always indicates this server, means localhost
 	case BERESP_BACKEND_SRC_IP:
@@ -667,7 +691,11 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 			id = FALCO_VIRTUAL_SERVICE_ID
 		}
 		return &value.String{Value: id}, nil
-	case REQ_TOPURL: // FIXME: what is the difference of req.url ?
+	case REQ_TOPURL:
+		// ESI subrequest has the URL of the top level request
+		if v.ctx.TopURL != "" {
+			return &value.String{Value: v.ctx.TopURL}, nil
+		}
 		u := req.URL.EscapedPath()
 		if v := req.URL.RawQuery; v != "" {
 			u += "?" + v
@@ -729,17 +757,17 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.String{Value: FALCO_DATACENTER}, nil
+		return &value.String{Value: ServerDatacenter(v.ctx)}, nil
 	case SERVER_HOSTNAME:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.String{Value: FALCO_SERVER_HOSTNAME}, nil
+		return &value.String{Value: ServerHostname(v.ctx)}, nil
 	case SERVER_IDENTITY:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.String{Value: FALCO_SERVER_HOSTNAME}, nil
+		return &value.String{Value: ServerHostname(v.ctx)}, nil
 	case SERVER_REGION:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
@@ -748,45 +776,25 @@ func (v *AllScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 	case STALE_EXISTS:
 		return v.ctx.StaleContents, nil
 	case TIME_ELAPSED_MSEC:
-		return &value.String{
-			Value: fmt.Sprint(time.Since(v.ctx.RequestStartTime).Milliseconds()),
-		}, nil
+		return formatDurationVariable(time.Since(v.ctx.RequestStartTime), "msec"), nil
 	case TIME_ELAPSED_MSEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprintf("%03d", time.Since(v.ctx.RequestStartTime).Milliseconds()),
-		}, nil
+		return formatDurationVariable(time.Since(v.ctx.RequestStartTime), "msec_frac"), nil
 	case TIME_ELAPSED_SEC:
-		return &value.String{
-			Value: fmt.Sprint(int64(time.Since(v.ctx.RequestStartTime).Seconds())),
-		}, nil
+		return formatDurationVariable(time.Since(v.ctx.RequestStartTime), "sec"), nil
 	case TIME_ELAPSED_USEC:
-		return &value.String{
-			Value: fmt.Sprint(time.Since(v.ctx.RequestStartTime).Microseconds()),
-		}, nil
+		return formatDurationVariable(time.Since(v.ctx.RequestStartTime), "usec"), nil
 	case TIME_ELAPSED_USEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprintf("%06d", time.Since(v.ctx.RequestStartTime).Microseconds()),
-		}, nil
+		return formatDurationVariable(time.Since(v.ctx.RequestStartTime), "usec_frac"), nil
 	case TIME_START_MSEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestStartTime.UnixMilli()),
-		}, nil
+		return formatTimeVariable(v.ctx.RequestStartTime, "msec"), nil
 	case TIME_START_MSEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestStartTime.UnixMilli() % 1000),
-		}, nil
+		return formatTimeVariable(v.ctx.RequestStartTime, "msec_frac"), nil
 	case TIME_START_SEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestStartTime.Unix()),
-		}, nil
+		return formatTimeVariable(v.ctx.RequestStartTime, "sec"), nil
 	case TIME_START_USEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestStartTime.UnixMicro()),
-		}, nil
+		return formatTimeVariable(v.ctx.RequestStartTime, "usec"), nil
 	case TIME_START_USEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestStartTime.UnixMicro() % 1000000),
-		}, nil
+		return formatTimeVariable(v.ctx.RequestStartTime, "usec_frac"), nil
 	case NOW:
 		// For testing - if fixed time is injected, return it
 		if v.ctx.FixedTime != nil {
@@ -853,7 +861,7 @@ func (v *AllScopeVariables) getFromRegex(name string) (value.Value, error) {
 		if !ok {
 			return nil, exception.Runtime(nil, "ratecounter '%s' is not defined", name)
 		}
-		entry := rc.LastIncremented
+		entry := rc.LastEntry()
 		switch method {
 		case "bucket":
 			return getRateCounterBucketValue(v.ctx, rc, entry, window)
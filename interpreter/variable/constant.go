@@ -11,6 +11,10 @@ const (
 	FALCO_VIRTUAL_SERVICE_ID = "falco-virtual-service-id"
 	FALCO_SERVER_HOSTNAME    = "cache-localsimulator"
 	FALCO_DATACENTER         = "FALCO"
+
+	// Virtual server values for the shield node in shielding mode
+	FALCO_SHIELD_SERVER_HOSTNAME = "cache-shieldsimulator"
+	FALCO_SHIELD_DATACENTER      = "SHIELD"
 )
 
 // Mapping from tls package ciphersuite name (IANA) to OpenSSL name
@@ -188,34 +188,20 @@ func (v *DeliverScopeVariables) Get(s context.Scope, name string) (value.Value,
 	case RESP_STATUS:
 		return &value.Integer{Value: int64(v.ctx.Response.StatusCode)}, nil
 	case TIME_TO_FIRST_BYTE:
-		// TODO: this logic is only calculate response - request time.
-		// It means that is not correct RTIME value because TTFB is the first byte from response.
-		return &value.RTime{
-			Value: time.Since(v.ctx.RequestEndTime),
-		}, nil
+		return &value.RTime{Value: timeToFirstByte(v.ctx)}, nil
 
 	case TIME_END:
-		return value.NewTime(v.ctx.RequestEndTime), nil
+		return value.NewTime(requestEndTime(v.ctx)), nil
 	case TIME_END_MSEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMilli()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "msec"), nil
 	case TIME_END_MSEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprintf("%03d", v.ctx.RequestEndTime.UnixMilli()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "msec_frac"), nil
 	case TIME_END_SEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.Unix()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "sec"), nil
 	case TIME_END_USEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMicro()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "usec"), nil
 	case TIME_END_USEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMicro()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "usec_frac"), nil
 
 	// Digest ratio will return fixed value if not override
 	case REQ_DIGEST_RATIO:
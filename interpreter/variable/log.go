@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"strconv"
 	"strings"
 	"time"
 
@@ -38,27 +37,20 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 
 	switch name {
 	case BEREQ_BODY_BYTES_WRITTEN:
-		var buf bytes.Buffer
-		if _, err := buf.ReadFrom(bereq.Body); err != nil {
-			return value.Null, errors.WithStack(err)
+		if bereq == nil {
+			return &value.Integer{Value: 0}, nil
 		}
-		body := buf.Bytes()
-		bereq.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
-		return &value.Integer{Value: int64(len(body))}, nil
-
+		return &value.Integer{Value: bodyBytes(&bereq.Body)}, nil
 	case BEREQ_BYTES_WRITTEN:
-		// TODO: we need to implement backend communication without net/http package
-		// because we have to know more raw socket informations
-		return &value.Integer{Value: 0}, nil
-
+		if bereq == nil {
+			return &value.Integer{Value: 0}, nil
+		}
+		return &value.Integer{Value: requestHeaderBytes(bereq.Request) + bodyBytes(&bereq.Body)}, nil
 	case BEREQ_HEADER_BYTES_WRITTEN:
-		var headerBytes int64
-		// FIXME: Do we need to include total byte header LF bytes?
-		for k, v := range bereq.Header {
-			// add ":" character that header separator character
-			headerBytes += int64(len(k) + 1 + len(strings.Join(v, ";")))
+		if bereq == nil {
+			return &value.Integer{Value: 0}, nil
 		}
-		return &value.Integer{Value: headerBytes}, nil
+		return &value.Integer{Value: requestHeaderBytes(bereq.Request)}, nil
 
 	case CLIENT_SOCKET_CONGESTION_ALGORITHM:
 		return v.ctx.ClientSocketCongestionAlgorithm, nil
@@ -120,8 +112,7 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		// alias for obj.grace
 		return v.ctx.ObjectGrace, nil
 	case OBJ_STALE_WHILE_REVALIDATE:
-		// Return fixed value because we don't support SWR yet
-		return &value.RTime{Value: 60 * time.Second}, nil
+		return v.ctx.ObjectStaleWhileRevalidate, nil
 	case OBJ_TTL:
 		return v.ctx.ObjectTTL, nil
 
@@ -157,28 +148,9 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 	case BERESP_BACKEND_HOST:
 		return getBackendHost(v.ctx.Backend)
 	case REQ_BODY_BYTES_READ:
-		var buf bytes.Buffer
-		n, err := buf.ReadFrom(req.Body)
-		if err != nil {
-			return value.Null, errors.WithStack(err)
-		}
-		req.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
-		return &value.Integer{Value: n}, nil
+		return &value.Integer{Value: bodyBytes(&req.Body)}, nil
 	case REQ_BYTES_READ:
-		var readBytes int64
-		// FIXME: Do we need to include total byte header LF bytes?
-		for k, v := range req.Header {
-			// add ":" character that header separator character
-			readBytes += int64(len(k) + 1 + len(strings.Join(v, ";")))
-		}
-		var buf bytes.Buffer
-		n, err := buf.ReadFrom(req.Body)
-		if err != nil {
-			return value.Null, errors.WithStack(err)
-		}
-		req.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
-		readBytes += n
-		return &value.Integer{Value: readBytes}, nil
+		return &value.Integer{Value: requestHeaderBytes(req.Request) + bodyBytes(&req.Body)}, nil
 	// Digest ratio will return fixed value if not override
 	case REQ_DIGEST_RATIO:
 		if v := lookupOverride(v.ctx, name); v != nil {
@@ -186,24 +158,26 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		}
 		return &value.Float{Value: 0.4}, nil
 
-	// FIXME: We need to send actual request to the backend
+	// Bytes which are written to the client, calculated from the delivered response
 	case RESP_BODY_BYTES_WRITTEN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: 0}, nil
+		return &value.Integer{Value: bodyBytes(&v.ctx.Response.Body)}, nil
 	case RESP_BYTES_WRITTEN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: 0}, nil
+		return &value.Integer{
+			Value: responseHeaderBytes(v.ctx.Response.Response) + bodyBytes(&v.ctx.Response.Body),
+		}, nil
 	case RESP_COMPLETED:
 		return &value.Boolean{Value: true}, nil
 	case RESP_HEADER_BYTES_WRITTEN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: 0}, nil
+		return &value.Integer{Value: responseHeaderBytes(v.ctx.Response.Response)}, nil
 	case RESP_IS_LOCALLY_GENERATED:
 		return v.ctx.IsLocallyGenerated, nil
 	case RESP_PROTO:
@@ -219,125 +193,103 @@ func (v *LogScopeVariables) Get(s context.Scope, name string) (value.Value, erro
 		return &value.Integer{Value: int64(v.ctx.Response.StatusCode)}, nil
 
 	case TIME_END:
-		return value.NewTime(v.ctx.RequestEndTime), nil
+		return value.NewTime(requestEndTime(v.ctx)), nil
 	case TIME_END_MSEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMilli()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "msec"), nil
 	case TIME_END_MSEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprintf("%03d", v.ctx.RequestEndTime.UnixMilli()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "msec_frac"), nil
 	case TIME_END_SEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.Unix()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "sec"), nil
 	case TIME_END_USEC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMicro()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "usec"), nil
 	case TIME_END_USEC_FRAC:
-		return &value.String{
-			Value: fmt.Sprint(v.ctx.RequestEndTime.UnixMicro()),
-		}, nil
+		return formatTimeVariable(requestEndTime(v.ctx), "usec_frac"), nil
 	case TIME_TO_FIRST_BYTE:
-		// TODO: this logic is only calculate response - request time.
-		// It means that is not correct RTIME value because TFB is the first byte from response.
-		return &value.RTime{
-			Value: time.Since(v.ctx.RequestEndTime),
-		}, nil
+		return &value.RTime{Value: timeToFirstByte(v.ctx)}, nil
 
-	// FIXME: segmented_caching related variables is just fake value
-	case SEGMENTED_CACHING_AUTOPURGED:
+	// Segmented caching variables are calculated from the delivered object
+	// but the simulator never runs inner requests for each block
+	case SEGMENTED_CACHING_AUTOPURGED,
+		SEGMENTED_CACHING_CANCELLED, // nolint: misspell
+		SEGMENTED_CACHING_FAILED,
+		SEGMENTED_CACHING_IS_INNER_REQ:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
 		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_BLOCK_NUMBER:
+	case SEGMENTED_CACHING_ERROR:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: 1}, nil
-	case SEGMENTED_CACHING_BLOCK_SIZE:
+		return &value.String{Value: ""}, nil
+	case SEGMENTED_CACHING_BLOCK_NUMBER:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Integer{Value: 1}, nil
-	case SEGMENTED_CACHING_CANCELLED: // nolint: misspell
+		// Block number is only available in inner requests
+		return &value.Integer{Value: -1}, nil
+	case SEGMENTED_CACHING_IS_OUTER_REQ,
+		SEGMENTED_CACHING_COMPLETED:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_CLIENT_REQ_IS_OPEN_ENDED:
+		return &value.Boolean{Value: newSegmentedCaching(v.ctx) != nil}, nil
+	case SEGMENTED_CACHING_BLOCK_SIZE:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_CLIENT_REQ_IS_RANGE:
-		return &value.Boolean{Value: req.Header.Get("Range") != ""}, nil
-	case SEGMENTED_CACHING_CLIENT_REQ_RANGE_HIGH:
-		spl := strings.SplitN(req.Header.Get("Range"), "-", 2)
-		if len(spl) != 2 {
-			return &value.Integer{Value: 0}, nil
-		}
-		high, err := strconv.ParseInt(spl[1], 10, 64)
-		if err != nil {
-			return value.Null, errors.WithStack(err)
+		if sc := newSegmentedCaching(v.ctx); sc != nil {
+			return &value.Integer{Value: sc.blockSize}, nil
 		}
-		return &value.Integer{Value: high}, nil
-	case SEGMENTED_CACHING_CLIENT_REQ_RANGE_LOW:
-		spl := strings.SplitN(req.Header.Get("Range"), "-", 2)
-		if len(spl) != 2 {
-			return &value.Integer{Value: 0}, nil
-		}
-		low, err := strconv.ParseInt(spl[0], 10, 64)
-		if err != nil {
-			return value.Null, errors.WithStack(err)
-		}
-		return &value.Integer{Value: low}, nil
-	case SEGMENTED_CACHING_COMPLETED:
+		return &value.Integer{Value: 0}, nil
+	case SEGMENTED_CACHING_TOTAL_BLOCKS:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_ERROR:
-		if v := lookupOverride(v.ctx, name); v != nil {
-			return v, nil
+		if sc := newSegmentedCaching(v.ctx); sc != nil {
+			return &value.Integer{Value: sc.totalBlocks}, nil
 		}
-		return &value.String{Value: ""}, nil
-	case SEGMENTED_CACHING_FAILED:
+		return &value.Integer{Value: 0}, nil
+	case SEGMENTED_CACHING_OBJ_COMPLETE_LENGTH:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_IS_INNER_REQ:
-		if v := lookupOverride(v.ctx, name); v != nil {
-			return v, nil
+		if sc := newSegmentedCaching(v.ctx); sc != nil {
+			return &value.Integer{Value: sc.completeLength}, nil
 		}
-		return &value.Boolean{Value: false}, nil
-	case SEGMENTED_CACHING_IS_OUTER_REQ:
+		return &value.Integer{Value: 0}, nil
+	case SEGMENTED_CACHING_ROUNDED_REQ_RANGE_LOW:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: true}, nil
-	case SEGMENTED_CACHING_OBJ_COMPLETE_LENGTH:
-		if v := lookupOverride(v.ctx, name); v != nil {
-			return v, nil
+		if sc := newSegmentedCaching(v.ctx); sc != nil {
+			return &value.Integer{Value: sc.roundedLow}, nil
 		}
 		return &value.Integer{Value: 0}, nil
 	case SEGMENTED_CACHING_ROUNDED_REQ_RANGE_HIGH:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		if sc := newSegmentedCaching(v.ctx); sc != nil {
+			return &value.Integer{Value: sc.roundedHigh}, nil
+		}
 		return &value.Integer{Value: 0}, nil
-	case SEGMENTED_CACHING_ROUNDED_REQ_RANGE_LOW:
+	case SEGMENTED_CACHING_CLIENT_REQ_IS_RANGE:
+		return &value.Boolean{Value: parseClientRange(req.Request).isRange}, nil
+	case SEGMENTED_CACHING_CLIENT_REQ_IS_OPEN_ENDED:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
+		r := parseClientRange(req.Request)
+		return &value.Boolean{Value: r.isRange && r.low >= 0 && r.high < 0}, nil
+	case SEGMENTED_CACHING_CLIENT_REQ_RANGE_HIGH:
+		if r := parseClientRange(req.Request); r.isRange {
+			return &value.Integer{Value: r.high}, nil
+		}
 		return &value.Integer{Value: 0}, nil
-	case SEGMENTED_CACHING_TOTAL_BLOCKS:
-		if v := lookupOverride(v.ctx, name); v != nil {
-			return v, nil
+	case SEGMENTED_CACHING_CLIENT_REQ_RANGE_LOW:
+		if r := parseClientRange(req.Request); r.isRange {
+			return &value.Integer{Value: r.low}, nil
 		}
 		return &value.Integer{Value: 0}, nil
 	case FASTLY_INFO_REQUEST_ID:
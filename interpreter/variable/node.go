@@ -0,0 +1,53 @@
+package variable
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+)
+
+// ServerHostname returns virtual hostname of the node which processes the request.
+// The shield node has a different hostname from the edge node in shielding mode.
+func ServerHostname(ctx *context.Context) string {
+	if ctx.IsShieldNode {
+		return FALCO_SHIELD_SERVER_HOSTNAME
+	}
+	return FALCO_SERVER_HOSTNAME
+}
+
+// ServerDatacenter returns virtual datacenter of the node which processes the request
+func ServerDatacenter(ctx *context.Context) string {
+	if ctx.IsShieldNode {
+		return FALCO_SHIELD_DATACENTER
+	}
+	return FALCO_DATACENTER
+}
+
+// Fastly-FF entry identifies the service by the hash of service id
+func fastlyFFServiceHash() string {
+	mac := hmac.New(sha256.New, []byte("falco"))
+	mac.Write([]byte(FALCO_VIRTUAL_SERVICE_ID))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// FastlyFFEntry returns Fastly-FF header entry which the node adds to the backend request
+// https://www.fastly.com/documentation/reference/http/http-headers/Fastly-FF/#format
+func FastlyFFEntry(ctx *context.Context) string {
+	return fmt.Sprintf("%s!%s!%s", fastlyFFServiceHash(), ServerDatacenter(ctx), ServerHostname(ctx))
+}
+
+// CountServiceVisits returns the number of Fastly-FF entries which have been added by this service
+func CountServiceVisits(ff string) int {
+	hash := fastlyFFServiceHash()
+	var visits int
+	for _, entry := range strings.Split(ff, ",") {
+		if h, _, found := strings.Cut(strings.TrimSpace(entry), "!"); found && h == hash {
+			visits++
+		}
+	}
+	return visits
+}
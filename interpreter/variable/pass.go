@@ -72,12 +72,11 @@ func (v *PassScopeVariables) Get(s context.Scope, name string) (value.Value, err
 	case BEREQ_MAX_REUSE_IDLE_TIME:
 		return v.ctx.BackendRequestMaxReuseIdleTime, nil
 
-	// We simulate request is always pass to the origin, not consider shielding
 	case REQ_BACKEND_IS_ORIGIN:
 		if v := lookupOverride(v.ctx, name); v != nil {
 			return v, nil
 		}
-		return &value.Boolean{Value: true}, nil
+		return &value.Boolean{Value: !v.ctx.Backend.IsShieldDirector()}, nil
 	// Digest ratio will return fixed value
 	case REQ_DIGEST_RATIO:
 		if v := lookupOverride(v.ctx, name); v != nil {
@@ -0,0 +1,201 @@
+package variable
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+// Default block size of segmented caching, 1MB
+const defaultSegmentedCachingBlockSize = 1024 * 1024
+
+// Time variables have the formatted values which are calculated from the same time.
+// For example, time.start.msec_frac is the milliseconds fraction of time.start.
+func formatTimeVariable(t time.Time, format string) *value.String {
+	switch format {
+	case "msec":
+		return &value.String{Value: fmt.Sprint(t.UnixMilli())}
+	case "msec_frac":
+		return &value.String{Value: fmt.Sprintf("%03d", t.UnixMilli()%1000)}
+	case "sec":
+		return &value.String{Value: fmt.Sprint(t.Unix())}
+	case "usec":
+		return &value.String{Value: fmt.Sprint(t.UnixMicro())}
+	default: // usec_frac
+		return &value.String{Value: fmt.Sprintf("%06d", t.UnixMicro()%1000000)}
+	}
+}
+
+// Same as formatTimeVariable but for the duration like time.elapsed
+func formatDurationVariable(d time.Duration, format string) *value.String {
+	switch format {
+	case "msec":
+		return &value.String{Value: fmt.Sprint(d.Milliseconds())}
+	case "msec_frac":
+		return &value.String{Value: fmt.Sprintf("%03d", d.Milliseconds()%1000)}
+	case "sec":
+		return &value.String{Value: fmt.Sprint(int64(d.Seconds()))}
+	case "usec":
+		return &value.String{Value: fmt.Sprint(d.Microseconds())}
+	default: // usec_frac
+		return &value.String{Value: fmt.Sprintf("%06d", d.Microseconds()%1000000)}
+	}
+}
+
+// Request end time is set before vcl_log, it is the current time until then
+func requestEndTime(ctx *context.Context) time.Time {
+	if ctx.RequestEndTime.IsZero() {
+		return time.Now()
+	}
+	return ctx.RequestEndTime
+}
+
+// Time to first byte is measured when the response starts to be delivered after vcl_deliver,
+// it is the elapsed time until then
+func timeToFirstByte(ctx *context.Context) time.Duration {
+	if ctx.FirstByteTime.IsZero() {
+		return time.Since(ctx.RequestStartTime)
+	}
+	return ctx.FirstByteTime.Sub(ctx.RequestStartTime)
+}
+
+// Size of request line and headers on the wire, including CRLF
+func requestHeaderBytes(req *http.Request) int64 {
+	if req == nil {
+		return 0
+	}
+	proto := req.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	line := fmt.Sprintf("%s %s %s\r\n", req.Method, req.URL.RequestURI(), proto)
+	return int64(len(line)) + headerBytes(req.Header) + 2
+}
+
+// Size of status line and headers on the wire, including CRLF
+func responseHeaderBytes(resp *http.Response) int64 {
+	if resp == nil {
+		return 0
+	}
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := resp.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+	line := fmt.Sprintf("%s %s\r\n", proto, status)
+	return int64(len(line)) + headerBytes(resp.Header) + 2
+}
+
+func headerBytes(h http.Header) int64 {
+	var buf bytes.Buffer
+	h.Write(&buf) // nolint:errcheck
+	return int64(buf.Len())
+}
+
+// Read body size and rewind the body reader
+func bodyBytes(body *io.ReadCloser) int64 {
+	if *body == nil {
+		return 0
+	}
+	var buf bytes.Buffer
+	n, _ := buf.ReadFrom(*body) // nolint:errcheck
+	*body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+	return n
+}
+
+// Client range request, high is -1 for the open ended range like "bytes=100-",
+// and low is -1 for the suffix range like "bytes=-100" which high is the suffix length
+type clientRange struct {
+	isRange bool
+	low     int64
+	high    int64
+}
+
+func parseClientRange(req *http.Request) clientRange {
+	spec, found := strings.CutPrefix(req.Header.Get("Range"), "bytes=")
+	if !found {
+		return clientRange{}
+	}
+	// Multiple ranges are not supported in segmented caching, use the first one
+	spec, _, _ = strings.Cut(spec, ",")
+	lo, hi, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found || (lo == "" && hi == "") {
+		return clientRange{}
+	}
+	r := clientRange{isRange: true, low: -1, high: -1}
+	if lo != "" {
+		v, err := strconv.ParseInt(lo, 10, 64)
+		if err != nil {
+			return clientRange{}
+		}
+		r.low = v
+	}
+	if hi != "" {
+		v, err := strconv.ParseInt(hi, 10, 64)
+		if err != nil {
+			return clientRange{}
+		}
+		r.high = v
+	}
+	return r
+}
+
+// Segmented caching state which is calculated from the delivered object
+type segmentedCaching struct {
+	blockSize      int64
+	totalBlocks    int64
+	completeLength int64
+	roundedLow     int64
+	roundedHigh    int64
+}
+
+func newSegmentedCaching(ctx *context.Context) *segmentedCaching {
+	if !ctx.EnableSegmentedCaching.Value || ctx.Response == nil {
+		return nil
+	}
+	s := &segmentedCaching{blockSize: ctx.SegmentedCacheingBlockSize.Value}
+	if s.blockSize <= 0 {
+		s.blockSize = defaultSegmentedCachingBlockSize
+	}
+
+	// Complete length is the total of Content-Range for partial content
+	s.completeLength = bodyBytes(&ctx.Response.Body)
+	if ctx.Response.StatusCode == http.StatusPartialContent {
+		if _, total, found := strings.Cut(ctx.Response.Header.Get("Content-Range"), "/"); found {
+			if v, err := strconv.ParseInt(total, 10, 64); err == nil {
+				s.completeLength = v
+			}
+		}
+	}
+	if s.completeLength == 0 {
+		return s
+	}
+	s.totalBlocks = (s.completeLength + s.blockSize - 1) / s.blockSize
+
+	// Requested range is rounded to the block boundaries
+	low, high := int64(0), s.completeLength-1
+	if r := parseClientRange(ctx.Request.Request); r.isRange {
+		switch {
+		case r.low < 0:
+			low = max(s.completeLength-r.high, 0)
+		case r.high >= 0:
+			low, high = r.low, min(r.high, high)
+		default:
+			low = r.low
+		}
+	}
+	low = min(low, high)
+	s.roundedLow = low / s.blockSize * s.blockSize
+	s.roundedHigh = min((high/s.blockSize+1)*s.blockSize-1, s.completeLength-1)
+	return s
+}
@@ -0,0 +1,124 @@
+package variable
+
+import (
+	"io"
+	ghttp "net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/http"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+func TestFormatTimeVariable(t *testing.T) {
+	tm := time.Unix(1700000000, 5006000)
+	tests := map[string]string{
+		"msec":      "1700000000005",
+		"msec_frac": "005",
+		"sec":       "1700000000",
+		"usec":      "1700000000005006",
+		"usec_frac": "005006",
+	}
+	for format, expect := range tests {
+		if v := formatTimeVariable(tm, format).Value; v != expect {
+			t.Errorf("%s mismatch, expect=%s, got=%s", format, expect, v)
+		}
+	}
+}
+
+func TestParseClientRange(t *testing.T) {
+	tests := []struct {
+		header string
+		expect clientRange
+	}{
+		{header: "", expect: clientRange{}},
+		{header: "bytes=100-199", expect: clientRange{isRange: true, low: 100, high: 199}},
+		{header: "bytes=100-", expect: clientRange{isRange: true, low: 100, high: -1}},
+		{header: "bytes=-500", expect: clientRange{isRange: true, low: -1, high: 500}},
+		{header: "bytes=0-10, 20-30", expect: clientRange{isRange: true, low: 0, high: 10}},
+		{header: "bytes=-", expect: clientRange{}},
+		{header: "bytes=a-b", expect: clientRange{}},
+		{header: "items=0-10", expect: clientRange{}},
+	}
+	for _, tt := range tests {
+		req, _ := ghttp.NewRequest(ghttp.MethodGet, "http://localhost/", nil)
+		req.Header.Set("Range", tt.header)
+		if diff := cmp.Diff(tt.expect, parseClientRange(req), cmp.AllowUnexported(clientRange{})); diff != "" {
+			t.Errorf("parseClientRange mismatch for %q, diff=%s", tt.header, diff)
+		}
+	}
+}
+
+func TestSegmentedCaching(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		status  int
+		header  ghttp.Header
+		rng     string
+		expect  *segmentedCaching
+	}{
+		{name: "disabled", status: 200},
+		{
+			name:    "full object",
+			enabled: true,
+			status:  200,
+			expect:  &segmentedCaching{blockSize: 100, totalBlocks: 3, completeLength: 250, roundedLow: 0, roundedHigh: 249},
+		},
+		{
+			name:    "range is rounded to blocks",
+			enabled: true,
+			status:  200,
+			rng:     "bytes=120-130",
+			expect:  &segmentedCaching{blockSize: 100, totalBlocks: 3, completeLength: 250, roundedLow: 100, roundedHigh: 199},
+		},
+		{
+			name:    "suffix range",
+			enabled: true,
+			status:  200,
+			rng:     "bytes=-30",
+			expect:  &segmentedCaching{blockSize: 100, totalBlocks: 3, completeLength: 250, roundedLow: 200, roundedHigh: 249},
+		},
+		{
+			name:    "complete length from Content-Range",
+			enabled: true,
+			status:  206,
+			header:  ghttp.Header{"Content-Range": {"bytes 0-249/1000"}},
+			rng:     "bytes=0-249",
+			expect:  &segmentedCaching{blockSize: 100, totalBlocks: 10, completeLength: 1000, roundedLow: 0, roundedHigh: 299},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := ghttp.NewRequest(ghttp.MethodGet, "http://localhost/", nil)
+			if tt.rng != "" {
+				req.Header.Set("Range", tt.rng)
+			}
+			header := tt.header
+			if header == nil {
+				header = ghttp.Header{}
+			}
+			ctx := &context.Context{
+				Request: http.WrapRequest(req),
+				Response: http.WrapResponse(&ghttp.Response{
+					StatusCode: tt.status,
+					Header:     header,
+					Body:       io.NopCloser(strings.NewReader(strings.Repeat("a", 250))),
+				}),
+				EnableSegmentedCaching:     &value.Boolean{Value: tt.enabled},
+				SegmentedCacheingBlockSize: &value.Integer{Value: 100},
+			}
+			if diff := cmp.Diff(tt.expect, newSegmentedCaching(ctx), cmp.AllowUnexported(segmentedCaching{})); diff != "" {
+				t.Errorf("newSegmentedCaching mismatch, diff=%s", diff)
+			}
+			// Body must be readable after calculation
+			if n := bodyBytes(&ctx.Response.Body); n != 250 {
+				t.Errorf("Body must be rewound, got %d bytes", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+// Package limits defines Fastly resource limits which are shared by the linter and the interpreter.
+package limits
+
+// Units
+const (
+	KB = 1024
+	MB = 1024 * 1024
+)
+
+// Consolidate Fastly's limitation checks
+// See https://docs.fastly.com/en/guides/resource-limits#request-and-response-limits
+const (
+	// Request and Response limitations
+	MaxURLSize                = 8 * KB
+	MaxCookieSize             = 32 * KB
+	MaxRequestHeaderSize      = 69 * KB
+	MaxResponseHeaderSize     = 69 * KB
+	MaxRequestHeaderCount     = 96
+	MaxResponseHeaderCount    = 96
+	MaxRequestBodyPayloadSize = 8 * KB
+
+	// Surrogate key limitations but actually don't check these
+	MaxSurrogateKeySize       = 1 * KB
+	MaxSurrogateKeyHeaderSize = 1 * KB
+
+	// VCL limitations
+	MaxCustomVCLFileSize = 1 * MB
+	MaxVarnishRestarts   = 3
+	MaxLogLineSize       = 16 * KB
+
+	// Synthetic response body is limited, the linter checks it statically
+	MaxSyntheticResponseSize = 64 * KB
+
+	// ESI limitations, includes are counted through the whole nested subrequests
+	MaxESIDepth    = 5
+	MaxESIIncludes = 256
+
+	// MaxSubroutineCallTree is the ceiling Fastly enforces on the fully inlined
+	// subroutine call graph. The cost of a subroutine is the sum, over each of
+	// its `call` statements, of one plus the callee's own cost, so nested calls
+	// multiply. Past this, Fastly rejects activation with "Too many sub calls".
+	MaxSubroutineCallTree = 25000
+
+	// MaxRequestWorkspaceSize is the size of the per-request workspace. Request
+	// headers are assembled into this workspace and the previous copy is never
+	// reclaimed, even across restarts, so a VCL that rewrites a header many
+	// times eventually overflows it and Fastly returns "503 Header overflow".
+	MaxRequestWorkspaceSize = 256 * KB
+
+	// BaseRequestWorkspaceOverhead approximates what Fastly has already consumed
+	// before any user VCL runs (internal structures and injected headers). A
+	// production service shows ~8.5KB, varying by POP and connection, so we charge
+	// a conservative 10KB on top of the inbound headers we can see.
+	BaseRequestWorkspaceOverhead = 10 * KB
+
+	// Increasable limitations by contacting Fastly support
+	// These are defaults, you can override by configuration
+	MaxACLCounts     = 1000
+	MaxBackendCounts = 5
+)
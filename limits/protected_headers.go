@@ -0,0 +1,28 @@
+package limits
+
+import (
+	"strings"
+)
+
+// Fastly proctects some headers.
+// The proctcted headers cannot modify (set, unset) in VCL.
+// We define header name as lower case to ensure easily.
+// see: https://developer.fastly.com/reference/http/http-headers/
+var protectedHeaders = map[string]struct{}{
+	"proxy-authenticate":  {},
+	"proxy-authorization": {},
+	"content-length":      {},
+	"content-range":       {},
+	"te":                  {},
+	"trailer":             {},
+	"expect":              {},
+	"transfer-encoding":   {},
+	"upgrade":             {},
+	"fastly-ff":           {},
+}
+
+// IsProtectedHeader returns true if the header name is protected by Fastly
+func IsProtectedHeader(name string) bool {
+	_, ok := protectedHeaders[strings.ToLower(name)]
+	return ok
+}
@@ -1,6 +1,7 @@
 package context
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/ysugimoto/falco/v2/ast"
@@ -278,3 +279,43 @@ func TestSplitName(t *testing.T) {
 		}
 	})
 }
+
+func TestLookup(t *testing.T) {
+	c := New()
+	c.Scope(LOG)
+
+	t.Run("lookup variable without scope check", func(t *testing.T) {
+		v, ok := c.LookupVariable("req.http.Foo")
+		if !ok || v.Get != types.StringType {
+			t.Errorf("req.http.Foo must be found as STRING")
+		}
+		if _, ok := c.LookupVariable("beresp.ttl"); !ok {
+			t.Errorf("beresp.ttl must be found in any scope")
+		}
+		if _, ok := c.LookupVariable("foo.bar"); ok {
+			t.Errorf("foo.bar must not be found")
+		}
+		// Lookup must not mutate the context
+		if _, ok := c.Variables["req"].Items["http"].Items["foo"]; ok {
+			t.Errorf("LookupVariable must not persist the resolved object")
+		}
+	})
+
+	t.Run("lookup function", func(t *testing.T) {
+		if fn, ok := c.LookupFunction("std.tolower"); !ok || fn.Return != types.StringType {
+			t.Errorf("std.tolower must be found")
+		}
+		if _, ok := c.LookupFunction("std"); ok {
+			t.Errorf("std namespace must not be a function")
+		}
+	})
+
+	t.Run("names", func(t *testing.T) {
+		if !slices.Contains(c.VariableNames(), "req.http.%any%") {
+			t.Errorf("VariableNames must contain wildcard variable")
+		}
+		if !slices.Contains(c.FunctionNames(), "std.tolower") {
+			t.Errorf("FunctionNames must contain std.tolower")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package context
+
+import (
+	"sort"
+)
+
+// LookupVariable finds the predefined variable accessor without scope checks.
+// This is used by tooling like language server to describe variables.
+func (c *Context) LookupVariable(name string) (*Accessor, bool) {
+	first, remains := splitName(name)
+	obj, ok := c.Variables[first]
+	if !ok {
+		return nil, false
+	}
+	obj, ok = resolveVariablePath(obj, remains, false)
+	if !ok || obj == nil || obj.Value == nil {
+		return nil, false
+	}
+	return obj.Value, true
+}
+
+// LookupFunction finds the builtin function without scope checks
+func (c *Context) LookupFunction(name string) (*BuiltinFunction, bool) {
+	first, remains := splitName(name)
+	spec, ok := c.functions[first]
+	if !ok {
+		return nil, false
+	}
+	for _, key := range remains {
+		if spec, ok = spec.Items[key]; !ok {
+			return nil, false
+		}
+	}
+	if spec.Value == nil {
+		return nil, false
+	}
+	return spec.Value, true
+}
+
+// VariableNames returns sorted names of all predefined variables.
+// Wildcard segment is returned as it is, like "req.http.%any%".
+func (c *Context) VariableNames() []string {
+	var names []string
+	var walk func(prefix string, items map[string]*Object)
+	walk = func(prefix string, items map[string]*Object) {
+		for key, obj := range items {
+			name := prefix + key
+			if obj.Value != nil {
+				names = append(names, name)
+			}
+			walk(name+".", obj.Items)
+		}
+	}
+	walk("", c.Variables)
+	sort.Strings(names)
+	return names
+}
+
+// FunctionNames returns sorted names of all builtin functions
+func (c *Context) FunctionNames() []string {
+	var names []string
+	var walk func(prefix string, items map[string]*FunctionSpec)
+	walk = func(prefix string, items map[string]*FunctionSpec) {
+		for key, spec := range items {
+			name := prefix + key
+			if spec.Value != nil && !spec.Value.IsUserDefinedFunction {
+				names = append(names, name)
+			}
+			walk(name+".", spec.Items)
+		}
+	}
+	walk("", c.functions)
+	sort.Strings(names)
+	return names
+}
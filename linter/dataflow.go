@@ -0,0 +1,651 @@
+package linter
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/ast"
+)
+
+// Lifecycle subroutines in the order of Fastly request flow.
+// Each subroutine is analyzed after all of its predecessors so the entry state is fixed on analyzing
+var lifecycleOrder = []string{
+	"vcl_recv",
+	"vcl_hash",
+	"vcl_hit",
+	"vcl_miss",
+	"vcl_pass",
+	"vcl_fetch",
+	"vcl_error",
+	"vcl_deliver",
+	"vcl_log",
+}
+
+// Next lifecycle subroutines for each returned state. Empty key is the default state when the subroutine ends without return.
+// Passed request goes to vcl_pass directly in order to distinguish from the lookup, vcl_hash does not usually modify request headers.
+// vcl_fetch may not be reached due to the backend failure so vcl_error also follows the state which goes to vcl_fetch
+var lifecycleTransitions = map[string]map[string][]string{
+	"vcl_recv": {
+		"":       {"vcl_hash"},
+		"lookup": {"vcl_hash"},
+		"pass":   {"vcl_pass"},
+	},
+	"vcl_hash": {
+		"":     {"vcl_hit", "vcl_miss", "vcl_pass"},
+		"hash": {"vcl_hit", "vcl_miss", "vcl_pass"},
+	},
+	"vcl_hit": {
+		"":              {"vcl_deliver"},
+		"deliver":       {"vcl_deliver"},
+		"deliver_stale": {"vcl_deliver"},
+		"pass":          {"vcl_pass"},
+	},
+	"vcl_miss": {
+		"":              {"vcl_fetch", "vcl_error"},
+		"fetch":         {"vcl_fetch", "vcl_error"},
+		"deliver_stale": {"vcl_deliver"},
+		"pass":          {"vcl_pass"},
+	},
+	"vcl_pass": {
+		"":     {"vcl_fetch", "vcl_error"},
+		"pass": {"vcl_fetch", "vcl_error"},
+	},
+	"vcl_fetch": {
+		"":              {"vcl_deliver"},
+		"deliver":       {"vcl_deliver"},
+		"deliver_stale": {"vcl_deliver"},
+		"pass":          {"vcl_deliver"},
+	},
+	"vcl_error": {
+		"":        {"vcl_deliver"},
+		"deliver": {"vcl_deliver"},
+	},
+	"vcl_deliver": {
+		"":        {"vcl_log"},
+		"deliver": {"vcl_log"},
+	},
+}
+
+// flowState is the set of values which are definitely set at the point of the program.
+// Unreachable state is the identity of join operation
+type flowState struct {
+	unreachable bool
+	set         map[string]struct{}
+}
+
+func newFlowState() flowState {
+	return flowState{set: make(map[string]struct{})}
+}
+
+func unreachableFlowState() flowState {
+	return flowState{unreachable: true, set: make(map[string]struct{})}
+}
+
+func (s flowState) clone() flowState {
+	return flowState{unreachable: s.unreachable, set: maps.Clone(s.set)}
+}
+
+func (s flowState) has(name string) bool {
+	_, ok := s.set[name]
+	return s.unreachable || ok
+}
+
+func (s flowState) with(name string) flowState {
+	c := s.clone()
+	c.set[name] = struct{}{}
+	return c
+}
+
+// join merges states of the control flow paths, the value is set only when it is set on all paths
+func joinFlowState(a, b flowState) flowState {
+	if a.unreachable {
+		return b.clone()
+	}
+	if b.unreachable {
+		return a.clone()
+	}
+	s := newFlowState()
+	for name := range a.set {
+		if _, ok := b.set[name]; ok {
+			s.set[name] = struct{}{}
+		}
+	}
+	return s
+}
+
+// flowFrame holds the state of analyzing subroutine
+type flowFrame struct {
+	name    string
+	locals  map[string]struct{} // declared STRING local variables
+	gotos   map[string]flowState
+	breaks  flowState
+	returns flowState // state returns to the caller
+}
+
+// notsetAnalyzer finds the request headers and local variables which may be NOTSET at the point of use.
+// The analysis follows the Fastly lifecycle and inlines called subroutines in order to be context sensitive
+type notsetAnalyzer struct {
+	l           *Linter
+	subroutines map[string]*ast.SubroutineDeclaration
+	headers     map[string]struct{} // request headers which are set in VCL
+	incoming    map[string]flowState
+	lifecycle   string
+	restarts    flowState // state at restart statements
+	isRestarted bool
+	frames      []*flowFrame
+	reported    map[*ast.Ident]struct{}
+	ignore      *ignore
+}
+
+func newNotsetAnalyzer(l *Linter, statements []ast.Statement) *notsetAnalyzer {
+	a := &notsetAnalyzer{
+		l:           l,
+		subroutines: make(map[string]*ast.SubroutineDeclaration),
+		headers:     make(map[string]struct{}),
+		incoming:    make(map[string]flowState),
+		reported:    make(map[*ast.Ident]struct{}),
+		ignore:      &ignore{},
+	}
+	for _, stmt := range statements {
+		sub, ok := stmt.(*ast.SubroutineDeclaration)
+		if !ok {
+			continue
+		}
+		if _, ok := a.subroutines[sub.Name.Value]; !ok {
+			a.subroutines[sub.Name.Value] = sub
+		}
+		// Request headers which are never set in VCL are supposed to be sent from the client
+		walkStatements(sub.Block.Statements, func(stmt ast.Statement) {
+			var ident *ast.Ident
+			switch t := stmt.(type) {
+			case *ast.SetStatement:
+				ident = t.Ident
+			case *ast.AddStatement:
+				ident = t.Ident
+			default:
+				return
+			}
+			if name, ok := requestHeaderName(ident.Value); ok {
+				a.headers[name] = struct{}{}
+			}
+		})
+	}
+	return a
+}
+
+// requestHeaderName returns the normalized header name without subfield
+func requestHeaderName(ident string) (string, bool) {
+	lower := strings.ToLower(ident)
+	if !strings.HasPrefix(lower, "req.http.") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(lower, ":")
+	return name, true
+}
+
+func (a *notsetAnalyzer) frame() *flowFrame {
+	return a.frames[len(a.frames)-1]
+}
+
+// trackedName returns the name of the value which is tracked in the flow state
+func (a *notsetAnalyzer) trackedName(ident string) (string, bool) {
+	if name, ok := requestHeaderName(ident); ok {
+		_, tracked := a.headers[name]
+		return name, tracked
+	}
+	if _, ok := a.frame().locals[ident]; ok {
+		return ident, true
+	}
+	return "", false
+}
+
+// Fastly limits the number of restarts
+const maxRestarts = 3
+
+func (a *notsetAnalyzer) analyze() {
+	restarted := a.run(newFlowState(), false)
+
+	// Restarted request keeps request headers so analyze again with the state at restart statements
+	for i := 0; i < maxRestarts && !restarted.unreachable; i++ {
+		next := joinFlowState(restarted, a.run(restarted, true))
+		if maps.Equal(next.set, restarted.set) {
+			break
+		}
+		restarted = next
+	}
+}
+
+// run analyzes lifecycle subroutines from vcl_recv with the entry state and returns the state at restart statements
+func (a *notsetAnalyzer) run(entry flowState, isRestarted bool) flowState {
+	a.incoming = map[string]flowState{"vcl_recv": entry}
+	a.restarts = unreachableFlowState()
+	a.isRestarted = isRestarted
+
+	for _, name := range lifecycleOrder {
+		entry, ok := a.incoming[name]
+		if !ok {
+			continue
+		}
+
+		a.lifecycle = name
+		sub, ok := a.subroutines[name]
+		if !ok || isIgnoredSubroutineInConfig(a.l.conf.ForFile(sub.Token.File).IgnoreSubroutines, name) {
+			// Fastly runs the default logic which does not touch request headers
+			a.exit(entry, "")
+			continue
+		}
+		if state := a.call(sub, entry); !state.unreachable {
+			a.exit(state, "")
+		}
+	}
+	return a.restarts
+}
+
+// exit records the state to next lifecycle subroutines
+func (a *notsetAnalyzer) exit(state flowState, returnState string) {
+	next, ok := lifecycleTransitions[a.lifecycle][returnState]
+	if !ok {
+		next = lifecycleTransitions[a.lifecycle][""]
+	}
+	for _, name := range next {
+		a.enter(name, state)
+	}
+}
+
+func (a *notsetAnalyzer) enter(name string, state flowState) {
+	if v, ok := a.incoming[name]; ok {
+		a.incoming[name] = joinFlowState(v, state)
+	} else {
+		a.incoming[name] = state.clone()
+	}
+}
+
+// call analyzes the subroutine with the entry state and returns the state which returns to the caller
+func (a *notsetAnalyzer) call(sub *ast.SubroutineDeclaration, entry flowState) flowState {
+	// Recursive call is reported by other rule
+	for _, f := range a.frames {
+		if f.name == sub.Name.Value {
+			return entry
+		}
+	}
+
+	// Local variables are not shared between subroutines
+	state := newFlowState()
+	for name := range entry.set {
+		if !strings.HasPrefix(name, "var.") {
+			state.set[name] = struct{}{}
+		}
+	}
+
+	a.frames = append(a.frames, &flowFrame{
+		name:    sub.Name.Value,
+		locals:  make(map[string]struct{}),
+		gotos:   make(map[string]flowState),
+		breaks:  unreachableFlowState(),
+		returns: unreachableFlowState(),
+	})
+	a.ignore.SetupBlockStatement(sub.Block.GetMeta())
+	state = a.statements(sub.Block.Statements, state)
+	a.ignore.TeardownBlockStatement(sub.Block.GetMeta())
+	ret := joinFlowState(a.frame().returns, state)
+	a.frames = a.frames[:len(a.frames)-1]
+
+	if ret.unreachable {
+		return ret
+	}
+	// Restore local variables of the caller
+	for name := range entry.set {
+		if strings.HasPrefix(name, "var.") {
+			ret.set[name] = struct{}{}
+		}
+	}
+	return ret
+}
+
+func (a *notsetAnalyzer) statements(statements []ast.Statement, state flowState) flowState {
+	for _, stmt := range statements {
+		a.ignore.SetupStatement(stmt.GetMeta())
+		state = a.statement(stmt, state)
+		a.ignore.TeardownStatement(stmt.GetMeta())
+	}
+	return state
+}
+
+// nolint: gocognit,funlen
+func (a *notsetAnalyzer) statement(stmt ast.Statement, state flowState) flowState {
+	switch t := stmt.(type) {
+	case *ast.BlockStatement:
+		a.ignore.SetupBlockStatement(t.GetMeta())
+		defer a.ignore.TeardownBlockStatement(t.GetMeta())
+		return a.statements(t.Statements, state)
+
+	case *ast.DeclareStatement:
+		if t.ValueType.Value != "STRING" {
+			return state
+		}
+		a.frame().locals[t.Name.Value] = struct{}{}
+		state = state.clone()
+		delete(state.set, t.Name.Value)
+		if t.Value != nil {
+			state = a.use(t.Value, state)
+			state.set[t.Name.Value] = struct{}{}
+		}
+		return state
+
+	case *ast.SetStatement:
+		return a.assign(t.Ident, a.use(t.Value, state))
+	case *ast.AddStatement:
+		return a.assign(t.Ident, a.use(t.Value, state))
+	case *ast.UnsetStatement:
+		return a.unset(t.Ident, state)
+	case *ast.RemoveStatement:
+		return a.unset(t.Ident, state)
+
+	case *ast.IfStatement:
+		consequence, alternative := a.condition(t.Condition, state)
+		out := a.statement(t.Consequence, consequence)
+		for _, another := range t.Another {
+			consequence, alternative = a.condition(another.Condition, alternative)
+			out = joinFlowState(out, a.statement(another.Consequence, consequence))
+		}
+		if t.Alternative != nil {
+			return joinFlowState(out, a.statement(t.Alternative.Consequence, alternative))
+		}
+		return joinFlowState(out, alternative)
+
+	case *ast.SwitchStatement:
+		out := unreachableFlowState()
+		fallthroughState := unreachableFlowState()
+		f := a.frame()
+		breaks := f.breaks
+		for _, c := range t.Cases {
+			f.breaks = unreachableFlowState()
+			end := a.statements(c.Statements, joinFlowState(state, fallthroughState))
+			if c.Fallthrough {
+				fallthroughState = end
+				continue
+			}
+			fallthroughState = unreachableFlowState()
+			out = joinFlowState(out, joinFlowState(f.breaks, end))
+		}
+		f.breaks = breaks
+		if t.Default < 0 {
+			out = joinFlowState(out, state)
+		}
+		return out
+
+	case *ast.BreakStatement:
+		a.frame().breaks = joinFlowState(a.frame().breaks, state)
+		return unreachableFlowState()
+
+	case *ast.GotoStatement:
+		f := a.frame()
+		if v, ok := f.gotos[t.Destination.Value]; ok {
+			f.gotos[t.Destination.Value] = joinFlowState(v, state)
+		} else {
+			f.gotos[t.Destination.Value] = state
+		}
+		return unreachableFlowState()
+
+	case *ast.GotoDestinationStatement:
+		if v, ok := a.frame().gotos[strings.TrimSuffix(t.Name.Value, ":")]; ok {
+			return joinFlowState(state, v)
+		}
+		return state
+
+	case *ast.ReturnStatement:
+		if t.ReturnExpression == nil {
+			return a.returns(state, "")
+		}
+		// Returned lifecycle state is the identifier, e.g. return(lookup)
+		if ident, ok := t.ReturnExpression.(*ast.Ident); ok && a.subroutines[a.frame().name].ReturnType == nil {
+			return a.returns(state, ident.Value)
+		}
+		return a.returns(a.use(t.ReturnExpression, state), "")
+
+	case *ast.ErrorStatement:
+		state = a.use(t.Code, state)
+		if t.Argument != nil {
+			state = a.use(t.Argument, state)
+		}
+		a.enter("vcl_error", state)
+		return unreachableFlowState()
+
+	case *ast.RestartStatement:
+		// Local variables are not kept on restart
+		restarted := newFlowState()
+		for name := range state.set {
+			if !strings.HasPrefix(name, "var.") {
+				restarted.set[name] = struct{}{}
+			}
+		}
+		a.restarts = joinFlowState(a.restarts, restarted)
+		return unreachableFlowState()
+
+	case *ast.CallStatement:
+		for _, arg := range t.Arguments {
+			state = a.use(arg, state)
+		}
+		if sub, ok := a.subroutines[t.Subroutine.Value]; ok {
+			return a.call(sub, state)
+		}
+		return state
+
+	case *ast.FunctionCallStatement:
+		for _, arg := range t.Arguments {
+			state = a.use(arg, state)
+		}
+		if sub, ok := a.subroutines[t.Function.Value]; ok {
+			return a.call(sub, state)
+		}
+		return state
+
+	case *ast.LogStatement:
+		return a.use(t.Value, state)
+	case *ast.SyntheticStatement:
+		return a.use(t.Value, state)
+	case *ast.SyntheticBase64Statement:
+		return a.use(t.Value, state)
+
+	case *ast.IncludeStatement:
+		// Included snippet may set any values
+		state = state.clone()
+		for name := range a.headers {
+			state.set[name] = struct{}{}
+		}
+		for name := range a.frame().locals {
+			state.set[name] = struct{}{}
+		}
+		return state
+	}
+	return state
+}
+
+// returns finishes the subroutine. Empty return goes back to the caller,
+// and returning lifecycle state finishes the lifecycle subroutine even if it is returned in the called subroutine
+func (a *notsetAnalyzer) returns(state flowState, returnState string) flowState {
+	if returnState == "" && len(a.frames) > 1 {
+		a.frame().returns = joinFlowState(a.frame().returns, state)
+		return unreachableFlowState()
+	}
+	a.exit(state, returnState)
+	return unreachableFlowState()
+}
+
+func (a *notsetAnalyzer) assign(ident *ast.Ident, state flowState) flowState {
+	name, ok := a.trackedName(ident.Value)
+	if !ok {
+		return state
+	}
+	// Subfield assignment sets the header
+	return state.with(name)
+}
+
+func (a *notsetAnalyzer) unset(ident *ast.Ident, state flowState) flowState {
+	name, ok := a.trackedName(ident.Value)
+	if !ok || strings.Contains(ident.Value, ":") {
+		return state
+	}
+	state = state.clone()
+	delete(state.set, name)
+	return state
+}
+
+// use reports the values which may be NOTSET in the expression.
+// Calling functional subroutine may set values so the state after evaluation is returned
+func (a *notsetAnalyzer) use(expr ast.Expression, state flowState) flowState {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if name, ok := a.trackedName(t.Value); ok && !state.has(name) {
+			a.report(t)
+		}
+	case *ast.GroupedExpression:
+		return a.use(t.Right, state)
+	case *ast.PrefixExpression:
+		// Negation is the test of the value
+		if t.Operator != "!" {
+			return a.use(t.Right, state)
+		}
+	case *ast.PostfixExpression:
+		return a.use(t.Left, state)
+	case *ast.InfixExpression:
+		// Comparison is the test of the value, only string concatenation and arithmetic use the value
+		switch t.Operator {
+		case "+", "-", "*", "/", "%":
+			return a.use(t.Right, a.use(t.Left, state))
+		}
+	case *ast.IfExpression:
+		consequence, alternative := a.condition(t.Condition, state)
+		return joinFlowState(a.use(t.Consequence, consequence), a.use(t.Alternative, alternative))
+	case *ast.FunctionCallExpression:
+		for _, arg := range t.Arguments {
+			state = a.use(arg, state)
+		}
+		if sub, ok := a.subroutines[t.Function.Value]; ok {
+			return a.call(sub, state)
+		}
+	}
+	return state
+}
+
+// condition returns states which the condition is evaluated as true and false.
+// Testing the value narrows the state, e.g. the header is set in the consequence of if (req.http.Foo)
+func (a *notsetAnalyzer) condition(expr ast.Expression, state flowState) (flowState, flowState) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if name, ok := a.trackedName(t.Value); ok {
+			return state.with(name), state
+		}
+	case *ast.GroupedExpression:
+		return a.condition(t.Right, state)
+	case *ast.PrefixExpression:
+		if t.Operator == "!" {
+			consequence, alternative := a.condition(t.Right, state)
+			return alternative, consequence
+		}
+	case *ast.InfixExpression:
+		switch t.Operator {
+		case "&&":
+			lt, lf := a.condition(t.Left, state)
+			rt, rf := a.condition(t.Right, lt)
+			return rt, joinFlowState(lf, rf)
+		case "||":
+			lt, lf := a.condition(t.Left, state)
+			rt, rf := a.condition(t.Right, lf)
+			return joinFlowState(lt, rt), rf
+		case "==", "!=", ">", ">=", "<", "<=":
+			if v, ok := a.evaluateRestarts(t); ok {
+				if v {
+					return state, unreachableFlowState()
+				}
+				return unreachableFlowState(), state
+			}
+		}
+		switch t.Operator {
+		case "~", "==":
+			if name, ok := a.testedName(t); ok {
+				return state.with(name), state
+			}
+		case "!~", "!=":
+			if name, ok := a.testedName(t); ok {
+				return state, state.with(name)
+			}
+		}
+	}
+	return state, state
+}
+
+// evaluateRestarts evaluates the comparison of req.restarts.
+// The value is 0 on the fresh request and greater than 0 on the restarted request
+func (a *notsetAnalyzer) evaluateRestarts(exp *ast.InfixExpression) (bool, bool) {
+	ident, ok := exp.Left.(*ast.Ident)
+	if !ok || ident.Value != "req.restarts" {
+		return false, false
+	}
+	v, ok := exp.Right.(*ast.Integer)
+	if !ok {
+		return false, false
+	}
+
+	if !a.isRestarted {
+		switch exp.Operator {
+		case "==":
+			return v.Value == 0, true
+		case "!=":
+			return v.Value != 0, true
+		case ">":
+			return 0 > v.Value, true
+		case ">=":
+			return 0 >= v.Value, true
+		case "<":
+			return 0 < v.Value, true
+		case "<=":
+			return 0 <= v.Value, true
+		}
+		return false, false
+	}
+
+	// Exact number of restarts is unknown, only comparison with 0 is evaluated
+	if v.Value != 0 {
+		return false, false
+	}
+	switch exp.Operator {
+	case "==", "<", "<=":
+		return false, true
+	case "!=", ">":
+		return true, true
+	case ">=":
+		return true, true
+	}
+	return false, false
+}
+
+// testedName returns the name of the value which is compared with non-empty literal.
+// NOTSET value never matches to them
+func (a *notsetAnalyzer) testedName(exp *ast.InfixExpression) (string, bool) {
+	ident, ok := exp.Left.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if str, ok := exp.Right.(*ast.String); !ok || str.Value == "" {
+		return "", false
+	}
+	return a.trackedName(ident.Value)
+}
+
+func (a *notsetAnalyzer) report(ident *ast.Ident) {
+	if _, ok := a.reported[ident]; ok {
+		return
+	}
+	a.reported[ident] = struct{}{}
+	if a.ignore.IsEnable(POSSIBLY_NOTSET) {
+		return
+	}
+	a.l.Error(PossiblyNotSet(ident.GetMeta(), ident.Value).Match(POSSIBLY_NOTSET))
+}
+
+// lintPossiblyNotSetValues runs dataflow analysis through the Fastly lifecycle
+// and reports values which may be NOTSET at the point of use
+func (l *Linter) lintPossiblyNotSetValues(statements []ast.Statement) {
+	newNotsetAnalyzer(l, statements).analyze()
+}
@@ -0,0 +1,320 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+)
+
+func TestPossiblyNotSetValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		expect []int // lines which are reported
+	}{
+		{
+			name: "header is set in one branch of vcl_recv and read in vcl_miss",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (client.geo.country_code == "JP") {
+    set req.http.X-Geo = "jp";
+  }
+  return (lookup);
+}
+
+sub vcl_miss {
+  #FASTLY MISS
+  set bereq.http.X-Geo = req.http.X-Geo;
+  return (fetch);
+}`,
+			expect: []int{12},
+		},
+		{
+			name: "header is set in all branches",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (client.geo.country_code == "JP") {
+    set req.http.X-Geo = "jp";
+  } else {
+    set req.http.X-Geo = "other";
+  }
+  return (lookup);
+}
+
+sub vcl_miss {
+  #FASTLY MISS
+  set bereq.http.X-Geo = req.http.X-Geo;
+  return (fetch);
+}`,
+		},
+		{
+			name: "header is unset before use",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.X-Geo = "jp";
+  if (req.url ~ "^/admin") {
+    unset req.http.X-Geo;
+  }
+  set req.http.X-Foo = req.http.X-Geo;
+  return (lookup);
+}`,
+			expect: []int{8},
+		},
+		{
+			name: "header which is never set in VCL is sent from the client",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.X-Foo = req.http.User-Agent;
+  return (lookup);
+}`,
+		},
+		{
+			name: "use is guarded by condition",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/jp") {
+    set req.http.X-Geo = "jp";
+  }
+  if (req.http.X-Geo) {
+    set req.http.X-Foo = req.http.X-Geo;
+  }
+  if (req.http.X-Geo == "jp" && req.url ~ "^/") {
+    set req.http.X-Bar = req.http.X-Geo;
+  }
+  set req.http.X-Baz = if(req.http.X-Geo, req.http.X-Geo, "none");
+  return (lookup);
+}`,
+		},
+		{
+			name: "set if not set pattern",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (!req.http.X-Trace) {
+    set req.http.X-Trace = "recv";
+  }
+  set req.http.X-Foo = req.http.X-Trace;
+  return (lookup);
+}`,
+		},
+		{
+			name: "header is set in called subroutine",
+			input: `
+sub set_geo {
+  set req.http.X-Geo = "jp";
+}
+
+sub maybe_set_geo {
+  if (req.url ~ "^/jp") {
+    set req.http.X-Geo = "jp";
+    return;
+  }
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  call set_geo;
+  set req.http.X-Foo = req.http.X-Geo;
+  unset req.http.X-Geo;
+  call maybe_set_geo;
+  set req.http.X-Bar = req.http.X-Geo;
+  return (lookup);
+}`,
+			expect: []int{19},
+		},
+		{
+			name: "use in called subroutine depends on the caller",
+			input: `
+sub use_geo {
+  set req.http.X-Foo = req.http.X-Geo;
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.X-Geo = "jp";
+  call use_geo;
+  return (lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+  unset req.http.X-Geo;
+  call use_geo;
+  return (deliver);
+}`,
+			expect: []int{3},
+		},
+		{
+			name: "returning lifecycle state in called subroutine",
+			input: `
+sub pass_admin {
+  if (req.url ~ "^/admin") {
+    return (pass);
+  }
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  call pass_admin;
+  set req.http.X-Cacheable = "1";
+  return (lookup);
+}
+
+sub vcl_miss {
+  #FASTLY MISS
+  set bereq.http.X-Cacheable = req.http.X-Cacheable;
+  return (fetch);
+}
+
+sub vcl_fetch {
+  #FASTLY FETCH
+  set beresp.http.X-Cacheable = req.http.X-Cacheable;
+  return (deliver);
+}`,
+			expect: []int{23},
+		},
+		{
+			name: "error statement flows to vcl_error",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/404") {
+    error 404;
+  }
+  set req.http.X-Foo = "foo";
+  return (lookup);
+}
+
+sub vcl_error {
+  #FASTLY ERROR
+  set obj.http.X-Foo = req.http.X-Foo;
+  return (deliver);
+}`,
+			expect: []int{13},
+		},
+		{
+			name: "header is set on the first request and kept on restart",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.restarts == 0) {
+    set req.http.X-Trace = "recv";
+  }
+  set req.http.X-Foo = req.http.X-Trace;
+  return (lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+  if (resp.status == 503) {
+    restart;
+  }
+  return (deliver);
+}`,
+		},
+		{
+			name: "header is unset before restart",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.restarts == 0) {
+    set req.http.X-Trace = "recv";
+  }
+  set req.http.X-Foo = req.http.X-Trace;
+  return (lookup);
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+  if (resp.status == 503) {
+    unset req.http.X-Trace;
+    restart;
+  }
+  return (deliver);
+}`,
+			expect: []int{7},
+		},
+		{
+			name: "local variable is read before assignment",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.S STRING;
+  declare local var.T STRING = "t";
+  declare local var.I INTEGER;
+  if (req.url ~ "^/foo") {
+    set var.S = "foo";
+  }
+  set req.http.X-S = var.S;
+  set req.http.X-T = var.T;
+  set req.http.X-I = var.I;
+  return (lookup);
+}`,
+			expect: []int{10},
+		},
+		{
+			name: "switch cases",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.S STRING;
+  declare local var.T STRING;
+  switch (req.url) {
+  case "/foo":
+    set var.S = "foo";
+    break;
+  case "/bar":
+    set var.S = "bar";
+    set var.T = "bar";
+    break;
+  default:
+    set var.S = "default";
+    break;
+  }
+  set req.http.X-S = var.S;
+  set req.http.X-T = var.T;
+  return (lookup);
+}`,
+			expect: []int{19},
+		},
+		{
+			name: "ignore comment",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.S STRING;
+  set req.http.X-S = var.S; # falco-ignore variable/possibly-notset
+  return (lookup);
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			l := New(testConfig)
+			l.Lint(vcl, context.New())
+
+			var lines []int
+			for _, le := range l.Errors {
+				if le.Rule == POSSIBLY_NOTSET {
+					lines = append(lines, le.Token.Line)
+				}
+			}
+			if diff := cmp.Diff(tt.expect, lines); diff != "" {
+				t.Errorf("Reported lines mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
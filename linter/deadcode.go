@@ -0,0 +1,235 @@
+package linter
+
+import (
+	"slices"
+
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/linter/context"
+)
+
+// lintUnreachableSubroutines reports subroutines which are called from somewhere
+// but never reached from Fastly lifecycle subroutines through the call graph.
+// Subroutines which are not called at all are reported by unused/declaration rule.
+func (l *Linter) lintUnreachableSubroutines(graph callGraph, ctx *context.Context) {
+	var roots []string
+	for name, s := range ctx.Subroutines {
+		// Fastly lifecycle subroutines, ignored and scope-enforced ones are entry points
+		// which are invoked from outside of the VCL
+		conf := l.conf.ForFile(s.Decl.Token.File)
+		if context.IsFastlySubroutine(name) || isIgnoredSubroutineInConfig(conf.IgnoreSubroutines, name) {
+			roots = append(roots, name)
+		} else if _, ok := conf.EnforceSubroutineScopes[name]; ok {
+			roots = append(roots, name)
+		}
+	}
+
+	reachable := make(map[string]struct{})
+	for len(roots) > 0 {
+		name := roots[0]
+		roots = roots[1:]
+		if _, ok := reachable[name]; ok {
+			continue
+		}
+		reachable[name] = struct{}{}
+		roots = append(roots, graph[name]...)
+		roots = append(roots, l.includedCallees[name]...)
+	}
+
+	for name, s := range ctx.Subroutines {
+		if !s.IsUsed {
+			continue
+		}
+		if _, ok := reachable[name]; ok {
+			continue
+		}
+		l.Error(UnreachableSubroutine(s.Decl.GetMeta(), name).Match(UNREACHABLE_SUBROUTINE))
+	}
+}
+
+// recordIncludedCallees records subroutines which are called from the included statements in the current subroutine
+func (l *Linter) recordIncludedCallees(original, resolved []ast.Statement, ctx *context.Context) {
+	if ctx.CurrentSubroutine == nil || !slices.ContainsFunc(original, isIncludeStatement) {
+		return
+	}
+	callees := extractCallees(&ast.BlockStatement{Statements: resolved})
+	if len(callees) == 0 {
+		return
+	}
+	if l.includedCallees == nil {
+		l.includedCallees = make(callGraph)
+	}
+	name := ctx.CurrentSubroutine.Name.Value
+	l.includedCallees[name] = append(l.includedCallees[name], callees...)
+}
+
+func isIncludeStatement(stmt ast.Statement) bool {
+	_, ok := stmt.(*ast.IncludeStatement)
+	return ok
+}
+
+// isTerminatedStatement returns true if the statement never passes control to the next statement.
+// if statement terminates when all branches including else terminate.
+func isTerminatedStatement(stmt ast.Statement) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStatement, *ast.ErrorStatement, *ast.RestartStatement, *ast.GotoStatement:
+		return true
+	case *ast.BlockStatement:
+		return isTerminatedBlock(s.Statements)
+	case *ast.IfStatement:
+		if s.Alternative == nil || !isTerminatedBlock(s.Consequence.Statements) {
+			return false
+		}
+		for _, a := range s.Another {
+			if !isTerminatedBlock(a.Consequence.Statements) {
+				return false
+			}
+		}
+		return isTerminatedBlock(s.Alternative.Consequence.Statements)
+	}
+	return false
+}
+
+func isTerminatedBlock(statements []ast.Statement) bool {
+	var terminated bool
+	for _, stmt := range statements {
+		switch stmt.(type) {
+		case *ast.GotoDestinationStatement:
+			// Goto destination could be reached by jump
+			terminated = false
+		case *ast.IncludeStatement:
+			// Included statements are unknown
+			return false
+		default:
+			if isTerminatedStatement(stmt) {
+				terminated = true
+			}
+		}
+	}
+	return terminated
+}
+
+// unreachableStatementChecker reports the first statement after the terminated statement in the same block.
+// The statements after goto destination are reachable again.
+type unreachableStatementChecker struct {
+	terminated bool
+}
+
+func (c *unreachableStatementChecker) check(l *Linter, stmt ast.Statement) {
+	if _, ok := stmt.(*ast.GotoDestinationStatement); ok {
+		c.terminated = false
+		return
+	}
+	if c.terminated {
+		l.Error(UnreachableStatement(stmt.GetMeta()).Match(UNREACHABLE_STATEMENT))
+		// Report only once for the sequence of unreachable statements
+		c.terminated = false
+	}
+}
+
+func (c *unreachableStatementChecker) next(stmt ast.Statement) {
+	if isTerminatedStatement(stmt) {
+		c.terminated = true
+	}
+}
+
+// lintConstantCondition reports the if condition which is constant-folded to true or false
+func (l *Linter) lintConstantCondition(cond ast.Expression) {
+	if v, ok := foldConstantCondition(cond); ok {
+		l.Error(ConstantCondition(cond.GetMeta(), v).Match(CONSTANT_CONDITION))
+	}
+}
+
+// foldConstantCondition evaluates the condition which consists of literals only.
+// Second return value is false if the condition could not be determined statically.
+func foldConstantCondition(expr ast.Expression) (bool, bool) {
+	switch e := expr.(type) {
+	case *ast.Boolean:
+		return e.Value, true
+	case *ast.GroupedExpression:
+		return foldConstantCondition(e.Right)
+	case *ast.PrefixExpression:
+		if e.Operator != "!" {
+			return false, false
+		}
+		v, ok := foldConstantCondition(e.Right)
+		return !v, ok
+	case *ast.InfixExpression:
+		switch e.Operator {
+		case "&&", "||":
+			left, lok := foldConstantCondition(e.Left)
+			right, rok := foldConstantCondition(e.Right)
+			short := e.Operator == "||"
+			// false && x, x && false, true || x, x || true are constant regardless of the other side
+			if (lok && left == short) || (rok && right == short) {
+				return short, true
+			}
+			if lok && rok {
+				return left == short || right == short, true
+			}
+			return false, false
+		default:
+			return foldLiteralComparison(e.Left, e.Operator, e.Right)
+		}
+	}
+	return false, false
+}
+
+func foldLiteralComparison(left ast.Expression, operator string, right ast.Expression) (bool, bool) {
+	switch lv := left.(type) {
+	case *ast.String:
+		rv, ok := right.(*ast.String)
+		if !ok {
+			return false, false
+		}
+		switch operator {
+		case "==":
+			return lv.Value == rv.Value, true
+		case "!=":
+			return lv.Value != rv.Value, true
+		}
+	case *ast.Boolean:
+		rv, ok := right.(*ast.Boolean)
+		if !ok {
+			return false, false
+		}
+		switch operator {
+		case "==":
+			return lv.Value == rv.Value, true
+		case "!=":
+			return lv.Value != rv.Value, true
+		}
+	case *ast.Integer:
+		switch rv := right.(type) {
+		case *ast.Integer:
+			return compareNumbers(float64(lv.Value), operator, float64(rv.Value))
+		case *ast.Float:
+			return compareNumbers(float64(lv.Value), operator, rv.Value)
+		}
+	case *ast.Float:
+		switch rv := right.(type) {
+		case *ast.Integer:
+			return compareNumbers(lv.Value, operator, float64(rv.Value))
+		case *ast.Float:
+			return compareNumbers(lv.Value, operator, rv.Value)
+		}
+	}
+	return false, false
+}
+
+func compareNumbers(left float64, operator string, right float64) (bool, bool) {
+	switch operator {
+	case "==":
+		return left == right, true
+	case "!=":
+		return left != right, true
+	case ">":
+		return left > right, true
+	case ">=":
+		return left >= right, true
+	case "<":
+		return left < right, true
+	case "<=":
+		return left <= right, true
+	}
+	return false, false
+}
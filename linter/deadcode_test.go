@@ -0,0 +1,236 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+)
+
+func TestDeadCode(t *testing.T) {
+	type report struct {
+		Rule Rule
+		Line int
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		dependency map[string]string
+		expect     []report
+	}{
+		{
+			name: "subroutine is called only from unused subroutine",
+			input: `
+sub leaf {
+  set req.http.X-Leaf = "1";
+}
+
+sub orphan {
+  call leaf;
+}
+
+sub reached {
+  set req.http.X-Reached = "1";
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  call reached;
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: UNREACHABLE_SUBROUTINE, Line: 2},
+			},
+		},
+		{
+			name: "subroutine is reached through nested calls and functions",
+			input: `
+sub get_geo STRING {
+  return "jp";
+}
+
+sub set_geo {
+  set req.http.X-Geo = get_geo();
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/jp") {
+    call set_geo;
+  }
+  return (lookup);
+}`,
+		},
+		{
+			name: "statements after terminated statements",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/admin") {
+    error 403;
+    set req.http.X-Foo = "1";
+    set req.http.X-Bar = "1";
+  }
+  if (req.restarts > 0) {
+    restart;
+  } else {
+    return (pass);
+  }
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: UNREACHABLE_STATEMENT, Line: 6},
+				{Rule: UNREACHABLE_STATEMENT, Line: 14},
+			},
+		},
+		{
+			name: "goto destination is reachable",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/admin") {
+    goto done;
+  }
+  goto done;
+  set req.http.X-Foo = "1";
+  done:
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: UNREACHABLE_STATEMENT, Line: 8},
+			},
+		},
+		{
+			name: "statements in switch case",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  switch (req.url) {
+  case "/foo":
+    return (pass);
+    set req.http.X-Foo = "1";
+    break;
+  default:
+    set req.http.X-Foo = "2";
+    break;
+  }
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: UNREACHABLE_STATEMENT, Line: 7},
+			},
+		},
+		{
+			name: "constant conditions",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (false) {
+    set req.http.X-A = "1";
+  } else if (!(true || req.http.X-Foo)) {
+    set req.http.X-B = "1";
+  }
+  if (req.http.X-Foo && false) {
+    set req.http.X-C = "1";
+  }
+  if (req.http.X-Foo || req.http.X-Bar) {
+    set req.http.X-D = "1";
+  }
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: CONSTANT_CONDITION, Line: 4},
+				{Rule: CONSTANT_CONDITION, Line: 6},
+				{Rule: CONSTANT_CONDITION, Line: 9},
+			},
+		},
+		{
+			name: "subroutine is called from included statements",
+			input: `
+sub included_leaf {
+  set req.http.X-Included = "1";
+}
+
+sub leaf {
+  set req.http.X-Leaf = "1";
+}
+
+sub orphan {
+  call leaf;
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  include "recv";
+  return (lookup);
+}`,
+			dependency: map[string]string{
+				"recv": `call included_leaf;`,
+			},
+			expect: []report{
+				{Rule: UNREACHABLE_SUBROUTINE, Line: 6},
+			},
+		},
+		{
+			name: "ignore comment",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  return (lookup);
+  # falco-ignore-next-line unreachable/statement
+  set req.http.X-Foo = "1";
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			l := New(testConfig)
+			l.Lint(vcl, context.New(context.WithResolver(&mockResolver{dependency: tt.dependency})))
+
+			var reports []report
+			for _, le := range l.Errors {
+				switch le.Rule {
+				case UNREACHABLE_SUBROUTINE, UNREACHABLE_STATEMENT, CONSTANT_CONDITION:
+					reports = append(reports, report{Rule: le.Rule, Line: le.Token.Line})
+				}
+			}
+			if diff := cmp.Diff(tt.expect, reports); diff != "" {
+				t.Errorf("Reports mismatch, diff=%s", diff)
+			}
+		})
+	}
+
+	t.Run("literal comparison", func(t *testing.T) {
+		tests := []struct {
+			input  string
+			value  bool
+			folded bool
+		}{
+			{input: `1 == 1`, value: true, folded: true},
+			{input: `1 > 2.5`, value: false, folded: true},
+			{input: `"foo" != "bar"`, value: true, folded: true},
+			{input: `"foo" ~ "bar"`},
+			{input: `req.restarts == 0`},
+		}
+		for _, tt := range tests {
+			vcl, err := parser.New(lexer.NewFromString("sub vcl_recv { if (" + tt.input + ") { esi; } }")).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			cond := vcl.Statements[0].(*ast.SubroutineDeclaration).Block.Statements[0].(*ast.IfStatement).Condition
+			value, folded := foldConstantCondition(cond)
+			if value != tt.value || folded != tt.folded {
+				t.Errorf("%s: expects (%t, %t), got (%t, %t)", tt.input, tt.value, tt.folded, value, folded)
+			}
+		}
+	})
+}
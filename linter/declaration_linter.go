@@ -303,7 +303,8 @@ func (l *Linter) lintTableProperty(prop *ast.TableProperty, tableType types.Type
 
 func (l *Linter) lintSubRoutineDeclaration(decl *ast.SubroutineDeclaration, ctx *context.Context) types.Type {
 	// If ignore target in configuration, skip it
-	if isIgnoredSubroutineInConfig(l.conf.IgnoreSubroutines, decl.Name.Value) {
+	conf := l.conf.ForFile(decl.Token.File)
+	if isIgnoredSubroutineInConfig(conf.IgnoreSubroutines, decl.Name.Value) {
 		return types.NeverType
 	}
 
@@ -326,7 +327,7 @@ func (l *Linter) lintSubRoutineDeclaration(decl *ast.SubroutineDeclaration, ctx
 	if scope == -1 {
 		// If scope could not recognized from subroutine name or annotation,
 		// try to find from configuration
-		if enforces, ok := l.conf.EnforceSubroutineScopes[decl.Name.Value]; ok {
+		if enforces, ok := conf.EnforceSubroutineScopes[decl.Name.Value]; ok {
 			scope = enforceSubroutineCallScopeFromConfig(enforces)
 		}
 	}
@@ -383,7 +384,7 @@ func (l *Linter) lintSubRoutineDeclaration(decl *ast.SubroutineDeclaration, ctx
 	// Switch context mode which corredponds to call scope and restore after linting block statements
 	defer func() {
 		// Lint declared variables are used
-		l.lintUnusedVariables(ctx)
+		l.lintUnusedVariables(ctx, decl)
 		cc.Restore()
 	}()
 
@@ -7,7 +7,9 @@ import (
 	"github.com/ysugimoto/falco/v2/ast"
 	"github.com/ysugimoto/falco/v2/lexer"
 	"github.com/ysugimoto/falco/v2/linter/types"
+	"github.com/ysugimoto/falco/v2/parser"
 	"github.com/ysugimoto/falco/v2/plugin"
+	"github.com/ysugimoto/falco/v2/regex"
 	"github.com/ysugimoto/falco/v2/token"
 )
 
@@ -26,6 +28,7 @@ type LintError struct {
 	Message   string
 	Reference string
 	Rule      Rule
+	Fix       *Fix `json:",omitempty"` // suggested edit, only present for mechanically fixable errors
 }
 
 func (l *LintError) Match(r Rule) *LintError {
@@ -424,6 +427,14 @@ func DeprecatedVariable(name string, m *ast.Meta) *LintError {
 	}
 }
 
+func DeprecatedFunction(name, replacement string, m *ast.Meta) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Function "%s" is deprecated, use "%s" instead`, name, replacement),
+	}
+}
+
 func UncapturedRegexVariable(name string, m *ast.Meta) *LintError {
 	err := &LintError{
 		Severity: WARNING,
@@ -477,4 +488,88 @@ func FromPluginError(pe *plugin.Error, m *ast.Meta) *LintError {
 type FatalError struct {
 	Lexer *lexer.Lexer
 	Error error
+
+	// All syntax errors found in included modules
+	ParseErrors []*parser.ParseError
+}
+
+// PossiblyNotSet raises WARNING when the value may be NOTSET at the point of use
+func PossiblyNotSet(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`"%s" may be NOTSET at this point, it is not set on all paths which reach here`, name),
+	}
+}
+
+func UnreachableSubroutine(m *ast.Meta, name string) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Subroutine "%s" is never reached from Fastly lifecycle subroutines`, name),
+	}
+}
+
+func UnreachableStatement(m *ast.Meta) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  "Unreachable statement, previous statement never passes control to here",
+	}
+}
+
+func ConstantCondition(m *ast.Meta, v bool) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf("Condition is always %t", v),
+	}
+}
+
+func LiteralSizeOverflow(m *ast.Meta, name string, size, limit int) *LintError {
+	return &LintError{
+		Severity: ERROR,
+		Token:    m.Token,
+		Message:  fmt.Sprintf("Literal for %s is at least %d bytes, exceeding the Fastly limit of %d bytes", name, size, limit),
+	}
+}
+
+func HeaderCountBudget(m *ast.Meta, group string, count, limit int) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf("VCL may put %d %s headers in the worst case, exceeding the Fastly limit of %d headers", count, group, limit),
+	}
+}
+
+func HeaderSizeBudget(m *ast.Meta, group string, size, limit int) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf("VCL may put at least %d bytes of %s headers in the worst case, exceeding the Fastly limit of %d bytes", size, group, limit),
+	}
+}
+
+func RegexUnsupported(m *ast.Meta, issue *regex.Issue) *LintError {
+	return &LintError{
+		Severity: ERROR,
+		Token:    m.Token,
+		Message:  fmt.Sprintf("Regex pattern is accepted locally but rejected on Fastly, %s", issue.Error()),
+	}
+}
+
+func RegexBacktracking(m *ast.Meta, issue *regex.Issue) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  issue.Error(),
+	}
+}
+
+func UndefinedReplacementReference(m *ast.Meta, ref, groups int) *LintError {
+	return &LintError{
+		Severity: WARNING,
+		Token:    m.Token,
+		Message:  fmt.Sprintf(`Replacement references \%d but the pattern has %d capture group(s), it is replaced with empty string`, ref, groups),
+	}
 }
@@ -227,7 +227,11 @@ func (l *Linter) lintStringConcatInfixExpression(exp *ast.InfixExpression, ctx *
 			}
 			l.Error(InvalidStringConcatenation(meta, "RTIME").Match(OPERATOR_CONDITIONAL))
 		default:
-			l.Error(InvalidStringConcatenation(meta, nt.String()).Match(OPERATOR_CONDITIONAL))
+			err := InvalidStringConcatenation(meta, nt.String()).Match(OPERATOR_CONDITIONAL)
+			if fix := stringLiteralFix(exp, s.Expression); fix != nil {
+				err.WithFix(fix)
+			}
+			l.Error(err)
 		}
 	OUT:
 		ct = nt
@@ -296,14 +300,7 @@ func (l *Linter) lintInfixExpression(exp *ast.InfixExpression, ctx *context.Cont
 		}
 		// And, if right expression is STRING, regex must be valid
 		if v, ok := exp.Right.(*ast.String); ok {
-			if err := validateRegex(v.Value); err != nil {
-				err := &LintError{
-					Severity: ERROR,
-					Token:    exp.Right.GetMeta().Token,
-					Message:  "regex string is invalid, " + err.Error(),
-				}
-				l.Error(err)
-			}
+			l.lintRegexPattern(v)
 		}
 		// Check if regex is matching file extensions on req.url or req.url.path
 		if ident, ok := exp.Left.(*ast.Ident); ok {
@@ -311,7 +308,9 @@ func (l *Linter) lintInfixExpression(exp *ast.InfixExpression, ctx *context.Cont
 				if str, ok := exp.Right.(*ast.String); ok {
 					if exts := extractExtensionsFromRegex(str.Value); exts != nil {
 						suggestion := buildExtSuggestion(exp.Operator, exts)
-						l.Error(RegexUrlExtension(exp.GetMeta(), suggestion).Match(REGEX_URL_EXTENSION))
+						l.Error(RegexUrlExtension(exp.GetMeta(), suggestion).Match(REGEX_URL_EXTENSION).WithFix(
+							regexUrlExtensionFix(exp, ident, str, exts),
+						))
 					}
 				}
 			}
@@ -405,6 +404,12 @@ func (l *Linter) lintFunctionCallExpression(exp *ast.FunctionCallExpression, ctx
 		return types.NeverType
 	}
 
+	if replacement, ok := deprecatedFunctions[exp.Function.Value]; ok {
+		l.Error(DeprecatedFunction(exp.Function.Value, replacement, exp.Function.GetMeta()).Match(DEPRECATED).WithFix(
+			deprecatedFunctionFix(exp.Function, replacement),
+		))
+	}
+
 	// testing.call_subroutine has a dynamic signature whose extra arguments
 	// depend on the target subroutine's parameter list. Validate it separately,
 	// mirroring the statement-call path.
@@ -30,7 +30,8 @@ sub foo {
 		restart;
 	}
 }`
-		assertNoError(t, input)
+		// Bang prefix is allowed, but the condition is always false
+		assertOnlyRule(t, input, CONSTANT_CONDITION)
 
 	})
 
@@ -0,0 +1,307 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// Fix is the suggested edit which resolves the lint error mechanically.
+// The edit is described as the source range replacement for editor integrations,
+// and the same change is applied to the AST via Apply.
+type Fix struct {
+	Description string
+
+	// Source range to be replaced with Text.
+	// Line and Position are 1-based, and the end of range is exclusive
+	Line        int
+	Position    int
+	EndLine     int
+	EndPosition int
+	Text        string
+
+	apply   func(vcl *ast.VCL) bool
+	applied bool
+}
+
+// Apply applies the fix to the AST of the file which the lint error is reported on.
+// Returns false if the fix has already been applied or the target node is not found
+func (f *Fix) Apply(vcl *ast.VCL) bool {
+	if f.applied || f.apply == nil {
+		return false
+	}
+	f.applied = f.apply(vcl)
+	return f.applied
+}
+
+func (e *LintError) WithFix(f *Fix) *LintError {
+	e.Fix = f
+	return e
+}
+
+// replaceFix replaces the source range of start and end node with the text
+func replaceFix(description string, start, end *ast.Meta, text string, apply func() bool) *Fix {
+	return &Fix{
+		Description: description,
+		Line:        start.Token.Line,
+		Position:    start.Token.Position,
+		EndLine:     end.EndLine,
+		EndPosition: end.EndPosition + 1,
+		Text:        text,
+		apply: func(vcl *ast.VCL) bool {
+			return apply()
+		},
+	}
+}
+
+// removeStatementFix removes whole lines of the statement
+func removeStatementFix(description string, stmt ast.Statement) *Fix {
+	m := stmt.GetMeta()
+	return &Fix{
+		Description: description,
+		Line:        m.Token.Line,
+		Position:    1,
+		EndLine:     m.EndLine + 1,
+		EndPosition: 1,
+		apply: func(vcl *ast.VCL) bool {
+			return removeStatement(vcl, stmt)
+		},
+	}
+}
+
+// removeStatement finds the statement in the VCL and removes it from the parent
+func removeStatement(vcl *ast.VCL, target ast.Statement) bool {
+	if statements, _, ok := removeFromStatements(vcl.Statements, target); ok {
+		vcl.Statements = statements
+		return true
+	}
+	for _, stmt := range vcl.Statements {
+		if sub, ok := stmt.(*ast.SubroutineDeclaration); ok && removeFromBlock(sub.Block, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFromStatements removes the target statement and moves its leading comments to the next statement.
+// Comments are returned if the target is the last statement
+func removeFromStatements(statements []ast.Statement, target ast.Statement) ([]ast.Statement, ast.Comments, bool) {
+	for i := range statements {
+		if statements[i] != target {
+			continue
+		}
+		comments := target.GetMeta().Leading
+		if i+1 < len(statements) {
+			next := statements[i+1].GetMeta()
+			next.Leading = append(comments, next.Leading...)
+			comments = nil
+		}
+		return append(statements[:i:i], statements[i+1:]...), comments, true
+	}
+	return statements, nil, false
+}
+
+// nolint: gocognit
+func removeFromBlock(block *ast.BlockStatement, target ast.Statement) bool {
+	if block == nil {
+		return false
+	}
+	if statements, comments, ok := removeFromStatements(block.Statements, target); ok {
+		block.Statements = statements
+		block.Infix = append(block.Infix, comments...)
+		return true
+	}
+	for _, stmt := range block.Statements {
+		switch t := stmt.(type) {
+		case *ast.BlockStatement:
+			if removeFromBlock(t, target) {
+				return true
+			}
+		case *ast.IfStatement:
+			if removeFromBlock(t.Consequence, target) {
+				return true
+			}
+			for _, another := range t.Another {
+				if removeFromBlock(another.Consequence, target) {
+					return true
+				}
+			}
+			if t.Alternative != nil && removeFromBlock(t.Alternative.Consequence, target) {
+				return true
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				if statements, _, ok := removeFromStatements(c.Statements, target); ok {
+					c.Statements = statements
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// walkStatements calls the function for all statements in the nested blocks
+func walkStatements(statements []ast.Statement, fn func(stmt ast.Statement)) {
+	for _, stmt := range statements {
+		fn(stmt)
+		switch t := stmt.(type) {
+		case *ast.BlockStatement:
+			walkStatements(t.Statements, fn)
+		case *ast.IfStatement:
+			walkStatements(t.Consequence.Statements, fn)
+			for _, another := range t.Another {
+				walkStatements(another.Consequence.Statements, fn)
+			}
+			if t.Alternative != nil {
+				walkStatements(t.Alternative.Consequence.Statements, fn)
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				walkStatements(c.Statements, fn)
+			}
+		}
+	}
+}
+
+// replaceOperand replaces the operand of string concatenation expression
+func replaceOperand(exp ast.Expression, target, replacement ast.Expression) bool {
+	infix, ok := exp.(*ast.InfixExpression)
+	if !ok {
+		return false
+	}
+	switch {
+	case infix.Left == target:
+		infix.Left = replacement
+	case infix.Right == target:
+		infix.Right = replacement
+	default:
+		return replaceOperand(infix.Left, target, replacement) || replaceOperand(infix.Right, target, replacement)
+	}
+	return true
+}
+
+// regexUrlExtensionFix rewrites the regex matching to the comparison of req.url.ext
+func regexUrlExtensionFix(exp *ast.InfixExpression, ident *ast.Ident, str *ast.String, exts []string) *Fix {
+	var operator, value string
+	if len(exts) == 1 {
+		operator, value = "==", exts[0]
+		if exp.Operator == "!~" {
+			operator = "!="
+		}
+	} else {
+		operator, value = exp.Operator, "^("+strings.Join(exts, "|")+")$"
+	}
+
+	suggestion := buildExtSuggestion(exp.Operator, exts)
+	return replaceFix("Use req.url.ext", ident.GetMeta(), str.GetMeta(), suggestion, func() bool {
+		ident.Value = "req.url.ext"
+		exp.Operator = operator
+		str.Value = value
+		str.Token.Literal = value
+		str.LongString = false
+		str.Delimiter = ""
+		return true
+	})
+}
+
+// overwriteVaryFix sets the value as the subfield of Vary header
+func overwriteVaryFix(stmt *ast.SetStatement, subfield string) *Fix {
+	name := stmt.Ident.Value + ":" + subfield
+	return replaceFix("Use subfield "+name, stmt.Ident.GetMeta(), stmt.Value.GetMeta(), name+` = ""`, func() bool {
+		stmt.Ident.Value = name
+		stmt.Value = newStringLiteral(stmt.Value.GetMeta(), "")
+		return true
+	})
+}
+
+// Return states which Fastly boilerplate uses for each state-machine method
+var defaultReturnStates = map[int]string{
+	context.RECV:    "lookup",
+	context.HASH:    "hash",
+	context.HIT:     "deliver",
+	context.MISS:    "fetch",
+	context.PASS:    "pass",
+	context.FETCH:   "deliver",
+	context.ERROR:   "deliver",
+	context.DELIVER: "deliver",
+	context.LOG:     "deliver",
+}
+
+// emptyReturnFix returns the default state of the state-machine method
+func emptyReturnFix(stmt *ast.ReturnStatement, state string) *Fix {
+	return replaceFix("Return "+state, stmt.GetMeta(), stmt.GetMeta(), "return("+state+")", func() bool {
+		stmt.ReturnExpression = &ast.Ident{Meta: ast.New(stmt.Token, stmt.Nest), Value: state}
+		stmt.HasParenthesis = true
+		return true
+	})
+}
+
+// stringLiteralFix quotes the numeric literal in the string concatenation
+func stringLiteralFix(exp *ast.InfixExpression, operand ast.Expression) *Fix {
+	switch operand.(type) {
+	case *ast.Integer, *ast.Float:
+	default:
+		return nil
+	}
+	meta := operand.GetMeta()
+	literal := meta.Token.Literal
+	return replaceFix("Use string literal", meta, meta, `"`+literal+`"`, func() bool {
+		return replaceOperand(exp, operand, newStringLiteral(meta, literal))
+	})
+}
+
+// unusedVariableFix removes the declaration of the variable which is never referenced in the subroutine.
+// The fix is not suggested when the variable is assigned or the initial value may have side effects
+func unusedVariableFix(sub *ast.SubroutineDeclaration, m *ast.Meta) *Fix {
+	var declare *ast.DeclareStatement
+	assigned := map[string]struct{}{}
+	var hasInclude bool
+	walkStatements(sub.Block.Statements, func(stmt ast.Statement) {
+		switch t := stmt.(type) {
+		case *ast.DeclareStatement:
+			if t.Meta == m {
+				declare = t
+			}
+		case *ast.SetStatement:
+			assigned[t.Ident.Value] = struct{}{}
+		case *ast.UnsetStatement:
+			assigned[t.Ident.Value] = struct{}{}
+		case *ast.AddStatement:
+			assigned[t.Ident.Value] = struct{}{}
+		case *ast.RemoveStatement:
+			assigned[t.Ident.Value] = struct{}{}
+		case *ast.IncludeStatement:
+			// Included snippet may assign the variable
+			hasInclude = true
+		}
+	})
+	if declare == nil || hasInclude {
+		return nil
+	}
+	if _, ok := assigned[declare.Name.Value]; ok {
+		return nil
+	}
+	if declare.Value != nil && !isLiteralExpression(declare.Value) {
+		return nil
+	}
+	return removeStatementFix("Remove unused variable "+declare.Name.Value, declare)
+}
+
+// deprecatedFunctionFix renames the function to the replacement
+func deprecatedFunctionFix(fn *ast.Ident, replacement string) *Fix {
+	return replaceFix("Use "+replacement, fn.GetMeta(), fn.GetMeta(), replacement, func() bool {
+		fn.Value = replacement
+		return true
+	})
+}
+
+// newStringLiteral creates the string literal node at the position of the meta
+func newStringLiteral(m *ast.Meta, value string) *ast.String {
+	meta := m.Clone()
+	meta.Token.Type = token.STRING
+	meta.Token.Literal = value
+	return &ast.String{Meta: meta, Value: value}
+}
@@ -0,0 +1,203 @@
+package linter
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/formatter"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+)
+
+// applyTextEdit replaces the source range of the fix with its text
+func applyTextEdit(src string, f *Fix) string {
+	lines := strings.SplitAfter(src, "\n")
+	offset := func(line, position int) int {
+		var n int
+		for i := 0; i < line-1 && i < len(lines); i++ {
+			n += len(lines[i])
+		}
+		return n + position - 1
+	}
+	return src[:offset(f.Line, f.Position)] + f.Text + src[offset(f.EndLine, f.EndPosition):]
+}
+
+func formatVCL(t *testing.T, vcl *ast.VCL) string {
+	buf, err := io.ReadAll(formatter.New(&config.FormatConfig{
+		IndentWidth:                2,
+		IndentStyle:                config.IndentStyleSpace,
+		TrailingCommentWidth:       1,
+		LineWidth:                  120,
+		ExplicitStringConcat:       true,
+		ReturnStatementParenthesis: true,
+	}).Format(vcl))
+	if err != nil {
+		t.Fatalf("Unexpected format error: %s", err)
+	}
+	return string(buf)
+}
+
+func TestLintFixes(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		input  string
+		expect string
+	}{
+		{
+			name: "quote numeric literal in string concatenation",
+			rule: OPERATOR_CONDITIONAL,
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Cache-Control = "max-age=" + 300;
+  return (lookup);
+}`,
+			expect: `"300"`,
+		},
+		{
+			name: "empty return in state-machine method",
+			rule: DISALLOW_EMPTY_RETURN,
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  return;
+}`,
+			expect: `return(lookup)`,
+		},
+		{
+			name: "remove unused variable",
+			rule: UNUSED_VARIABLE,
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  declare local var.unused STRING;
+  return (lookup);
+}`,
+			expect: ``,
+		},
+		{
+			name: "set Vary subfield",
+			rule: OVERWRITE_VARY,
+			input: `sub vcl_fetch {
+  #FASTLY FETCH
+  set beresp.http.Vary = "Accept-Encoding";
+  return (deliver);
+}`,
+			expect: `beresp.http.Vary:Accept-Encoding = ""`,
+		},
+		{
+			name: "replace deprecated function",
+			rule: DEPRECATED,
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  set req.url = boltsort.sort(req.url);
+  return (lookup);
+}`,
+			expect: `querystring.sort`,
+		},
+		{
+			name: "use req.url.ext",
+			rule: REGEX_URL_EXTENSION,
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  if (req.url !~ "\.(jpg|png)$") {
+    return (pass);
+  }
+  return (lookup);
+}`,
+			expect: `req.url.ext !~ "^(jpg|png)$"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("Unexpected parser error: %s", err)
+			}
+			l := New(&config.LinterConfig{})
+			l.Lint(vcl, context.New())
+
+			var fix *Fix
+			for _, le := range l.Errors {
+				if le.Rule == tt.rule && le.Fix != nil {
+					fix = le.Fix
+				}
+			}
+			if fix == nil {
+				t.Fatalf("Expected fix for %s but not found in %v", tt.rule, l.Errors)
+			}
+			if fix.Text != tt.expect {
+				t.Errorf("Fix text mismatch, expect=%q, got=%q", tt.expect, fix.Text)
+			}
+
+			// Text edit and AST fix must produce the same VCL
+			edited, err := parser.New(lexer.NewFromString(applyTextEdit(tt.input, fix))).ParseVCL()
+			if err != nil {
+				t.Fatalf("Edited VCL could not be parsed: %s", err)
+			}
+			if !fix.Apply(vcl) {
+				t.Fatalf("Failed to apply fix")
+			}
+			if fix.Apply(vcl) {
+				t.Errorf("Fix must be applied only once")
+			}
+			if expect, actual := formatVCL(t, edited), formatVCL(t, vcl); expect != actual {
+				t.Errorf("Fixed AST mismatch, expect=\n%s\ngot=\n%s", expect, actual)
+			}
+
+			// Fixed VCL does not have the error anymore
+			l = New(&config.LinterConfig{})
+			l.Lint(vcl, context.New())
+			for _, le := range l.Errors {
+				if le.Rule == tt.rule {
+					t.Errorf("Lint error still exists after fix: %s", le)
+				}
+			}
+		})
+	}
+}
+
+func TestUnusedVariableFixIsNotSuggested(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name: "variable is assigned",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  declare local var.v STRING;
+  set var.v = randomstr(10);
+  return (lookup);
+}`,
+		},
+		{
+			name: "snippet is included",
+			input: `sub vcl_recv {
+  #FASTLY RECV
+  declare local var.v STRING;
+  include "snippet::assign";
+  return (lookup);
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("Unexpected parser error: %s", err)
+			}
+			l := New(&config.LinterConfig{})
+			l.Lint(vcl, context.New())
+			for _, le := range l.Errors {
+				if le.Rule == UNUSED_VARIABLE && le.Fix != nil {
+					t.Errorf("Fix must not be suggested: %+v", le.Fix)
+				}
+			}
+		})
+	}
+}
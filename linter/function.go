@@ -28,6 +28,11 @@ var implicitCoersionTable = map[types.Type][]types.Type{
 	},
 }
 
+// Deprecated builtin functions and their replacements which have the same signature
+var deprecatedFunctions = map[string]string{
+	"boltsort.sort": "querystring.sort",
+}
+
 func (l *Linter) lintFunctionArguments(fn *context.BuiltinFunction, calledFn functionMeta, ctx *context.Context) types.Type {
 	// lint empty arguments
 	if len(fn.Arguments) == 0 {
@@ -114,7 +119,8 @@ func (l *Linter) lintFunctionArguments(fn *context.BuiltinFunction, calledFn fun
 	}
 
 	// Special cases
-	if calledFn.name == "regsub" || calledFn.name == "regsuball" {
+	switch calledFn.name {
+	case "regsub", "regsuball":
 		if !isTypeLiteral(calledFn.arguments[1]) {
 			l.Error(&LintError{
 				Severity: ERROR,
@@ -122,6 +128,16 @@ func (l *Linter) lintFunctionArguments(fn *context.BuiltinFunction, calledFn fun
 				Message:  "Regex patterns must be string literals.",
 			})
 		}
+		if pattern, ok := calledFn.arguments[1].(*ast.String); ok {
+			l.lintRegexPattern(pattern)
+			if replacement, ok := calledFn.arguments[2].(*ast.String); ok {
+				l.lintRegexReplacement(pattern, replacement)
+			}
+		}
+	case "querystring.regfilter", "querystring.regfilter_except":
+		if pattern, ok := calledFn.arguments[1].(*ast.String); ok {
+			l.lintRegexPattern(pattern)
+		}
 	}
 
 	return fn.Return
@@ -0,0 +1,207 @@
+package linter
+
+import (
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/limits"
+)
+
+// Static checks of Fastly platform limits which interpreter/limitations enforces at runtime.
+// The interpreter only finds the overflow when the code path is executed,
+// so the linter estimates them from the literals in the code.
+
+const (
+	headerGroupRequest  = "request"
+	headerGroupResponse = "response"
+)
+
+// headerBudget accumulates the worst-case header count and size of request or response.
+// All header modifications in the VCL are counted regardless of the path
+// because any of them may be executed in the same request.
+// Unset headers are subtracted, assuming that the code is executed in order of the declaration.
+type headerBudget struct {
+	sizes         map[string]int   // header name -> max size of the header line
+	adds          map[string][]int // header name -> sizes of header lines appended by add statements
+	size          int
+	countReported bool
+	sizeReported  bool
+}
+
+func (b *headerBudget) count() int {
+	count := len(b.sizes)
+	for _, lines := range b.adds {
+		count += len(lines)
+	}
+	return count
+}
+
+// remove subtracts the header from the budget, name may end with wildcard
+func (b *headerBudget) remove(name string) {
+	prefix, wildcard := strings.CutSuffix(name, "*")
+	match := func(key string) bool {
+		return key == name || (wildcard && strings.HasPrefix(key, prefix))
+	}
+	for key, line := range b.sizes {
+		if match(key) {
+			b.size -= line
+			delete(b.sizes, key)
+		}
+	}
+	for key, lines := range b.adds {
+		if match(key) {
+			for _, line := range lines {
+				b.size -= line
+			}
+			delete(b.adds, key)
+		}
+	}
+}
+
+// headerGroup returns the budget group and header name of the variable.
+// Request headers are copied to the backend request, and response headers are copied
+// from the backend response or cached object, so they share the budget.
+func headerGroup(name string) (string, string) {
+	lower := strings.ToLower(name)
+	for _, prefix := range []string{"req.http.", "bereq.http."} {
+		if strings.HasPrefix(lower, prefix) {
+			return headerGroupRequest, stripHeaderSubField(strings.TrimPrefix(lower, prefix))
+		}
+	}
+	for _, prefix := range []string{"beresp.http.", "obj.http.", "resp.http."} {
+		if strings.HasPrefix(lower, prefix) {
+			return headerGroupResponse, stripHeaderSubField(strings.TrimPrefix(lower, prefix))
+		}
+	}
+	return "", ""
+}
+
+func stripHeaderSubField(name string) string {
+	if idx := strings.Index(name, ":"); idx != -1 {
+		return name[:idx]
+	}
+	return name
+}
+
+// staticStringLength returns the lower bound of the string length of the expression.
+// Only string literals are counted so dynamic values are treated as empty.
+func staticStringLength(expr ast.Expression) int {
+	switch e := expr.(type) {
+	case *ast.String:
+		return len(e.Value)
+	case *ast.GroupedExpression:
+		return staticStringLength(e.Right)
+	case *ast.InfixExpression:
+		if e.Operator == "+" {
+			return staticStringLength(e.Left) + staticStringLength(e.Right)
+		}
+	}
+	return 0
+}
+
+// lintHeaderLimitation checks the set or add statement against header count and size limits
+func (l *Linter) lintHeaderLimitation(ident *ast.Ident, value ast.Expression, isAdd bool) {
+	group, name := headerGroup(ident.Value)
+	if group == "" {
+		return
+	}
+
+	maxCount, maxSize := limits.MaxRequestHeaderCount, limits.MaxRequestHeaderSize
+	if group == headerGroupResponse {
+		maxCount, maxSize = limits.MaxResponseHeaderCount, limits.MaxResponseHeaderSize
+	}
+
+	// "Name: value\n" is counted as the header size like interpreter does
+	line := len(name) + 3 + staticStringLength(value)
+	if line > maxSize {
+		l.Error(LiteralSizeOverflow(value.GetMeta(), ident.Value, line, maxSize).Match(LIMITATION_LITERAL_SIZE))
+		return
+	}
+
+	b := l.headerBudget(group)
+	if isAdd {
+		b.adds[name] = append(b.adds[name], line)
+		b.size += line
+	} else if prev, ok := b.sizes[name]; !ok || prev < line {
+		b.sizes[name] = line
+		b.size += line - prev
+	}
+
+	if count := b.count(); count > maxCount && !b.countReported {
+		b.countReported = true
+		l.Error(HeaderCountBudget(ident.GetMeta(), group, count, maxCount).Match(LIMITATION_HEADER_BUDGET))
+	}
+	if b.size > maxSize && !b.sizeReported {
+		b.sizeReported = true
+		l.Error(HeaderSizeBudget(ident.GetMeta(), group, b.size, maxSize).Match(LIMITATION_HEADER_BUDGET))
+	}
+}
+
+// lintHeaderUnset subtracts the unset header from the budget
+func (l *Linter) lintHeaderUnset(ident string) {
+	// Unsetting a subfield keeps the header
+	if strings.Contains(ident, ":") {
+		return
+	}
+	if group, name := headerGroup(ident); group != "" {
+		l.headerBudget(group).remove(name)
+	}
+}
+
+func (l *Linter) headerBudget(group string) *headerBudget {
+	if l.headerBudgets == nil {
+		l.headerBudgets = make(map[string]*headerBudget)
+	}
+	b, ok := l.headerBudgets[group]
+	if !ok {
+		b = &headerBudget{
+			sizes: make(map[string]int),
+			adds:  make(map[string][]int),
+		}
+		l.headerBudgets[group] = b
+	}
+	return b
+}
+
+// lintURLLimitation checks the URL literal does not exceed the limit
+func (l *Linter) lintURLLimitation(ident *ast.Ident, value ast.Expression) {
+	switch strings.ToLower(ident.Value) {
+	case "req.url", "bereq.url":
+		if size := staticStringLength(value); size > limits.MaxURLSize {
+			l.Error(LiteralSizeOverflow(value.GetMeta(), ident.Value, size, limits.MaxURLSize).Match(LIMITATION_LITERAL_SIZE))
+		}
+	}
+}
+
+// lintLiteralSizeLimitation checks the literal of log or synthetic statement does not exceed the limit
+func (l *Linter) lintLiteralSizeLimitation(value ast.Expression, name string, limit int) {
+	if size := staticStringLength(value); size > limit {
+		l.Error(LiteralSizeOverflow(value.GetMeta(), name, size, limit).Match(LIMITATION_LITERAL_SIZE))
+	}
+}
+
+// lintProtectedHeaderFunction checks header functions do not modify protected headers.
+// Header name is checked only when it is specified as a string literal.
+func (l *Linter) lintProtectedHeaderFunction(name string, args []ast.Expression) {
+	var names []ast.Expression
+	switch name {
+	case "header.set", "header.unset":
+		if len(args) > 1 {
+			names = args[1:2]
+		}
+	case "header.filter":
+		if len(args) > 1 {
+			names = args[1:]
+		}
+	default:
+		return
+	}
+
+	for _, arg := range names {
+		s, ok := arg.(*ast.String)
+		if !ok || !limits.IsProtectedHeader(s.Value) {
+			continue
+		}
+		l.Error(ProtectedHTTPHeader(s.GetMeta(), s.Value).Match(LIMITATION_PROTECTED_HEADER))
+	}
+}
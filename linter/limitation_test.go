@@ -0,0 +1,172 @@
+package linter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+)
+
+func TestLimitations(t *testing.T) {
+	type report struct {
+		Rule Rule
+		Line int
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		expect []report
+	}{
+		{
+			name: "protected headers",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Content-Length = "0";
+  header.set(req, "Transfer-Encoding", "chunked");
+  header.unset(req, "X-Foo");
+  header.filter(req, "X-Foo", "Upgrade");
+  unset req.http.TE;
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: LIMITATION_PROTECTED_HEADER, Line: 4},
+				{Rule: LIMITATION_PROTECTED_HEADER, Line: 5},
+				{Rule: LIMITATION_PROTECTED_HEADER, Line: 7},
+				{Rule: LIMITATION_PROTECTED_HEADER, Line: 8},
+			},
+		},
+		{
+			name: "literal sizes",
+			input: fmt.Sprintf(`
+sub vcl_recv {
+  #FASTLY RECV
+  set req.url = "/%[1]s";
+  set req.http.X-Large = "%[2]s" + "%[2]s";
+  set req.http.X-Dynamic = req.url "%[3]s";
+  log "%[3]s";
+  return (lookup);
+}
+
+sub vcl_error {
+  #FASTLY ERROR
+  synthetic "%[2]s";
+  return (deliver);
+}`,
+				strings.Repeat("a", 8*1024),
+				strings.Repeat("b", 35*1024),
+				strings.Repeat("c", 16*1024+1),
+			),
+			expect: []report{
+				{Rule: LIMITATION_LITERAL_SIZE, Line: 4},
+				{Rule: LIMITATION_LITERAL_SIZE, Line: 5},
+				{Rule: LIMITATION_LITERAL_SIZE, Line: 7},
+			},
+		},
+		{
+			name: "synthetic body",
+			input: fmt.Sprintf(`
+sub vcl_error {
+  #FASTLY ERROR
+  synthetic "%s";
+  return (deliver);
+}`, strings.Repeat("a", 64*1024+1)),
+			expect: []report{
+				{Rule: LIMITATION_LITERAL_SIZE, Line: 4},
+			},
+		},
+		{
+			name: "request header count across request and backend request",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+` + setNamedHeaders("req", "X-Header", 90) + `  return (lookup);
+}
+
+sub vcl_miss {
+  #FASTLY MISS
+` + setNamedHeaders("bereq", "X-Header", 90) + `  add bereq.http.X-Add = "1";
+  add bereq.http.X-Add = "2";
+  set bereq.http.X-Miss-1 = "1";
+  set bereq.http.X-Miss-2 = "2";
+  set bereq.http.X-Miss-3 = "3";
+  set bereq.http.X-Miss-4 = "4";
+  set bereq.http.X-Miss-5 = "5";
+  return (fetch);
+}`,
+			expect: []report{
+				{Rule: LIMITATION_HEADER_BUDGET, Line: 195},
+			},
+		},
+		{
+			name: "unset headers are subtracted from the budget",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+` + setNamedHeaders("req", "X-Header", 90) + `  unset req.http.X-Header-1*;
+  unset req.http.X-Header-2:foo;
+  remove req.http.X-Header-3;
+  return (lookup);
+}
+
+sub vcl_miss {
+  #FASTLY MISS
+` + setNamedHeaders("bereq", "X-Miss", 18) + `  add bereq.http.X-Add = "1";
+  return (fetch);
+}`,
+			expect: []report{
+				{Rule: LIMITATION_HEADER_BUDGET, Line: 120},
+			},
+		},
+		{
+			name: "response header size",
+			input: fmt.Sprintf(`
+sub vcl_deliver {
+  #FASTLY DELIVER
+  set resp.http.X-A = "%[1]s";
+  set resp.http.X-A = "%[1]s";
+  set resp.http.X-B = "%[1]s";
+  return (deliver);
+}`, strings.Repeat("a", 35*1024)),
+			expect: []report{
+				{Rule: LIMITATION_HEADER_BUDGET, Line: 6},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			l := New(testConfig)
+			l.Lint(vcl, context.New())
+
+			var reports []report
+			for _, le := range l.Errors {
+				switch le.Rule {
+				case LIMITATION_PROTECTED_HEADER, LIMITATION_LITERAL_SIZE, LIMITATION_HEADER_BUDGET:
+					reports = append(reports, report{Rule: le.Rule, Line: le.Token.Line})
+				}
+			}
+			if diff := cmp.Diff(tt.expect, reports); diff != "" {
+				t.Errorf("Reports mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
+
+// setNamedHeaders makes set statements for n distinct headers which have the name prefix
+func setNamedHeaders(prefix, name string, n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "  set %s.http.%s-%d = \"%d\";\n", prefix, name, i, i)
+	}
+	return b.String()
+}
@@ -19,15 +19,22 @@ type Linter struct {
 	Errors     []*LintError
 	FatalError *FatalError
 	lexers     map[string]*lexer.Lexer
+	modules    map[string]*ast.VCL
 	ignore     *ignore
 	conf       *config.LinterConfig
+
+	headerBudgets map[string]*headerBudget
+	// Subroutines which are called from the statements included in the subroutine body,
+	// the call graph is built before includes are resolved so they are recorded on linting
+	includedCallees callGraph
 }
 
 func New(c *config.LinterConfig, opts ...optionFunc) *Linter {
 	l := &Linter{
-		lexers: make(map[string]*lexer.Lexer),
-		ignore: &ignore{},
-		conf:   c,
+		lexers:  make(map[string]*lexer.Lexer),
+		modules: make(map[string]*ast.VCL),
+		ignore:  &ignore{},
+		conf:    c,
 	}
 	for i := range opts {
 		opts[i](l)
@@ -39,6 +46,11 @@ func (l *Linter) Lexers() map[string]*lexer.Lexer {
 	return l.lexers
 }
 
+// Modules returns parsed ASTs of included modules keyed by the module name
+func (l *Linter) Modules() map[string]*ast.VCL {
+	return l.modules
+}
+
 func (l *Linter) Error(err error) {
 	if le, ok := err.(*LintError); ok {
 		if !l.ignore.IsEnable(le.Rule) {
@@ -130,7 +142,7 @@ func (l *Linter) lintUnusedSubroutines(ctx *context.Context) {
 			continue
 		}
 		// Or, subroutine is ignored to lint, skip it
-		if isIgnoredSubroutineInConfig(l.conf.IgnoreSubroutines, s.Decl.Name.Value) {
+		if isIgnoredSubroutineInConfig(l.conf.ForFile(s.Decl.Token.File).IgnoreSubroutines, s.Decl.Name.Value) {
 			continue
 		}
 		l.Error(UnusedDeclaration(s.Decl.GetMeta(), s.Decl.Name.Value, "subroutine").Match(UNUSED_DECLARATION))
@@ -155,7 +167,7 @@ func (l *Linter) lintUnusedRatecounters(ctx *context.Context) {
 	}
 }
 
-func (l *Linter) lintUnusedVariables(ctx *context.Context) {
+func (l *Linter) lintUnusedVariables(ctx *context.Context, decl *ast.SubroutineDeclaration) {
 	v, ok := ctx.Variables["var"]
 	if !ok {
 		return
@@ -165,7 +177,11 @@ func (l *Linter) lintUnusedVariables(ctx *context.Context) {
 		if o.IsUsed {
 			continue
 		}
-		l.Error(UnusedVariable(o.Meta, k).Match(UNUSED_VARIABLE))
+		err := UnusedVariable(o.Meta, k).Match(UNUSED_VARIABLE)
+		if fix := unusedVariableFix(decl, o.Meta); fix != nil {
+			err.WithFix(fix)
+		}
+		l.Error(err)
 	}
 }
 
@@ -322,6 +338,12 @@ func (l *Linter) lintVCL(vcl *ast.VCL, ctx *context.Context) types.Type {
 		l.lintStatement(s, ctx)
 	}
 
+	// Dataflow analysis needs all subroutines so run after linting each statement
+	l.lintPossiblyNotSetValues(statements)
+
+	// Reachability needs all call statements are linted to know which subroutines are used
+	l.lintUnreachableSubroutines(graph, ctx)
+
 	return types.NeverType
 }
 
@@ -364,31 +386,46 @@ func (l *Linter) lintStatement(s ast.Statement, ctx *context.Context) {
 func (l *Linter) loadSnippetVCL(file, content string) []ast.Statement {
 	lx := lexer.NewFromString(content, lexer.WithFile(file))
 	l.lexers[file] = lx
-	statements, err := parser.New(lx).ParseSnippetVCL()
+	p := parser.New(lx)
+	statements, err := p.ParseSnippetVCL()
 	if err != nil {
 		lx.NewLine()
-		l.FatalError = &FatalError{
-			Lexer: lx,
-			Error: errors.Cause(err),
-		}
-		return []ast.Statement{}
+		l.fatal(lx, err, p.Errors())
+	} else {
+		l.modules[file] = &ast.VCL{Statements: statements, IsSnippet: true}
 	}
+	// Keep linting statements which are parsed successfully even if syntax error exists
 	return statements
 }
 
 func (l *Linter) loadVCL(file, content string) []ast.Statement {
 	lx := lexer.NewFromString(content, lexer.WithFile(file))
 	l.lexers[file] = lx
-	vcl, err := parser.New(lx).ParseVCL()
+	p := parser.New(lx)
+	vcl, err := p.ParseVCL()
 	if err != nil {
 		lx.NewLine()
+		l.fatal(lx, err, p.Errors())
+		if vcl == nil {
+			return []ast.Statement{}
+		}
+		// Keep linting statements which are parsed successfully
+		return vcl.Statements
+	}
+	l.modules[file] = vcl
+	return vcl.Statements
+}
+
+// fatal records the parse error of included module.
+// The first error is kept as FatalError and syntax errors of all modules are accumulated.
+func (l *Linter) fatal(lx *lexer.Lexer, err error, parseErrors []*parser.ParseError) {
+	if l.FatalError == nil {
 		l.FatalError = &FatalError{
 			Lexer: lx,
 			Error: errors.Cause(err),
 		}
-		return []ast.Statement{}
 	}
-	return vcl.Statements
+	l.FatalError.ParseErrors = append(l.FatalError.ParseErrors, parseErrors...)
 }
 
 func (l *Linter) resolveIncludeStatements(statements []ast.Statement, ctx *context.Context, isRoot bool) []ast.Statement {
@@ -88,6 +88,29 @@ func assertErrorWithSeverity(t *testing.T, input string, severity Severity, opts
 	}
 }
 
+// assertOnlyRule asserts that all lint errors are reported by the rule
+func assertOnlyRule(t *testing.T, input string, rule Rule, opts ...context.Option) {
+	vcl, err := parser.New(lexer.NewFromString(input)).ParseVCL()
+	if err != nil {
+		t.Errorf("unexpected parser error: %s", err)
+		t.FailNow()
+	}
+
+	l := New(testConfig)
+	l.lint(vcl, context.New(opts...))
+	if len(l.Errors) == 0 {
+		t.Errorf("Expect %s lint error but empty returned", rule)
+	}
+	for _, le := range l.Errors {
+		if le.Rule != rule {
+			t.Errorf("Lint error: %s", le)
+		}
+	}
+	if l.FatalError != nil {
+		t.Errorf("Fatal error: %s", l.FatalError.Error)
+	}
+}
+
 func TestLintStuff(t *testing.T) {
 
 	tests := []struct {
@@ -872,6 +895,53 @@ sub ignored_subroutine {
 	})
 }
 
+func TestLinterConfigOverrides(t *testing.T) {
+	c := &config.LinterConfig{
+		Overrides: []*config.LinterOverride{
+			{
+				Files:                   []string{"/repo/vendor/**"},
+				EnforceSubroutineScopes: map[string][]string{"vendor_subroutine": {"pass"}},
+				IgnoreSubroutines:       []string{"vendor_ignored"},
+			},
+		},
+	}
+	input := `
+sub vendor_subroutine {
+	set bereq.method = "POST";
+}
+
+sub vendor_ignored {
+	set bereq.method = 1;
+}
+
+sub vcl_recv {
+	#FASTLY RECV
+	call vendor_subroutine;
+	return (lookup);
+}
+`
+	tests := []struct {
+		file   string
+		errors int
+	}{
+		{file: "/repo/vendor/fastly/module.vcl", errors: 0},
+		// vendor_subroutine is inferred as RECV scope, and vendor_ignored is linted and unused
+		{file: "/repo/main.vcl", errors: 6},
+	}
+
+	for _, tt := range tests {
+		vcl, err := parser.New(lexer.NewFromString(input, lexer.WithFile(tt.file))).ParseVCL()
+		if err != nil {
+			t.Fatalf("unexpected parser error: %s", err)
+		}
+		l := New(c)
+		l.Lint(vcl, context.New())
+		if len(l.Errors) != tt.errors {
+			t.Errorf("%s: expects %d errors but got %d: %s", tt.file, tt.errors, len(l.Errors), l.Errors)
+		}
+	}
+}
+
 func TestForbidVclPipeSubroutine(t *testing.T) {
 	input := `
 sub vcl_pipe {}
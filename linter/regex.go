@@ -1,11 +1,48 @@
 package linter
 
 import (
-	regexp "go.elara.ws/pcre"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/regex"
+	pcre "go.elara.ws/pcre"
 )
 
 // validateRegex checks if a regex pattern is valid using PCRE.
 func validateRegex(pattern string) error {
-	_, err := regexp.Compile(pattern)
+	_, err := pcre.Compile(pattern)
 	return err
 }
+
+// lintRegexPattern checks the regex literal is valid and behaves the same on Fastly
+func (l *Linter) lintRegexPattern(pattern *ast.String) {
+	if err := validateRegex(pattern.Value); err != nil {
+		l.Error(&LintError{
+			Severity: ERROR,
+			Token:    pattern.GetMeta().Token,
+			Message:  "regex string is invalid, " + err.Error(),
+		})
+		return
+	}
+
+	for _, issue := range regex.Analyze(pattern.Value) {
+		switch issue.Kind {
+		case regex.Unsupported:
+			l.Error(RegexUnsupported(pattern.GetMeta(), issue).Match(REGEX_PCRE_COMPATIBILITY))
+		case regex.Backtracking:
+			l.Error(RegexBacktracking(pattern.GetMeta(), issue).Match(REGEX_CATASTROPHIC_BACKTRACKING))
+		}
+	}
+}
+
+// lintRegexReplacement checks \1 to \9 references in the replacement of regsub and regsuball
+// point to the capture groups of the pattern. Undefined group is replaced with the empty string.
+func (l *Linter) lintRegexReplacement(pattern, replacement *ast.String) {
+	groups := regex.CaptureGroups(pattern.Value)
+	reported := make(map[int]struct{})
+	for _, ref := range regex.ReplacementReferences(replacement.Value) {
+		if _, ok := reported[ref]; ok || ref <= groups {
+			continue
+		}
+		reported[ref] = struct{}{}
+		l.Error(UndefinedReplacementReference(replacement.GetMeta(), ref, groups).Match(REGEX_REPLACEMENT_REFERENCE))
+	}
+}
@@ -0,0 +1,102 @@
+package linter
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+)
+
+func TestLintRegexCompatibility(t *testing.T) {
+	type report struct {
+		Rule Rule
+		Line int
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		expect []report
+	}{
+		{
+			name: "compatible patterns",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "^/(?:[a-z]+/)*(foo|bar)(?=\.html)") {
+    set req.url = regsub(req.url, "^/(foo)/(bar)", "/\2/\1");
+    set req.url = querystring.regfilter(req.url, "^utm_");
+  }
+  return (lookup);
+}`,
+		},
+		{
+			name: "pattern is rejected on Fastly",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url ~ "(*pla:/foo)") {
+    set req.url = regsuball(req.url, "(?n)(foo)", "bar");
+  }
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: REGEX_PCRE_COMPATIBILITY, Line: 4},
+				{Rule: REGEX_PCRE_COMPATIBILITY, Line: 5},
+			},
+		},
+		{
+			name: "nested quantifiers",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.http.Cookie ~ "^(\w+\s?)*$") {
+    set req.http.X-Foo = querystring.regfilter_except(req.url, "^(a+)+$");
+  }
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: REGEX_CATASTROPHIC_BACKTRACKING, Line: 4},
+				{Rule: REGEX_CATASTROPHIC_BACKTRACKING, Line: 5},
+			},
+		},
+		{
+			name: "replacement references undefined groups",
+			input: `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.url = regsub(req.url, "^/(?:foo)/(bar)", "/\1/\2/\2/\3");
+  set req.url = regsuball(req.url, "^/foo", "\0\\1");
+  return (lookup);
+}`,
+			expect: []report{
+				{Rule: REGEX_REPLACEMENT_REFERENCE, Line: 4},
+				{Rule: REGEX_REPLACEMENT_REFERENCE, Line: 4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vcl, err := parser.New(lexer.NewFromString(tt.input)).ParseVCL()
+			if err != nil {
+				t.Fatalf("unexpected parser error: %s", err)
+			}
+			l := New(testConfig)
+			l.Lint(vcl, context.New())
+
+			var reports []report
+			for _, le := range l.Errors {
+				switch le.Rule {
+				case REGEX_PCRE_COMPATIBILITY, REGEX_CATASTROPHIC_BACKTRACKING, REGEX_REPLACEMENT_REFERENCE:
+					reports = append(reports, report{Rule: le.Rule, Line: le.Token.Line})
+				}
+			}
+			if diff := cmp.Diff(tt.expect, reports); diff != "" {
+				t.Errorf("Reports mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
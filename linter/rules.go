@@ -75,6 +75,16 @@ const (
 	UNCAPTURED_REGEX_VARIABLE            = "regex/uncaptured-variable"
 	OVERWRITE_VARY                       = "set-statement/overwrite-vary"
 	REGEX_URL_EXTENSION                  = "regex/url-extension"
+	POSSIBLY_NOTSET                      = "variable/possibly-notset"
+	UNREACHABLE_SUBROUTINE               = "unreachable/subroutine"
+	UNREACHABLE_STATEMENT                = "unreachable/statement"
+	CONSTANT_CONDITION                   = "unreachable/constant-condition"
+	LIMITATION_PROTECTED_HEADER          = "limitation/protected-header"
+	LIMITATION_LITERAL_SIZE              = "limitation/literal-size"
+	LIMITATION_HEADER_BUDGET             = "limitation/header-budget"
+	REGEX_PCRE_COMPATIBILITY             = "regex/pcre-compatibility"
+	REGEX_CATASTROPHIC_BACKTRACKING      = "regex/catastrophic-backtracking"
+	REGEX_REPLACEMENT_REFERENCE          = "regex/replacement-reference"
 )
 
 var references = map[Rule]string{
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/limits"
 	"github.com/ysugimoto/falco/v2/linter/context"
 	"github.com/ysugimoto/falco/v2/linter/types"
 )
@@ -68,12 +69,16 @@ func (l *Linter) lintBlockStatement(block *ast.BlockStatement, ctx *context.Cont
 	defer l.ignore.TeardownBlockStatement(block.GetMeta())
 
 	statements := l.resolveIncludeStatements(block.Statements, ctx, false)
+	l.recordIncludedCallees(block.Statements, statements, ctx)
+	var unreachable unreachableStatementChecker
 	for _, stmt := range statements {
 		func(v ast.Statement, c *context.Context) {
 			l.ignore.SetupStatement(v.GetMeta())
 			defer l.ignore.TeardownStatement(v.GetMeta())
+			unreachable.check(l, v)
 			l.lint(v, c)
 		}(stmt, ctx)
+		unreachable.next(stmt)
 	}
 
 	return types.NeverType
@@ -141,9 +146,13 @@ func (l *Linter) lintSetStatement(stmt *ast.SetStatement, ctx *context.Context)
 
 	// Check protected header will be modified
 	if isProtectedHTTPHeaderName(stmt.Ident.Value) {
-		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value))
+		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value).Match(LIMITATION_PROTECTED_HEADER))
 	}
 
+	// Check literal value could not exceed Fastly limits
+	l.lintHeaderLimitation(stmt.Ident, stmt.Value, false)
+	l.lintURLLimitation(stmt.Ident, stmt.Value)
+
 	// Warn when overwriting Vary header entirely — the origin may have set
 	// important Vary values that would be discarded.
 	if stmt.Operator.Operator == "=" && isVaryHeader(stmt.Ident.Value) {
@@ -151,7 +160,12 @@ func (l *Linter) lintSetStatement(stmt *ast.SetStatement, ctx *context.Context)
 		if s, ok := stmt.Value.(*ast.String); ok {
 			subfield = strings.TrimSpace(s.Value)
 		}
-		l.Error(OverwriteVary(stmt.Ident.GetMeta(), stmt.Ident.Value, subfield).Match(OVERWRITE_VARY))
+		err := OverwriteVary(stmt.Ident.GetMeta(), stmt.Ident.Value, subfield).Match(OVERWRITE_VARY)
+		// Only single value could be replaced with the subfield mechanically
+		if subfield != "" && !strings.ContainsAny(subfield, ", \t") {
+			err.WithFix(overwriteVaryFix(stmt, subfield))
+		}
+		l.Error(err)
 	}
 
 	left, err := ctx.Set(stmt.Ident.Value)
@@ -206,9 +220,11 @@ func (l *Linter) lintUnsetStatement(stmt *ast.UnsetStatement, ctx *context.Conte
 
 	// Check protected header will be modified
 	if isProtectedHTTPHeaderName(stmt.Ident.Value) {
-		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value))
+		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value).Match(LIMITATION_PROTECTED_HEADER))
 	}
 
+	l.lintHeaderUnset(stmt.Ident.Value)
+
 	if err := ctx.Unset(stmt.Ident.Value); err != nil {
 		l.Error(&LintError{
 			Severity: ERROR,
@@ -227,9 +243,11 @@ func (l *Linter) lintRemoveStatement(stmt *ast.RemoveStatement, ctx *context.Con
 
 	// Check protected header will be modified
 	if isProtectedHTTPHeaderName(stmt.Ident.Value) {
-		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value))
+		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value).Match(LIMITATION_PROTECTED_HEADER))
 	}
 
+	l.lintHeaderUnset(stmt.Ident.Value)
+
 	if err := ctx.Unset(stmt.Ident.Value); err != nil {
 		l.Error(&LintError{
 			Severity: ERROR,
@@ -258,6 +276,8 @@ func (l *Linter) lintIfStatement(stmt *ast.IfStatement, ctx *context.Context) ty
 }
 
 func (l *Linter) lintIfCondition(cond ast.Expression, ctx *context.Context) {
+	l.lintConstantCondition(cond)
+
 	// Note: if condtion expression accepts STRING or BOOL (evaluate as truthy/falsy), but forbid to use literal.
 	//
 	// For example:
@@ -310,12 +330,15 @@ func (l *Linter) lintSwitchStatement(stmt *ast.SwitchStatement, ctx *context.Con
 	}
 
 	for _, c := range stmt.Cases {
+		var unreachable unreachableStatementChecker
 		for _, s := range c.Statements {
 			switch s.(type) {
 			case *ast.BreakStatement, *ast.FallthroughStatement:
 				break // parser already made sure break/fallthrough is at the end.
 			default:
+				unreachable.check(l, s)
 				l.lint(s, ctx)
+				unreachable.next(s)
 			}
 		}
 	}
@@ -349,8 +372,9 @@ func (l *Linter) lintAddStatement(stmt *ast.AddStatement, ctx *context.Context)
 
 	// Check protected header will be modified
 	if isProtectedHTTPHeaderName(stmt.Ident.Value) {
-		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value))
+		l.Error(ProtectedHTTPHeader(stmt.Ident.GetMeta(), stmt.Ident.Value).Match(LIMITATION_PROTECTED_HEADER))
 	}
+	l.lintHeaderLimitation(stmt.Ident, stmt.Value, true)
 
 	// Add statement could use only for HTTP headers.
 	// https://developer.fastly.com/reference/vcl/statements/add/
@@ -488,6 +512,8 @@ func (l *Linter) lintErrorStatement(stmt *ast.ErrorStatement, ctx *context.Conte
 }
 
 func (l *Linter) lintLogStatement(stmt *ast.LogStatement, ctx *context.Context) types.Type {
+	l.lintLiteralSizeLimitation(stmt.Value, "log", limits.MaxLogLineSize)
+
 	if isTypeLiteral(stmt.Value) {
 		switch stmt.Value.(type) {
 		case *ast.String:
@@ -600,6 +626,9 @@ func (l *Linter) lintReturnStatement(stmt *ast.ReturnStatement, ctx *context.Con
 				Token:    stmt.GetMeta().Token,
 				Message:  "Empty return is disallowed in state-machine method",
 			}
+			if state, ok := defaultReturnStates[ctx.Mode()]; ok {
+				err.WithFix(emptyReturnFix(stmt, state))
+			}
 			l.Error(err.Match(DISALLOW_EMPTY_RETURN))
 		}
 		return types.NeverType
@@ -624,6 +653,7 @@ func (l *Linter) lintSyntheticStatement(stmt *ast.SyntheticStatement, ctx *conte
 		l.Error(err.Match(SYNTHETIC_STATEMENT_SCOPE))
 	}
 
+	l.lintLiteralSizeLimitation(stmt.Value, "synthetic", limits.MaxSyntheticResponseSize)
 	l.lint(stmt.Value, ctx)
 	return types.NeverType
 }
@@ -729,6 +759,8 @@ func (l *Linter) lintFunctionCallStatement(exp *ast.FunctionCallStatement, ctx *
 		return l.lintTestingCallSubroutine(exp.Function, exp.Arguments, ctx)
 	}
 
+	l.lintProtectedHeaderFunction(exp.Function.Value, exp.Arguments)
+
 	return l.lintFunctionArguments(fn, functionMeta{
 		name:      exp.Function.Value,
 		token:     exp.Function.GetMeta().Token,
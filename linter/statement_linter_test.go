@@ -559,7 +559,8 @@ func TestLintGotoStatement(t *testing.T) {
 	}
 	`
 
-		assertNoError(t, input)
+		// Goto is valid, but the statement between goto and the destination is never executed
+		assertOnlyRule(t, input, UNREACHABLE_STATEMENT)
 	})
 
 	t.Run("only one destination is allowed", func(t *testing.T) {
@@ -0,0 +1,413 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/linter"
+	lcontext "github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/parser"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// Kinds of the user defined symbols
+const (
+	symbolAcl         = "acl"
+	symbolBackend     = "backend"
+	symbolDirector    = "director"
+	symbolTable       = "table"
+	symbolSubroutine  = "sub"
+	symbolPenaltybox  = "penaltybox"
+	symbolRatecounter = "ratecounter"
+	symbolLocal       = "local"
+)
+
+// symbol is the user defined declaration
+type symbol struct {
+	name   string
+	kind   string
+	detail string
+	token  token.Token // name token of the declaration
+
+	// Local variable is visible only in the subroutine lines
+	startLine int
+	endLine   int
+}
+
+func (s *symbol) visibleAt(file string, line int) bool {
+	if s.kind != symbolLocal {
+		return true
+	}
+	return s.token.File == file && line >= s.startLine && line <= s.endLine
+}
+
+// analysis is the result of linting the root document with its included files
+type analysis struct {
+	root        string
+	sources     map[string]string
+	tokens      map[string][]token.Token
+	diagnostics map[string][]Diagnostic
+	symbols     []*symbol
+}
+
+// documentResolver resolves included modules from the opened documents first,
+// and fall back to the file system like resolver.FileResolver does
+type documentResolver struct {
+	main         *resolver.VCL
+	includePaths []string
+	documents    map[string]string
+	loaded       map[string]string
+}
+
+func (r *documentResolver) MainVCL() (*resolver.VCL, error) {
+	return r.main, nil
+}
+
+func (r *documentResolver) Resolve(stmt *ast.IncludeStatement) (*resolver.VCL, error) {
+	module := stmt.Module.Value
+	if !strings.HasSuffix(module, ".vcl") {
+		module += ".vcl"
+	}
+	for _, p := range r.includePaths {
+		file := filepath.Join(p, module)
+		data, ok := r.documents[file]
+		if !ok {
+			buf, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			data = string(buf)
+		}
+		r.loaded[file] = data
+		return &resolver.VCL{Name: file, Data: data}, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Failed to resolve include file: %s", module))
+}
+
+func (r *documentResolver) Name() string {
+	return ""
+}
+
+func (r *documentResolver) IncludePaths() []string {
+	return r.includePaths
+}
+
+// analyze lints the root document and collects tokens and symbols of all related files
+func analyze(c *config.Config, root string, documents map[string]string) *analysis {
+	var includePaths []string
+	for _, p := range c.IncludePaths {
+		if abs, err := filepath.Abs(p); err == nil {
+			includePaths = append(includePaths, abs)
+		}
+	}
+	rslv := &documentResolver{
+		main:         &resolver.VCL{Name: root, Data: documents[root]},
+		includePaths: append(includePaths, filepath.Dir(root)),
+		documents:    documents,
+		loaded:       map[string]string{root: documents[root]},
+	}
+
+	a := &analysis{
+		root:        root,
+		sources:     rslv.loaded,
+		tokens:      make(map[string][]token.Token),
+		diagnostics: map[string][]Diagnostic{root: {}},
+	}
+
+	// Parser recovers from syntax errors, lint the partial AST as well
+	p := parser.New(lexer.NewFromString(documents[root], lexer.WithFile(root)))
+	vcl, _ := p.ParseVCLOrSnippet()
+	for _, pe := range p.Errors() {
+		a.addParseError(root, pe)
+	}
+	if vcl != nil {
+		lt := linter.New(c.Linter)
+		lt.Lint(vcl, lcontext.New(lcontext.WithResolver(rslv)))
+		if lt.FatalError != nil {
+			for _, pe := range lt.FatalError.ParseErrors {
+				a.addParseError(root, pe)
+			}
+		}
+		overrides := make(map[string]map[string]linter.Severity)
+		for _, le := range lt.Errors {
+			// Rule severities may be overridden for each file
+			if _, ok := overrides[le.Token.File]; !ok {
+				overrides[le.Token.File] = severityOverrides(c.Linter.ForFile(le.Token.File))
+			}
+			a.addLintError(root, le, overrides[le.Token.File])
+		}
+	}
+
+	for file, src := range a.sources {
+		a.tokens[file] = tokenize(file, src)
+		a.symbols = append(a.symbols, collectSymbols(file, src)...)
+		if _, ok := a.diagnostics[file]; !ok {
+			a.diagnostics[file] = []Diagnostic{}
+		}
+	}
+	return a
+}
+
+func (a *analysis) addParseError(root string, pe *parser.ParseError) {
+	file := pe.Token.File
+	if file == "" {
+		file = root
+	}
+	a.diagnostics[file] = append(a.diagnostics[file], Diagnostic{
+		Range:    a.tokenRange(pe.Token),
+		Severity: severityError,
+		Source:   "falco",
+		Message:  pe.Message,
+	})
+}
+
+func (a *analysis) addLintError(root string, le *linter.LintError, overrides map[string]linter.Severity) {
+	severity := le.Severity
+	if v, ok := overrides[string(le.Rule)]; ok {
+		severity = v
+	}
+	d := Diagnostic{
+		Range:   a.tokenRange(le.Token),
+		Code:    string(le.Rule),
+		Source:  "falco",
+		Message: le.Message,
+	}
+	switch severity {
+	case linter.IGNORE:
+		return
+	case linter.ERROR:
+		d.Severity = severityError
+	case linter.WARNING:
+		d.Severity = severityWarning
+	default:
+		d.Severity = severityInformation
+	}
+	if le.Reference != "" {
+		d.Message += "\nSee reference documentation: " + le.Reference
+	}
+
+	// Errors for external declarations do not have the token, report it on the root document
+	file := le.Token.File
+	if file == "" {
+		file = root
+	}
+	a.diagnostics[file] = append(a.diagnostics[file], d)
+}
+
+// Override linter rule severities like the lint command does
+func severityOverrides(c *config.LinterConfig) map[string]linter.Severity {
+	overrides := make(map[string]linter.Severity)
+	for key, value := range c.Rules {
+		switch strings.ToUpper(value) {
+		case "ERROR":
+			overrides[key] = linter.ERROR
+		case "WARNING":
+			overrides[key] = linter.WARNING
+		case "INFO":
+			overrides[key] = linter.INFO
+		case "IGNORE":
+			overrides[key] = linter.IGNORE
+		}
+	}
+	return overrides
+}
+
+func tokenize(file, src string) []token.Token {
+	var tokens []token.Token
+	lx := lexer.NewFromString(src, lexer.WithFile(file))
+	for {
+		tok := lx.NextToken()
+		if tok.Type == token.EOF {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func collectSymbols(file, src string) []*symbol {
+	// Symbols are collected from the partial AST even if syntax errors exist
+	vcl, _ := parser.New(lexer.NewFromString(src, lexer.WithFile(file))).ParseVCLOrSnippet()
+	if vcl == nil {
+		return nil
+	}
+
+	var symbols []*symbol
+	add := func(kind string, name *ast.Ident, detail string) {
+		symbols = append(symbols, &symbol{
+			name:   name.Value,
+			kind:   kind,
+			detail: detail,
+			token:  name.GetMeta().Token,
+		})
+	}
+	for _, stmt := range vcl.Statements {
+		switch t := stmt.(type) {
+		case *ast.AclDeclaration:
+			add(symbolAcl, t.Name, "acl "+t.Name.Value)
+		case *ast.BackendDeclaration:
+			add(symbolBackend, t.Name, "backend "+t.Name.Value)
+		case *ast.DirectorDeclaration:
+			add(symbolDirector, t.Name, "director "+t.Name.Value+" "+t.DirectorType.Value)
+		case *ast.TableDeclaration:
+			detail := "table " + t.Name.Value
+			if t.ValueType != nil {
+				detail += " " + t.ValueType.Value
+			}
+			add(symbolTable, t.Name, detail)
+		case *ast.PenaltyboxDeclaration:
+			add(symbolPenaltybox, t.Name, "penaltybox "+t.Name.Value)
+		case *ast.RatecounterDeclaration:
+			add(symbolRatecounter, t.Name, "ratecounter "+t.Name.Value)
+		case *ast.SubroutineDeclaration:
+			detail := "sub " + t.Name.Value
+			if t.ReturnType != nil {
+				detail += " " + t.ReturnType.Value
+			}
+			add(symbolSubroutine, t.Name, detail)
+			for _, d := range collectLocalDeclarations(t.Block.Statements) {
+				symbols = append(symbols, &symbol{
+					name:      d.Name.Value,
+					kind:      symbolLocal,
+					detail:    "declare local " + d.Name.Value + " " + d.ValueType.Value,
+					token:     d.Name.GetMeta().Token,
+					startLine: t.GetMeta().Token.Line,
+					endLine:   t.GetMeta().EndLine,
+				})
+			}
+		}
+	}
+	return symbols
+}
+
+func collectLocalDeclarations(statements []ast.Statement) []*ast.DeclareStatement {
+	var declarations []*ast.DeclareStatement
+	for _, stmt := range statements {
+		switch t := stmt.(type) {
+		case *ast.DeclareStatement:
+			declarations = append(declarations, t)
+		case *ast.BlockStatement:
+			declarations = append(declarations, collectLocalDeclarations(t.Statements)...)
+		case *ast.IfStatement:
+			declarations = append(declarations, collectLocalDeclarations(t.Consequence.Statements)...)
+			for _, another := range t.Another {
+				declarations = append(declarations, collectLocalDeclarations(another.Consequence.Statements)...)
+			}
+			if t.Alternative != nil {
+				declarations = append(declarations, collectLocalDeclarations(t.Alternative.Consequence.Statements)...)
+			}
+		case *ast.SwitchStatement:
+			for _, c := range t.Cases {
+				declarations = append(declarations, collectLocalDeclarations(c.Statements)...)
+			}
+		}
+	}
+	return declarations
+}
+
+// tokenAt finds the token which covers the position in the file
+func (a *analysis) tokenAt(file string, pos Position) (token.Token, bool) {
+	column := runeOffset(a.line(file, pos.Line), pos.Character)
+	for _, tok := range a.tokens[file] {
+		if tok.Line-1 != pos.Line {
+			continue
+		}
+		start := tok.Position - 1
+		if column >= start && column <= start+utf8.RuneCountInString(tok.Literal) {
+			return tok, true
+		}
+	}
+	return token.Null, false
+}
+
+// line returns the zero-based line of the file as runes
+func (a *analysis) line(file string, n int) []rune {
+	lines := strings.Split(a.sources[file], "\n")
+	if n < 0 || n >= len(lines) {
+		return nil
+	}
+	return []rune(strings.TrimRight(lines[n], "\r"))
+}
+
+// lookup finds the user defined symbol which is visible from the token
+func (a *analysis) lookup(tok token.Token) *symbol {
+	for _, s := range a.symbols {
+		if s.name == tok.Literal && s.visibleAt(tok.File, tok.Line) {
+			return s
+		}
+	}
+	return nil
+}
+
+// references finds all identifier tokens which refer to the symbol
+func (a *analysis) references(s *symbol, includeDeclaration bool) []token.Token {
+	var refs []token.Token
+	for file, tokens := range a.tokens {
+		for _, tok := range tokens {
+			if tok.Type != token.IDENT || tok.Literal != s.name || !s.visibleAt(file, tok.Line) {
+				continue
+			}
+			if !includeDeclaration && tok.Line == s.token.Line && tok.Position == s.token.Position && file == s.token.File {
+				continue
+			}
+			refs = append(refs, tok)
+		}
+	}
+	return refs
+}
+
+// tokenRange returns zero-based range of the token.
+// Token positions are counted in runes but LSP positions are counted in UTF-16 code units
+func (a *analysis) tokenRange(tok token.Token) Range {
+	line := max(tok.Line-1, 0)
+	start := max(tok.Position-1, 0)
+	length := utf8.RuneCountInString(tok.Literal)
+	if tok.Type == token.STRING {
+		length += 2 // quotes
+	}
+	runes := a.line(tok.File, line)
+	return Range{
+		Start: Position{Line: line, Character: utf16Offset(runes, start)},
+		End:   Position{Line: line, Character: utf16Offset(runes, start+max(length, 1))},
+	}
+}
+
+// utf16Offset converts the rune offset in the line to UTF-16 code units.
+// Offset beyond the line, like a multi-line token, is counted as one unit per rune
+func utf16Offset(line []rune, runes int) int {
+	var units int
+	for i := range runes {
+		if i >= len(line) {
+			return units + runes - i
+		}
+		units += utf16Len(line[i])
+	}
+	return units
+}
+
+// runeOffset converts UTF-16 code units in the line to the rune offset
+func runeOffset(line []rune, units int) int {
+	var n int
+	for i, r := range line {
+		if n >= units {
+			return i
+		}
+		n += utf16Len(r)
+	}
+	return len(line) + max(units-n, 0)
+}
+
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,285 @@
+package lsp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/formatter"
+	"github.com/ysugimoto/falco/v2/lexer"
+	lcontext "github.com/ysugimoto/falco/v2/linter/context"
+	"github.com/ysugimoto/falco/v2/linter/types"
+	"github.com/ysugimoto/falco/v2/parser"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// Symbol kinds which can be completed for each identifier
+var completionSymbolKinds = map[string]int{
+	symbolAcl:         completionKindValue,
+	symbolBackend:     completionKindValue,
+	symbolDirector:    completionKindValue,
+	symbolTable:       completionKindValue,
+	symbolSubroutine:  completionKindFunction,
+	symbolPenaltybox:  completionKindValue,
+	symbolRatecounter: completionKindValue,
+	symbolLocal:       completionKindVariable,
+}
+
+func (s *Server) formatting(params formattingParams) ([]TextEdit, error) {
+	path := uriToPath(params.TextDocument.URI)
+	src, ok := s.documents[path]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("document %s is not opened", params.TextDocument.URI))
+	}
+	vcl, err := parser.New(lexer.NewFromString(src, lexer.WithFile(path))).ParseVCLOrSnippet()
+	if err != nil {
+		return nil, errors.Cause(err)
+	}
+	// Formatter does not support statement snippets
+	if vcl.IsSnippet {
+		return []TextEdit{}, nil
+	}
+	formatted, err := io.ReadAll(formatter.New(s.config.Format).Format(vcl))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if string(formatted) == src {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{
+		{
+			Range: Range{
+				Start: Position{Line: 0, Character: 0},
+				End:   Position{Line: strings.Count(src, "\n") + 1, Character: 0},
+			},
+			NewText: string(formatted),
+		},
+	}, nil
+}
+
+func (s *Server) hover(params textDocumentPositionParams) *Hover {
+	path := uriToPath(params.TextDocument.URI)
+	a := s.analysisFor(path)
+	if a == nil {
+		return nil
+	}
+	tok, ok := a.tokenAt(path, params.Position)
+	if !ok || tok.Type != token.IDENT {
+		return nil
+	}
+
+	var contents string
+	if sym := a.lookup(tok); sym != nil {
+		contents = codeBlock(sym.detail)
+	} else if fn, ok := s.builtin.LookupFunction(tok.Literal); ok {
+		contents = describeFunction(tok.Literal, fn)
+	} else if v, ok := s.builtin.LookupVariable(tok.Literal); ok {
+		contents = describeVariable(tok.Literal, v)
+	} else {
+		return nil
+	}
+
+	r := a.tokenRange(tok)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: contents},
+		Range:    &r,
+	}
+}
+
+func codeBlock(code string) string {
+	return "```vcl\n" + code + "\n```"
+}
+
+func describeFunction(name string, fn *lcontext.BuiltinFunction) string {
+	var signatures []string
+	for _, args := range fn.Arguments {
+		names := make([]string, len(args))
+		for i := range args {
+			names[i] = args[i].String()
+		}
+		signatures = append(signatures, fmt.Sprintf("%s %s(%s)", fn.Return.String(), name, strings.Join(names, ", ")))
+	}
+	if len(signatures) == 0 {
+		signatures = append(signatures, fmt.Sprintf("%s %s()", fn.Return.String(), name))
+	}
+
+	lines := []string{
+		codeBlock(strings.Join(signatures, "\n")),
+		"Available scopes: " + strings.TrimSpace(lcontext.ScopesString(fn.Scopes)),
+	}
+	if fn.Reference != "" {
+		lines = append(lines, "See reference documentation: "+fn.Reference)
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func describeVariable(name string, v *lcontext.Accessor) string {
+	typ := v.Get
+	access := []string{}
+	if v.Get != types.NeverType {
+		access = append(access, "read")
+	}
+	if v.Set != types.NeverType {
+		typ = v.Set
+		access = append(access, "write")
+	}
+	if v.Unset {
+		access = append(access, "unset")
+	}
+
+	lines := []string{
+		codeBlock(fmt.Sprintf("%s %s", typ.String(), name)),
+		"Access: " + strings.Join(access, ", "),
+		"Available scopes: " + strings.TrimSpace(lcontext.ScopesString(v.Scopes)),
+	}
+	if v.Deprecated {
+		lines = append(lines, "**Deprecated**")
+	}
+	if v.Reference != "" {
+		lines = append(lines, "See reference documentation: "+v.Reference)
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func (s *Server) completion(params textDocumentPositionParams) []CompletionItem {
+	path := uriToPath(params.TextDocument.URI)
+	src, ok := s.documents[path]
+	if !ok {
+		return []CompletionItem{}
+	}
+	prefix, before := completionPrefix(src, params.Position)
+	edit := func(label string) *TextEdit {
+		return &TextEdit{
+			Range: Range{
+				Start: Position{Line: params.Position.Line, Character: params.Position.Character - utf16Offset([]rune(prefix), len([]rune(prefix)))},
+				End:   params.Position,
+			},
+			NewText: label,
+		}
+	}
+
+	items := []CompletionItem{}
+	seen := make(map[string]struct{})
+	add := func(label string, kind int, detail string) {
+		if _, ok := seen[label]; ok || !strings.HasPrefix(label, prefix) {
+			return
+		}
+		seen[label] = struct{}{}
+		items = append(items, CompletionItem{Label: label, Kind: kind, Detail: detail, TextEdit: edit(label)})
+	}
+
+	// User defined symbols
+	isCall := strings.HasSuffix(strings.TrimSpace(before), "call")
+	if a := s.analysisFor(path); a != nil {
+		for _, sym := range a.symbols {
+			if isCall && sym.kind != symbolSubroutine {
+				continue
+			}
+			if !sym.visibleAt(path, params.Position.Line+1) {
+				continue
+			}
+			add(sym.name, completionSymbolKinds[sym.kind], sym.detail)
+		}
+	}
+	if isCall {
+		return items
+	}
+
+	for _, name := range s.builtin.FunctionNames() {
+		fn, _ := s.builtin.LookupFunction(name)
+		add(name, completionKindFunction, fn.Return.String())
+	}
+	for _, name := range s.builtin.VariableNames() {
+		// Wildcard segment like req.http.%any% is completed until its parent
+		if i := strings.Index(name, "%any%"); i >= 0 {
+			add(name[:i], completionKindModule, "")
+			continue
+		}
+		v, _ := s.builtin.LookupVariable(name)
+		typ := v.Get
+		if typ == types.NeverType {
+			typ = v.Set
+		}
+		add(name, completionKindVariable, typ.String())
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// completionPrefix returns the identifier before the cursor and the rest of the line before it
+func completionPrefix(src string, pos Position) (string, string) {
+	lines := strings.Split(src, "\n")
+	if pos.Line >= len(lines) {
+		return "", ""
+	}
+	line := []rune(strings.TrimRight(lines[pos.Line], "\r"))
+	end := min(runeOffset(line, pos.Character), len(line))
+	start := end
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	return string(line[start:end]), string(line[:start])
+}
+
+func isIdentifierRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+		r == '_' || r == '.' || r == '-' || r == ':'
+}
+
+func (s *Server) definition(params textDocumentPositionParams) []Location {
+	path := uriToPath(params.TextDocument.URI)
+	a := s.analysisFor(path)
+	if a == nil {
+		return nil
+	}
+	tok, ok := a.tokenAt(path, params.Position)
+	if !ok || tok.Type != token.IDENT {
+		return nil
+	}
+	sym := a.lookup(tok)
+	if sym == nil {
+		return nil
+	}
+	return []Location{a.tokenLocation(sym.token)}
+}
+
+func (s *Server) references(params referenceParams) []Location {
+	path := uriToPath(params.TextDocument.URI)
+	a := s.analysisFor(path)
+	if a == nil {
+		return nil
+	}
+	tok, ok := a.tokenAt(path, params.Position)
+	if !ok || tok.Type != token.IDENT {
+		return nil
+	}
+	sym := a.lookup(tok)
+	if sym == nil {
+		return nil
+	}
+
+	refs := a.references(sym, params.Context.IncludeDeclaration)
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].File != refs[j].File {
+			return refs[i].File < refs[j].File
+		}
+		if refs[i].Line != refs[j].Line {
+			return refs[i].Line < refs[j].Line
+		}
+		return refs[i].Position < refs[j].Position
+	})
+	locations := make([]Location, len(refs))
+	for i := range refs {
+		locations[i] = a.tokenLocation(refs[i])
+	}
+	return locations
+}
+
+func (a *analysis) tokenLocation(tok token.Token) Location {
+	return Location{URI: pathToURI(tok.File), Range: a.tokenRange(tok)}
+}
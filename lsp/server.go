@@ -0,0 +1,323 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/config"
+	lcontext "github.com/ysugimoto/falco/v2/linter/context"
+)
+
+// Server is the Language Server Protocol server which communicates over stdio
+type Server struct {
+	config *config.Config
+	writer io.Writer
+	mu     sync.Mutex
+
+	documents map[string]string    // opened document contents keyed by the file path
+	roots     map[string]string    // root document path of the included file
+	analyses  map[string]*analysis // last analysis result keyed by the root document path
+	builtin   *lcontext.Context    // for looking up builtin functions and predefined variables
+	shutdown  bool
+}
+
+func New(c *config.Config) *Server {
+	return &Server{
+		config:    c,
+		documents: make(map[string]string),
+		roots:     make(map[string]string),
+		analyses:  make(map[string]*analysis),
+		builtin:   lcontext.New(),
+	}
+}
+
+func (s *Server) Run() error {
+	log.SetOutput(io.Discard)
+	return s.serve(os.Stdin, os.Stdout)
+}
+
+func (s *Server) serve(r io.Reader, w io.Writer) error {
+	s.writer = w
+	reader := bufio.NewReader(r)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg == nil {
+			s.reply(nil, nil, &responseError{Code: codeParseError, Message: "invalid JSON-RPC message"})
+			continue
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit notification is received before shutdown")
+			}
+			return nil
+		}
+		s.dispatch(msg)
+	}
+}
+
+// readMessage reads a message with the base protocol header.
+// Returns nil message if the content could not be decoded.
+func readMessage(r *bufio.Reader) (*message, error) {
+	var length int
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if v, found := strings.CutPrefix(line, "Content-Length:"); found {
+			if length, err = strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+	if length <= 0 {
+		return nil, errors.New("Content-Length header is required")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, nil
+	}
+	return &msg, nil
+}
+
+func (s *Server) write(msg *message) {
+	msg.JSONRPC = "2.0"
+	buf, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(buf), buf) // nolint:errcheck
+}
+
+func (s *Server) reply(id *json.RawMessage, result any, rerr *responseError) {
+	msg := &message{ID: id, Error: rerr}
+	if rerr == nil {
+		// Result must be present even if it is null
+		buf, err := json.Marshal(result)
+		if err != nil {
+			buf = []byte("null")
+		}
+		msg.Result = buf
+	}
+	if msg.ID == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+	s.write(msg)
+}
+
+func (s *Server) notify(method string, params any) {
+	buf, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.write(&message{Method: method, Params: buf})
+}
+
+// nolint: gocyclo
+func (s *Server) dispatch(msg *message) {
+	var result any
+	var err error
+
+	switch msg.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized", "$/cancelRequest", "textDocument/didSave":
+		// nothing to do
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.didOpen(params)
+		}
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.didChange(params)
+		}
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			s.didClose(params)
+		}
+	case "textDocument/formatting":
+		var params formattingParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result, err = s.formatting(params)
+		}
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.hover(params)
+		}
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.completion(params)
+		}
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.definition(params)
+		}
+	case "textDocument/references":
+		var params referenceParams
+		if err = json.Unmarshal(msg.Params, &params); err == nil {
+			result = s.references(params)
+		}
+	default:
+		// Notifications which are not supported are ignored
+		if msg.ID != nil {
+			s.reply(msg.ID, nil, &responseError{
+				Code:    codeMethodNotFound,
+				Message: fmt.Sprintf("method %s is not supported", msg.Method),
+			})
+		}
+		return
+	}
+
+	// Notifications do not have a response
+	if msg.ID == nil {
+		return
+	}
+	if err != nil {
+		code := codeRequestFailed
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			code = codeInvalidParams
+		}
+		s.reply(msg.ID, nil, &responseError{Code: code, Message: err.Error()})
+		return
+	}
+	s.reply(msg.ID, result, nil)
+}
+
+func (s *Server) initialize() *initializeResult {
+	result := &initializeResult{
+		Capabilities: map[string]any{
+			"textDocumentSync":           textDocumentSyncFull,
+			"hoverProvider":              true,
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"documentFormattingProvider": true,
+			"completionProvider": map[string]any{
+				"triggerCharacters": []string{"."},
+			},
+		},
+	}
+	result.ServerInfo.Name = "falco"
+	return result
+}
+
+func (s *Server) didOpen(params didOpenParams) {
+	path := uriToPath(params.TextDocument.URI)
+	s.documents[path] = params.TextDocument.Text
+	s.lint(path)
+}
+
+func (s *Server) didChange(params didChangeParams) {
+	path := uriToPath(params.TextDocument.URI)
+	// Full document sync, the last change is the whole content
+	if n := len(params.ContentChanges); n > 0 {
+		s.documents[path] = params.ContentChanges[n-1].Text
+	}
+	s.lint(path)
+}
+
+func (s *Server) didClose(params didCloseParams) {
+	path := uriToPath(params.TextDocument.URI)
+	delete(s.documents, path)
+	if a, ok := s.analyses[path]; ok {
+		delete(s.analyses, path)
+		for file := range a.diagnostics {
+			if s.roots[file] == path {
+				delete(s.roots, file)
+			}
+			s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+				URI:         pathToURI(file),
+				Diagnostics: []Diagnostic{},
+			})
+		}
+	}
+}
+
+// lint analyzes the root document of the changed file and publishes diagnostics of all related files
+func (s *Server) lint(path string) {
+	root := path
+	if v, ok := s.roots[path]; ok {
+		if _, opened := s.documents[v]; opened {
+			root = v
+		}
+	}
+
+	a := analyze(s.config, root, s.documents)
+	s.analyses[root] = a
+	for file := range a.sources {
+		// Keep the root which the file is included from
+		if file != root || s.roots[file] == "" {
+			s.roots[file] = root
+		}
+	}
+
+	files := make([]string, 0, len(a.diagnostics))
+	for file := range a.diagnostics {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         pathToURI(file),
+			Diagnostics: a.diagnostics[file],
+		})
+	}
+}
+
+// analysisFor returns the analysis result which contains the file
+func (s *Server) analysisFor(path string) *analysis {
+	if root, ok := s.roots[path]; ok {
+		if a, ok := s.analyses[root]; ok {
+			return a
+		}
+	}
+	return s.analyses[path]
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
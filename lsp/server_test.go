@@ -0,0 +1,298 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+const mainVCL = `include "module";
+
+backend F_origin {
+  .host = "example.com";
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  declare local var.name STRING;
+  set var.name = std.tolower(req.http.Host);
+  call module_recv;
+  set req.backend = F_origin;
+  return (lookup);
+}
+`
+
+const moduleVCL = `sub module_recv {
+  set req.http.X-Module = "1";
+}
+`
+
+type session struct {
+	buf bytes.Buffer
+	id  int
+}
+
+func (s *session) send(method string, params any) int {
+	s.id++
+	s.write(map[string]any{"jsonrpc": "2.0", "id": s.id, "method": method, "params": params})
+	return s.id
+}
+
+func (s *session) notify(method string, params any) {
+	s.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (s *session) write(v any) {
+	buf, _ := json.Marshal(v)
+	fmt.Fprintf(&s.buf, "Content-Length: %d\r\n\r\n%s", len(buf), buf)
+}
+
+// run sends all messages to the server and returns responses keyed by id and notifications
+func (s *session) run(t *testing.T, c *config.Config) (map[int]*message, []*message) {
+	s.send("shutdown", nil)
+	s.notify("exit", nil)
+
+	var out bytes.Buffer
+	if err := New(c).serve(&s.buf, &out); err != nil {
+		t.Fatalf("Unexpected server error: %s", err)
+	}
+
+	responses := make(map[int]*message)
+	var notifications []*message
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		if msg.ID == nil {
+			notifications = append(notifications, msg)
+			continue
+		}
+		var id int
+		json.Unmarshal(*msg.ID, &id) // nolint:errcheck
+		responses[id] = msg
+	}
+	return responses, notifications
+}
+
+func setupWorkspace(t *testing.T) (string, string, *config.Config) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	module := filepath.Join(dir, "module.vcl")
+	if err := os.WriteFile(module, []byte(moduleVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write module: %s", err)
+	}
+	c := &config.Config{
+		Linter: &config.LinterConfig{},
+		Format: &config.FormatConfig{IndentWidth: 2, IndentStyle: config.IndentStyleSpace, TrailingCommentWidth: 1, LineWidth: 120},
+	}
+	return pathToURI(main), pathToURI(module), c
+}
+
+func decode[T any](t *testing.T, msg *message) T {
+	var v T
+	if msg == nil {
+		t.Fatalf("Response is not returned")
+	}
+	if msg.Error != nil {
+		t.Fatalf("Unexpected error response: %s", msg.Error.Message)
+	}
+	if err := json.Unmarshal(msg.Result, &v); err != nil {
+		t.Fatalf("Failed to decode result: %s", err)
+	}
+	return v
+}
+
+func position(uri string, line, character int) map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": uri},
+		"position":     map[string]any{"line": line, "character": character},
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	mainURI, moduleURI, c := setupWorkspace(t)
+
+	s := &session{}
+	s.send("initialize", map[string]any{})
+	s.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI, "text": mainVCL, "version": 1},
+	})
+	// Unsaved change of the included module is used
+	s.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": moduleURI, "text": "sub module_recv {\n  set req.http.X-Module = undefined.var;\n}\n", "version": 1},
+	})
+	s.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": mainURI, "version": 2},
+		"contentChanges": []map[string]any{{"text": "sub vcl_recv {\n  set req.http.Foo = \n}\n"}},
+	})
+	_, notifications := s.run(t, c)
+
+	var published []publishDiagnosticsParams
+	for _, n := range notifications {
+		if n.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p publishDiagnosticsParams
+		json.Unmarshal(n.Params, &p) // nolint:errcheck
+		published = append(published, p)
+	}
+	if len(published) != 5 {
+		t.Fatalf("Unexpected number of published diagnostics: %d", len(published))
+	}
+
+	// First open: main and included module are linted without errors
+	for _, p := range published[:2] {
+		for _, d := range p.Diagnostics {
+			if d.Severity == severityError {
+				t.Errorf("Unexpected error diagnostic in %s: %s", p.URI, d.Message)
+			}
+		}
+	}
+	// Opening the module lints the root document, errors are reported on the module
+	if published[3].URI != moduleURI || len(published[3].Diagnostics) == 0 {
+		t.Errorf("Expected module diagnostics, got %+v", published[3])
+	} else if d := published[3].Diagnostics[0]; d.Range.Start.Line != 1 || !strings.Contains(d.Message, "undefined.var") {
+		t.Errorf("Unexpected module diagnostic: %+v", d)
+	}
+	// Parse error of the changed document
+	if p := published[4]; p.URI != mainURI || len(p.Diagnostics) != 1 || p.Diagnostics[0].Range.Start.Line != 2 {
+		t.Errorf("Expected parse error diagnostic, got %+v", p)
+	}
+}
+
+func TestLanguageFeatures(t *testing.T) {
+	mainURI, moduleURI, c := setupWorkspace(t)
+
+	s := &session{}
+	s.send("initialize", map[string]any{})
+	s.notify("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI, "text": mainVCL, "version": 1},
+	})
+	hoverFunction := s.send("textDocument/hover", position(mainURI, 9, 20))
+	hoverVariable := s.send("textDocument/hover", position(mainURI, 9, 34))
+	hoverLocal := s.send("textDocument/hover", position(mainURI, 9, 8))
+	definition := s.send("textDocument/definition", position(mainURI, 10, 10))
+	references := s.send("textDocument/references", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI},
+		"position":     map[string]any{"line": 11, "character": 22},
+		"context":      map[string]any{"includeDeclaration": true},
+	})
+	completion := s.send("textDocument/completion", position(mainURI, 9, 22))
+	formatting := s.send("textDocument/formatting", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI},
+	})
+	responses, _ := s.run(t, c)
+
+	t.Run("hover", func(t *testing.T) {
+		if v := decode[Hover](t, responses[hoverFunction]); !strings.Contains(v.Contents.Value, "STRING std.tolower(STRING)") {
+			t.Errorf("Unexpected function hover: %s", v.Contents.Value)
+		}
+		if v := decode[Hover](t, responses[hoverVariable]); !strings.Contains(v.Contents.Value, "STRING req.http.Host") {
+			t.Errorf("Unexpected variable hover: %s", v.Contents.Value)
+		}
+		if v := decode[Hover](t, responses[hoverLocal]); !strings.Contains(v.Contents.Value, "declare local var.name STRING") {
+			t.Errorf("Unexpected local variable hover: %s", v.Contents.Value)
+		}
+	})
+
+	t.Run("definition across included file", func(t *testing.T) {
+		expect := []Location{
+			{URI: moduleURI, Range: Range{Start: Position{Line: 0, Character: 4}, End: Position{Line: 0, Character: 15}}},
+		}
+		if diff := cmp.Diff(expect, decode[[]Location](t, responses[definition])); diff != "" {
+			t.Errorf("Definition mismatch, diff=%s", diff)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		expect := []Location{
+			{URI: mainURI, Range: Range{Start: Position{Line: 2, Character: 8}, End: Position{Line: 2, Character: 16}}},
+			{URI: mainURI, Range: Range{Start: Position{Line: 11, Character: 20}, End: Position{Line: 11, Character: 28}}},
+		}
+		if diff := cmp.Diff(expect, decode[[]Location](t, responses[references])); diff != "" {
+			t.Errorf("References mismatch, diff=%s", diff)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		items := decode[[]CompletionItem](t, responses[completion])
+		var found bool
+		for _, item := range items {
+			if !strings.HasPrefix(item.Label, "std.t") {
+				t.Errorf("Completion item must have the prefix: %s", item.Label)
+			}
+			if item.Label == "std.tolower" {
+				found = true
+				if item.TextEdit == nil || item.TextEdit.Range.Start.Character != 17 {
+					t.Errorf("Completion must replace the whole prefix: %+v", item.TextEdit)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("std.tolower must be completed")
+		}
+	})
+
+	t.Run("formatting", func(t *testing.T) {
+		edits := decode[[]TextEdit](t, responses[formatting])
+		if len(edits) != 1 || !strings.Contains(edits[0].NewText, "sub vcl_recv {") {
+			t.Errorf("Unexpected formatting edits: %+v", edits)
+		}
+	})
+}
+
+func TestCompletionPrefix(t *testing.T) {
+	tests := []struct {
+		line   string
+		char   int
+		prefix string
+		before string
+	}{
+		{line: "  set req.http.", char: 15, prefix: "req.http.", before: "  set "},
+		{line: "  call mod", char: 10, prefix: "mod", before: "  call "},
+		{line: "  set var.x = std.to(", char: 20, prefix: "std.to", before: "  set var.x = "},
+		{line: "", char: 0},
+	}
+	for _, tt := range tests {
+		prefix, before := completionPrefix(tt.line, Position{Line: 0, Character: tt.char})
+		if prefix != tt.prefix || before != tt.before {
+			t.Errorf("completionPrefix(%q) mismatch, got prefix=%q before=%q", tt.line, prefix, before)
+		}
+	}
+}
+
+func TestUTF16Positions(t *testing.T) {
+	_, _, c := setupWorkspace(t)
+	root := filepath.Join(t.TempDir(), "main.vcl")
+	src := "sub vcl_recv {\n  #FASTLY RECV\n  declare local var.s STRING;\n  set var.s = \"😀\"; set var.s = \"é\";\n}\n"
+	a := analyze(c, root, map[string]string{root: src})
+
+	// Emoji is counted as two UTF-16 code units, so the second var.s starts at 24 instead of 23
+	tok, ok := a.tokenAt(root, Position{Line: 3, Character: 25})
+	if !ok || tok.Literal != "var.s" {
+		t.Fatalf("Token must be found at the position, got %+v", tok)
+	}
+	expect := Range{Start: Position{Line: 3, Character: 24}, End: Position{Line: 3, Character: 29}}
+	if diff := cmp.Diff(expect, a.tokenRange(tok)); diff != "" {
+		t.Errorf("Token range mismatch, diff=%s", diff)
+	}
+	if tok, ok := a.tokenAt(root, Position{Line: 3, Character: 15}); !ok || tok.Type != token.STRING {
+		t.Errorf("String token must be found at the position, got %+v", tok)
+	}
+
+	prefix, before := completionPrefix(`  log "😀" std.to`, Position{Line: 0, Character: 17})
+	if prefix != "std.to" || before != `  log "😀" ` {
+		t.Errorf("completionPrefix mismatch, got prefix=%q before=%q", prefix, before)
+	}
+}
@@ -64,6 +64,9 @@ type Parser struct {
 	infixParsers   map[token.TokenType]infixParser
 	postfixParsers map[token.TokenType]postfixParser
 	customParsers  map[token.TokenType]CustomParser
+
+	// Syntax errors which are recovered while parsing
+	parseErrors []*ParseError
 }
 
 func New(tk Tokenizer, opts ...ParserOption) *Parser {
@@ -235,7 +238,7 @@ func (p *Parser) ParseVCLOrSnippet() (*ast.VCL, error) {
 
 	// Parse as snippet - the file contains statements, not declarations
 	statements, err := p.ParseSnippetVCL()
-	if err != nil {
+	if statements == nil && err != nil {
 		return nil, err
 	}
 
@@ -244,22 +247,30 @@ func (p *Parser) ParseVCLOrSnippet() (*ast.VCL, error) {
 		Statements: statements,
 		IsSnippet:  true,
 	}
-	return vcl, nil
+	return vcl, err
 }
 
+// ParseVCL parses root declarations.
+// When syntax errors are found, parser recovers from them and returns the partial AST
+// with the first error. All errors could be retrieved via Errors().
 func (p *Parser) ParseVCL() (*ast.VCL, error) {
 	vcl := &ast.VCL{}
 
 	for !p.CurTokenIs(token.EOF) {
+		p.resetNest()
 		stmt, err := p.Parse()
 		if err != nil {
-			return nil, err
+			if !p.recover(err) {
+				return nil, err
+			}
+			p.synchronizeDeclaration()
+			continue
 		} else if stmt != nil {
 			vcl.Statements = append(vcl.Statements, stmt)
 		}
 	}
 
-	return vcl, nil
+	return vcl, p.firstError()
 }
 
 func (p *Parser) Parse() (ast.Statement, error) {
@@ -367,12 +378,22 @@ func (p *Parser) ParseSnippetVCL() ([]ast.Statement, error) {
 		}
 
 		if err != nil {
-			return nil, errors.WithStack(err)
+			if !p.recover(err) {
+				return nil, errors.WithStack(err)
+			}
+			p.synchronizeStatement(0)
+		} else {
+			statements = append(statements, stmt)
 		}
-		statements = append(statements, stmt)
 		p.NextToken() // point to statement
 	}
 
 	p.NextToken() // point to EOF
+	if err := p.firstError(); err != nil {
+		if statements == nil {
+			statements = []ast.Statement{}
+		}
+		return statements, err
+	}
 	return statements, nil
 }
@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/token"
+)
+
+// Tokens which start a statement, used to find the next statement boundary on error recovery
+var statementTokens = map[token.TokenType]struct{}{
+	token.SET:              {},
+	token.UNSET:            {},
+	token.REMOVE:           {},
+	token.ADD:              {},
+	token.CALL:             {},
+	token.DECLARE:          {},
+	token.ERROR:            {},
+	token.ESI:              {},
+	token.LOG:              {},
+	token.RESTART:          {},
+	token.RETURN:           {},
+	token.SYNTHETIC:        {},
+	token.SYNTHETIC_BASE64: {},
+	token.IF:               {},
+	token.SWITCH:           {},
+	token.GOTO:             {},
+	token.INCLUDE:          {},
+	token.BREAK:            {},
+	token.FALLTHROUGH:      {},
+}
+
+// Errors returns all syntax errors which are collected while parsing.
+// Parse functions return the first one of them as an error.
+func (p *Parser) Errors() []*ParseError {
+	return p.parseErrors
+}
+
+// recover records the syntax error and reports whether parsing can be continued.
+// Errors which are not *ParseError (e.g. from custom parsers) could not be recovered.
+func (p *Parser) recover(err error) bool {
+	pe, ok := errors.Cause(err).(*ParseError)
+	if !ok {
+		return false
+	}
+	// The error may be returned again from the outer parse function
+	for _, v := range p.parseErrors {
+		if v == pe {
+			return true
+		}
+	}
+	p.parseErrors = append(p.parseErrors, pe)
+	return true
+}
+
+// firstError returns the first recorded syntax error, or nil if parsing succeeded
+func (p *Parser) firstError() error {
+	if len(p.parseErrors) == 0 {
+		return nil
+	}
+	return errors.WithStack(p.parseErrors[0])
+}
+
+// isTopLevelDeclaration reports whether the peek token looks like a new root declaration.
+// Declarations must be at the root level but also accept the one at the beginning of line
+// to recover from missing closing brace.
+func (p *Parser) isTopLevelDeclaration() bool {
+	if p.PeekTokenIs(token.INCLUDE) || !p.isDeclarationToken(p.peekToken.Token.Type) {
+		return false
+	}
+	return p.peekToken.Nest == 0 || p.peekToken.Token.Position == 1
+}
+
+// synchronizeStatement skips tokens of the broken statement in the block of the nest level.
+// Current token points to the end of the statement after this function,
+// and returns true if the current token is the closing brace of the block.
+func (p *Parser) synchronizeStatement(nest int) bool {
+	line := p.curToken.Token.Line
+	for {
+		switch {
+		case p.CurTokenIs(token.RIGHT_BRACE) && p.curToken.Nest < nest:
+			return true
+		case p.CurTokenIs(token.SEMICOLON) && p.curToken.Nest == nest:
+			return false
+		case p.CurTokenIs(token.RIGHT_BRACE) && p.curToken.Nest == nest && !p.peekIsElse():
+			// Closing brace of the nested statement like if or switch
+			return false
+		case p.PeekTokenIs(token.EOF):
+			return false
+		case p.PeekTokenIs(token.RIGHT_BRACE) && p.peekToken.Nest < nest:
+			return false
+		case p.peekToken.Nest == nest && p.peekToken.Token.Line > line && p.isStatementToken(p.peekToken.Token.Type):
+			return false
+		case p.isTopLevelDeclaration():
+			return false
+		}
+		p.NextToken()
+	}
+}
+
+// synchronizeDeclaration skips tokens until the next root declaration
+func (p *Parser) synchronizeDeclaration() {
+	for !p.PeekTokenIs(token.EOF) && !p.isTopLevelDeclaration() {
+		p.NextToken()
+	}
+	p.NextToken()
+	p.resetNest()
+}
+
+// resetNest normalizes nest level of tokens from the current root declaration
+// which may be broken by the unclosed brace
+func (p *Parser) resetNest() {
+	if nest := p.curToken.Nest; nest != 0 {
+		p.level -= nest
+		p.peekToken.Nest -= nest
+		p.curToken.Nest = 0
+	}
+}
+
+func (p *Parser) peekIsElse() bool {
+	return p.PeekTokenIs(token.ELSE) || p.PeekTokenIs(token.ELSEIF) || p.PeekTokenIs(token.ELSIF)
+}
+
+func (p *Parser) isStatementToken(t token.TokenType) bool {
+	_, ok := statementTokens[t]
+	return ok
+}
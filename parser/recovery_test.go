@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/lexer"
+)
+
+func TestRecoverFromSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		lines []int          // lines of syntax errors
+		subs  map[string]int // statement count of parsed subroutines
+		decls int            // number of parsed root declarations
+	}{
+		{
+			name: "broken statements in subroutines",
+			input: `sub vcl_recv {
+  set req.http.Foo = ;
+  set req.http.Bar = "bar";
+  if (req.http.Foo {
+    set req.http.Baz = "1";
+  }
+  return (lookup);
+}
+
+sub vcl_deliver {
+  unset resp.http.X-Foo
+  set resp.http.Y = "y";
+}`,
+			lines: []int{2, 4, 11},
+			subs:  map[string]int{"vcl_recv": 2, "vcl_deliver": 1},
+			decls: 2,
+		},
+		{
+			name: "missing closing brace",
+			input: `sub vcl_recv {
+  if (req.http.Foo) {
+    set req.http.Bar = "bar";
+  return (lookup);
+}
+
+sub vcl_deliver {
+  set resp.http.Y = "y";
+}`,
+			lines: []int{7},
+			subs:  map[string]int{"vcl_recv": 1, "vcl_deliver": 1},
+			decls: 2,
+		},
+		{
+			name: "broken declaration",
+			input: `backend F_origin {
+  .host "example.com";
+}
+
+acl internal {
+  "192.168.0.1"
+}
+
+sub vcl_recv {
+  set req.http.Foo = "foo";
+}`,
+			lines: []int{2, 6},
+			subs:  map[string]int{"vcl_recv": 1},
+			decls: 1,
+		},
+		{
+			name: "unclosed subroutine at the end of file",
+			input: `sub vcl_recv {
+  set req.http.Foo = "foo";
+}
+
+sub vcl_miss {
+  set req.http.A = "a"
+`,
+			lines: []int{6},
+			subs:  map[string]int{"vcl_recv": 1},
+			decls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := New(lexer.NewFromString(tt.input))
+			vcl, err := p.ParseVCL()
+			if err == nil {
+				t.Fatalf("Expected syntax error but got nil")
+			}
+			if vcl == nil {
+				t.Fatalf("Partial AST must be returned")
+			}
+			var lines []int
+			for _, pe := range p.Errors() {
+				lines = append(lines, pe.Token.Line)
+			}
+			if diff := cmp.Diff(tt.lines, lines); diff != "" {
+				t.Errorf("Error lines mismatch, diff=%s", diff)
+			}
+			if len(vcl.Statements) != tt.decls {
+				t.Errorf("Expected %d declarations, got %d", tt.decls, len(vcl.Statements))
+			}
+			subs := map[string]int{}
+			for _, stmt := range vcl.Statements {
+				if sub, ok := stmt.(*ast.SubroutineDeclaration); ok {
+					subs[sub.Name.Value] = len(sub.Block.Statements)
+				}
+			}
+			if diff := cmp.Diff(tt.subs, subs); diff != "" {
+				t.Errorf("Parsed subroutines mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
+
+func TestRecoverSnippetSyntaxErrors(t *testing.T) {
+	input := `set req.http.Foo = ;
+set req.http.Bar = "bar";
+unset req.http.Baz
+set req.http.Qux = "qux";`
+
+	p := New(lexer.NewFromString(input))
+	statements, err := p.ParseSnippetVCL()
+	if err == nil {
+		t.Fatalf("Expected syntax error but got nil")
+	}
+	if len(p.Errors()) != 2 {
+		t.Errorf("Expected 2 syntax errors, got %d", len(p.Errors()))
+	}
+	if len(statements) != 2 {
+		t.Errorf("Expected 2 statements, got %d", len(statements))
+	}
+}
+
+func TestRecoverReportsAllErrors(t *testing.T) {
+	input := `sub vcl_recv {
+  set req.http.Foo = ;
+  unset req.http.Bar
+  call ;
+  declare local var.Foo;
+  return (lookup);
+}`
+
+	type report struct {
+		Line     int
+		Position int
+		Message  string
+	}
+
+	p := New(lexer.NewFromString(input))
+	if _, err := p.ParseVCL(); err == nil {
+		t.Fatalf("Expected syntax error but got nil")
+	}
+	var reports []report
+	for _, pe := range p.Errors() {
+		reports = append(reports, report{Line: pe.Token.Line, Position: pe.Token.Position, Message: pe.Message})
+	}
+	expect := []report{
+		{Line: 2, Position: 22, Message: "Undefined prefix expression for ;"},
+		{Line: 3, Position: 9, Message: "Missing semicolon"},
+		{Line: 4, Position: 8, Message: `Unexpected token ";", expects IDENT`},
+		{Line: 5, Position: 24, Message: `Unexpected token ";", expects IDENT`},
+	}
+	if diff := cmp.Diff(expect, reports); diff != "" {
+		t.Errorf("Syntax errors mismatch, diff=%s", diff)
+	}
+}
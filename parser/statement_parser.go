@@ -146,18 +146,37 @@ func (p *Parser) ParseBlockStatement() (*ast.BlockStatement, error) {
 	}
 
 	for !p.PeekTokenIs(token.RIGHT_BRACE) {
+		// Root declaration appears, the block would not be closed
+		if p.isTopLevelDeclaration() {
+			p.recover(UnexpectedToken(p.peekToken, "RIGHT_BRACE"))
+			return b, nil
+		}
 		stmt, err := p.ParseStatement()
-		if err != nil {
-			return nil, errors.WithStack(err)
+		if err == nil {
+			switch stmt.(type) {
+			case *ast.BreakStatement, *ast.FallthroughStatement:
+				err = UnexpectedToken(stmt.GetMeta())
+			}
 		}
-		switch stmt.(type) {
-		case *ast.BreakStatement, *ast.FallthroughStatement:
-			return nil, UnexpectedToken(stmt.GetMeta())
+		if err != nil {
+			// Skip the broken statement and continue to parse the rest of block
+			if !p.recover(err) || p.PeekTokenIs(token.EOF) {
+				return nil, errors.WithStack(err)
+			}
+			if p.synchronizeStatement(b.Meta.Nest) {
+				return p.closeBlock(b), nil
+			}
+			continue
 		}
 		b.Statements = append(b.Statements, stmt)
 	}
 
 	p.NextToken() // point to RIGHT_BRACE
+	return p.closeBlock(b), nil
+}
+
+// closeBlock finishes the block statement which current token points to RIGHT_BRACE
+func (p *Parser) closeBlock(b *ast.BlockStatement) *ast.BlockStatement {
 	b.Trailing = p.Trailing()
 	b.EndLine = p.curToken.Token.Line
 	b.EndPosition = p.curToken.Token.Position
@@ -165,7 +184,7 @@ func (p *Parser) ParseBlockStatement() (*ast.BlockStatement, error) {
 	// RIGHT_BRACE leading comments are block infix comments
 	SwapLeadingInfix(p.curToken, b.Meta)
 
-	return b, nil
+	return b
 }
 
 func (p *Parser) ParseSetStatement() (*ast.SetStatement, error) {
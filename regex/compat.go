@@ -0,0 +1,272 @@
+package regex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unsupportedConstructs finds PCRE2 only constructs which local PCRE2 accepts but Fastly rejects
+func unsupportedConstructs(pattern string) []*Issue {
+	var issues []*Issue
+	unsupported := func(offset int, construct string) {
+		issues = append(issues, &Issue{
+			Kind:    Unsupported,
+			Offset:  offset,
+			Message: fmt.Sprintf("%s is not supported in Fastly", construct),
+		})
+	}
+
+	inCharClass := false
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '\\':
+			switch {
+			case strings.HasPrefix(pattern[i:], `\Q`):
+				i = skipQuotedLiteral(pattern, i) - 1
+				continue
+			case !inCharClass && strings.HasPrefix(pattern[i:], `\N{U+`):
+				unsupported(i, `Code point escape \N{U+...}`)
+			}
+			i++ // Skip escaped character
+		case inCharClass:
+			if ch == ']' {
+				inCharClass = false
+			}
+		case ch == '[':
+			inCharClass = true
+			i = skipCharClassStart(pattern, i+1) - 1
+		case strings.HasPrefix(pattern[i:], "(?#"):
+			i = handlePCREComment(pattern, i) - 1
+		case strings.HasPrefix(pattern[i:], "(*"):
+			name := verbName(pattern[i+2:])
+			next := i + 2 + len(name)
+			switch {
+			case name == "LIMIT_HEAP" || name == "LIMIT_DEPTH":
+				unsupported(i, fmt.Sprintf("Start option (*%s=...)", name))
+			case name != "" && name[0] >= 'a' && name[0] <= 'z' && next < len(pattern) && pattern[next] == ':':
+				// Alphabetic assertions like (*pla:...), (*atomic:...) or script runs like (*sr:...)
+				unsupported(i, fmt.Sprintf("Alphabetic assertion (*%s:...)", name))
+			}
+		case strings.HasPrefix(pattern[i:], "(?"):
+			rest := pattern[i+2:]
+			switch {
+			case strings.HasPrefix(rest, "^"):
+				unsupported(i, "Modifier reset (?^)")
+			case strings.HasPrefix(rest, "*") || strings.HasPrefix(rest, "<*"):
+				unsupported(i, "Non-atomic lookaround")
+			case strings.HasPrefix(rest, "C"):
+				unsupported(i, "Callout (?C)")
+			default:
+				// Inline modifiers, PCRE2 adds (?n) and (?xx)
+				j := 0
+				for j < len(rest) && (isInlineModifierChar(rest[j]) || rest[j] == 'n') {
+					j++
+				}
+				if j == 0 || j == len(rest) || (rest[j] != ')' && rest[j] != ':') {
+					break
+				}
+				if modifiers := rest[:j]; strings.Contains(modifiers, "n") {
+					unsupported(i, "Modifier (?n)")
+				} else if strings.Contains(modifiers, "xx") {
+					unsupported(i, "Modifier (?xx)")
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// verbName returns the name of backtracking control verb or start option which follows "(*"
+func verbName(s string) string {
+	var i int
+	for i < len(s) && (s[i] == '_' || (s[i] >= 'a' && s[i] <= 'z') || (s[i] >= 'A' && s[i] <= 'Z')) {
+		i++
+	}
+	return s[:i]
+}
+
+// skipQuotedLiteral skips \Q...\E quoted literal and returns the index after it
+func skipQuotedLiteral(pattern string, i int) int {
+	if end := strings.Index(pattern[i+2:], `\E`); end != -1 {
+		return i + 2 + end + 2
+	}
+	return len(pattern)
+}
+
+type quantifiedGroup struct {
+	start     int
+	atomic    bool
+	unbounded bool // group contains unbounded quantifier which backtracks
+	fixed     bool // group contains an atom without quantifier which delimits repetitions
+}
+
+// nestedQuantifiers finds groups which consist of quantified atoms only and are repeated unboundedly
+// like (a+)+ or (\w+\s?)*. These patterns backtrack exponentially on failure, and Fastly aborts
+// the matching by EREGRECUR while local PCRE2 may complete it.
+// Groups which have a delimiter like ([a-z]+\.)+ are not reported because the repetitions could not overlap.
+func nestedQuantifiers(pattern string) []*Issue {
+	var issues []*Issue
+	stack := []*quantifiedGroup{{start: -1}}
+
+	// quantify applies the quantifier which follows the atom or group at i and returns the index after it
+	quantify := func(i int, g *quantifiedGroup) int {
+		size, unbounded, possessive := quantifierAt(pattern, i)
+		top := stack[len(stack)-1]
+		fixed := size == 0
+		if g != nil {
+			if unbounded && !possessive && g.unbounded && !g.fixed && !g.atomic {
+				issues = append(issues, &Issue{
+					Kind:    Backtracking,
+					Offset:  g.start,
+					Message: "Nested quantifier may cause catastrophic backtracking and fail with EREGRECUR on Fastly",
+				})
+			}
+			if g.unbounded && !g.atomic {
+				top.unbounded = true
+			}
+			fixed = fixed && g.fixed
+		}
+		if fixed {
+			top.fixed = true
+		}
+		if unbounded && !possessive {
+			top.unbounded = true
+		}
+		return i + size
+	}
+
+	for i := 0; i < len(pattern); {
+		ch := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], `\Q`):
+			i = skipQuotedLiteral(pattern, i)
+		case ch == '\\':
+			i = quantify(min(i+2, len(pattern)), nil)
+		case ch == '[':
+			j := skipCharClassStart(pattern, i+1)
+			for j < len(pattern) && pattern[j] != ']' {
+				if pattern[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			i = quantify(min(j+1, len(pattern)), nil)
+		case ch == '(':
+			header, push := groupHeaderSize(pattern, i)
+			if push {
+				stack = append(stack, &quantifiedGroup{
+					start:  i,
+					atomic: strings.HasPrefix(pattern[i:], "(?>"),
+				})
+			}
+			i += header
+		case ch == ')':
+			if len(stack) == 1 {
+				i++
+				continue
+			}
+			g := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			i = quantify(i+1, g)
+		case ch == '|' || ch == '^' || ch == '$':
+			i++
+		default:
+			i = quantify(i+1, nil)
+		}
+	}
+	return issues
+}
+
+// groupHeaderSize returns the size of group opening like "(", "(?:" or "(?<name>",
+// and whether the group has a body. Inline options like "(?i)", comments and verbs do not have a body
+// so their whole size is returned.
+func groupHeaderSize(pattern string, i int) (int, bool) {
+	rest := pattern[i:]
+	closing := func() int {
+		if end := strings.Index(rest, ")"); end != -1 {
+			return end + 1
+		}
+		return len(rest)
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "(*"), strings.HasPrefix(rest, "(?#"):
+		return closing(), false
+	case strings.HasPrefix(rest, "(?<="), strings.HasPrefix(rest, "(?<!"):
+		return 4, true
+	case strings.HasPrefix(rest, "(?<"), strings.HasPrefix(rest, "(?P<"), strings.HasPrefix(rest, "(?'"):
+		if end := strings.IndexAny(rest[3:], ">'"); end != -1 {
+			return 3 + end + 1, true
+		}
+		return len(rest), false
+	case strings.HasPrefix(rest, "(?("):
+		// Conditional group, skip the condition
+		if end := strings.Index(rest[3:], ")"); end != -1 {
+			return 3 + end + 1, true
+		}
+		return len(rest), false
+	case strings.HasPrefix(rest, "(?"):
+		if len(rest) > 2 && strings.ContainsRune(":=!>|", rune(rest[2])) {
+			return 3, true
+		}
+		j := 2
+		for j < len(rest) && (isInlineModifierChar(rest[j]) || rest[j] == 'n') {
+			j++
+		}
+		if j < len(rest) && rest[j] == ':' {
+			return j + 1, true
+		}
+		// Inline option, backreference or subroutine call
+		return closing(), false
+	}
+	return 1, true
+}
+
+// quantifierAt parses the quantifier at i and returns its size,
+// whether it repeats unboundedly and whether it is possessive
+func quantifierAt(pattern string, i int) (int, bool, bool) {
+	if i >= len(pattern) {
+		return 0, false, false
+	}
+
+	var size int
+	var unbounded bool
+	switch pattern[i] {
+	case '*', '+':
+		size, unbounded = 1, true
+	case '?':
+		size = 1
+	case '{':
+		end := strings.Index(pattern[i:], "}")
+		if end == -1 || !isRangeQuantifier(pattern[i+1:i+end]) {
+			return 0, false, false
+		}
+		size, unbounded = end+1, strings.HasSuffix(pattern[i+1:i+end], ",")
+	default:
+		return 0, false, false
+	}
+
+	if i+size < len(pattern) {
+		switch pattern[i+size] {
+		case '+':
+			return size + 1, unbounded, true
+		case '?':
+			return size + 1, unbounded, false
+		}
+	}
+	return size, unbounded, false
+}
+
+// isRangeQuantifier returns true if the body of {...} is n, n, or n,m
+func isRangeQuantifier(body string) bool {
+	if body == "" || body[0] < '0' || body[0] > '9' {
+		return false
+	}
+	for _, c := range body {
+		if c != ',' && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return strings.Count(body, ",") <= 1
+}
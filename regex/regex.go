@@ -0,0 +1,53 @@
+// Package regex provides PCRE pattern analysis which is shared by the linter and the interpreter.
+//
+// falco runs regular expressions with PCRE2 locally, but Fastly runs them with
+// the PCRE library which Varnish has used. So some patterns are accepted locally
+// but rejected on Fastly, or could behave differently under Fastly's backtracking limit.
+package regex
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	pcre "go.elara.ws/pcre"
+)
+
+type IssueKind int
+
+const (
+	// Unsupported means the pattern is rejected by Fastly
+	Unsupported IssueKind = iota
+	// Backtracking means the pattern may fail with EREGRECUR on Fastly due to the backtracking limit
+	Backtracking
+)
+
+// Issue is the compatibility problem of the pattern
+type Issue struct {
+	Kind    IssueKind
+	Offset  int // byte offset of the construct in the pattern
+	Message string
+}
+
+func (i *Issue) Error() string {
+	return fmt.Sprintf("%s at offset %d", i.Message, i.Offset)
+}
+
+// Analyze returns compatibility issues of the pattern against Fastly
+func Analyze(pattern string) []*Issue {
+	issues := unsupportedConstructs(pattern)
+	return append(issues, nestedQuantifiers(pattern)...)
+}
+
+// Compile compiles the pattern like Fastly does.
+// The pattern which uses constructs unsupported on Fastly is treated as an invalid pattern
+// even if local PCRE2 could compile it.
+func Compile(pattern string) (*pcre.Regexp, error) {
+	if issues := unsupportedConstructs(pattern); len(issues) > 0 {
+		return nil, errors.WithStack(issues[0])
+	}
+	re, err := pcre.Compile(pattern)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return re, nil
+}
@@ -0,0 +1,80 @@
+package regex
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAnalyze(t *testing.T) {
+	type issue struct {
+		Kind   IssueKind
+		Offset int
+	}
+
+	tests := []struct {
+		pattern string
+		expect  []issue
+	}{
+		{pattern: `^/foo/(bar|baz)\.html$`},
+		{pattern: `(?=foo)(?<=bar)a++b*+(?>c+)+`},
+		{pattern: `(?i)^/(?:[a-z]+\.)+com$`},
+		{pattern: `\Q(*pla:\E(?#(?^))[(?n)]`},
+		{pattern: `(*pla:foo)bar`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `(*LIMIT_HEAP=100)foo`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `(*UTF8)(*SKIP)foo`},
+		{pattern: `foo(?^i:bar)`, expect: []issue{{Unsupported, 3}}},
+		{pattern: `(?n)(foo)`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `(?xx)[a b]`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `(?*foo)`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `a(?C1)b`, expect: []issue{{Unsupported, 1}}},
+		{pattern: `\N{U+263a}`, expect: []issue{{Unsupported, 0}}},
+		{pattern: `^(a+)+$`, expect: []issue{{Backtracking, 1}}},
+		{pattern: `^(?:\w+\s?)*$`, expect: []issue{{Backtracking, 1}}},
+		{pattern: `^((ab)*c?)+$`, expect: []issue{{Backtracking, 1}}},
+		{pattern: `^(a+)?$`},
+		{pattern: `^(a{2,5})+$`},
+		{pattern: `^([a-z]+/)*[a-z]+$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			var issues []issue
+			for _, v := range Analyze(tt.pattern) {
+				issues = append(issues, issue{Kind: v.Kind, Offset: v.Offset})
+			}
+			if diff := cmp.Diff(tt.expect, issues); diff != "" {
+				t.Errorf("Issues mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	if _, err := Compile(`^/foo/(a+)+$`); err != nil {
+		t.Errorf("Unexpected compile error: %s", err)
+	}
+	if _, err := Compile(`(*pla:foo)bar`); err == nil {
+		t.Errorf("Expected error for the pattern which is not supported in Fastly")
+	}
+	if _, err := Compile(`(foo`); err == nil {
+		t.Errorf("Expected error for the invalid pattern")
+	}
+}
+
+func TestReplacementReferences(t *testing.T) {
+	tests := []struct {
+		replacement string
+		expect      []int
+	}{
+		{replacement: `foo`},
+		{replacement: `\1-\2\0`, expect: []int{1, 2, 0}},
+		{replacement: `\\1\n\`, expect: nil},
+		{replacement: `\10`, expect: []int{1}},
+	}
+	for _, tt := range tests {
+		if diff := cmp.Diff(tt.expect, ReplacementReferences(tt.replacement)); diff != "" {
+			t.Errorf("References of %q mismatch, diff=%s", tt.replacement, diff)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package regex
+
+// ReplacementReferences returns the group numbers which are referenced as \0 to \9
+// in the replacement of regsub and regsuball
+func ReplacementReferences(replacement string) []int {
+	var refs []int
+	for i := 0; i < len(replacement)-1; i++ {
+		if replacement[i] != '\\' {
+			continue
+		}
+		i++
+		if next := replacement[i]; next >= '0' && next <= '9' {
+			refs = append(refs, int(next-'0'))
+		}
+	}
+	return refs
+}
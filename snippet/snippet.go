@@ -41,7 +41,7 @@ type Snippets struct {
 	ScopedSnippets  ScopedSnippets  `json:"scoped"`
 	IncludeSnippets IncludeSnippets `json:"include"`
 
-	// Currently no use
+	// Validate logging endpoint names in the simulator
 	LoggingEndpoints LoggingEndpoints `json:"logging"`
 }
 
@@ -96,8 +96,7 @@ func (s *Snippets) EmbedSnippets(enableTLS bool) ([]Item, error) {
 	return snippets, nil
 }
 
-// Fastly logging endpoints is not used on linting and interpreter,
-// but we need to be able to factory all endpoints for future works.
+// Fastly logging endpoints are used to validate the endpoint names of log lines in the simulator.
 // Fastly's logging endpoints API is divided for each services like BigQuery, S3, etc..
 // It means we need to make many API calls so implement as Snippets pointer method.
 func (s *Snippets) FetchLoggingEndpoint(fetcher Fetcher) error {
@@ -23,16 +23,46 @@ type Definiions struct {
 
 type Functions map[string]*ifn.Function
 
-func TestingFunctions(i *interpreter.Interpreter, defs *Definiions, c *shared.Counter, cv *shared.Coverage) Functions {
+func TestingFunctions(
+	i *interpreter.Interpreter,
+	defs *Definiions,
+	c *shared.Counter,
+	cv *shared.Coverage,
+	s *shared.Snapshots,
+) Functions {
 	functions := Functions{}
 	maps.Copy(functions, testingFunctions(i, defs))
 	maps.Copy(functions, assertionFunctions(i, c))
 	if cv != nil {
 		maps.Copy(functions, coverageFunctions(cv))
 	}
+	if s != nil {
+		maps.Copy(functions, snapshotFunctions(i, defs, c, s))
+	}
 	return functions
 }
 
+func snapshotFunctions(i *interpreter.Interpreter, defs *Definiions, c *shared.Counter, s *shared.Snapshots) Functions {
+	return Functions{
+		"testing.snapshot": {
+			Scope: allScope,
+			Call: func(ctx *context.Context, args ...value.Value) (value.Value, error) {
+				v, err := Testing_snapshot(ctx, i, defs, s, args...)
+				if err != nil {
+					c.Fail()
+				} else {
+					c.Pass()
+				}
+				return v, err
+			},
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+	}
+}
+
 func coverageFunctions(c *shared.Coverage) Functions {
 	return Functions{
 		"coverage.subroutine": {
@@ -211,6 +241,40 @@ func testingFunctions(i *interpreter.Interpreter, defs *Definiions) Functions {
 				return false
 			},
 		},
+		"testing.mock_backend_response": {
+			Scope:            allScope,
+			Call:             Testing_mock_backend_response,
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.fetch_backend": {
+			Scope: allScope,
+			Call: func(ctx *context.Context, args ...value.Value) (value.Value, error) {
+				return Testing_fetch_backend(ctx, i, args...)
+			},
+			CanStatementCall: true,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.origin_request": {
+			Scope:            allScope,
+			Call:             Testing_origin_request,
+			CanStatementCall: false,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
+		"testing.origin_request_count": {
+			Scope:            allScope,
+			Call:             Testing_origin_request_count,
+			CanStatementCall: false,
+			IsIdentArgument: func(i int) bool {
+				return false
+			},
+		},
 	}
 }
 
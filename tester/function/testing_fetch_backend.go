@@ -0,0 +1,33 @@
+package function
+
+import (
+	"github.com/ysugimoto/falco/v2/interpreter"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+const Testing_fetch_backend_Name = "testing.fetch_backend"
+
+func Testing_fetch_backend_Validate(args []value.Value) error {
+	if len(args) > 0 {
+		return errors.ArgumentMustEmpty(Testing_fetch_backend_Name, args)
+	}
+	return nil
+}
+
+// Send bereq to the backend, or the mock backend, and set the response to beresp
+func Testing_fetch_backend(
+	ctx *context.Context,
+	i *interpreter.Interpreter,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_fetch_backend_Validate(args); err != nil {
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+	if err := i.TestFetchBackend(); err != nil {
+		return value.Null, errors.NewTestingError("Failed to fetch backend: %s", err.Error())
+	}
+	return value.Null, nil
+}
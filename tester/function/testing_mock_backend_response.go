@@ -0,0 +1,93 @@
+package function
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+const Testing_mock_backend_response_Name = "testing.mock_backend_response"
+
+var Testing_mock_backend_response_ArgumentTypes = []value.Type{
+	value.StringType,  // backend name pattern, BACKEND is also accepted
+	value.StringType,  // method
+	value.StringType,  // path pattern
+	value.IntegerType, // status
+	value.StringType,  // body
+	value.StringType,  // headers
+	value.RTimeType,   // latency
+}
+
+func Testing_mock_backend_response_Validate(args []value.Value) error {
+	if len(args) < 4 || len(args) > 7 {
+		return errors.ArgumentNotInRange(Testing_mock_backend_response_Name, 4, 7, args)
+	}
+
+	for i := range args {
+		expect := Testing_mock_backend_response_ArgumentTypes[i]
+		if i == 0 && args[i].Type() == value.BackendType {
+			continue
+		}
+		if args[i].Type() != expect {
+			return errors.TypeMismatch(Testing_mock_backend_response_Name, i+1, expect, args[i].Type())
+		}
+	}
+	return nil
+}
+
+// Register mock backend response which takes precedence over the mocks in the configuration.
+// Headers are provided as "Name: value" lines which are separated by LF
+func Testing_mock_backend_response(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_mock_backend_response_Validate(args); err != nil {
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+
+	mock := &config.MockBackend{
+		Method: value.Unwrap[*value.String](args[1]).Value,
+		Path:   value.Unwrap[*value.String](args[2]).Value,
+		Status: int(value.Unwrap[*value.Integer](args[3]).Value),
+	}
+	if args[0].Type() == value.BackendType {
+		mock.Backend = value.Unwrap[*value.Backend](args[0]).String()
+	} else {
+		mock.Backend = value.Unwrap[*value.String](args[0]).Value
+	}
+	if len(args) > 4 {
+		mock.Body = value.Unwrap[*value.String](args[4]).Value
+	}
+	if len(args) > 5 {
+		headers := value.Unwrap[*value.String](args[5]).Value
+		mock.Headers = make(map[string]string)
+		for line := range strings.SplitSeq(headers, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			name, val, found := strings.Cut(line, ":")
+			if !found {
+				return value.Null, errors.NewTestingError(
+					"Invalid header line %q for %s, must be \"Name: value\" format", line, Testing_mock_backend_response_Name,
+				)
+			}
+			mock.Headers[strings.TrimSpace(name)] = strings.TrimSpace(val)
+		}
+	}
+	if len(args) > 6 {
+		mock.Latency = fmt.Sprint(value.Unwrap[*value.RTime](args[6]).Value)
+	}
+
+	compiled, err := context.NewMockBackend(mock)
+	if err != nil {
+		return value.Null, errors.NewTestingError("%s", err.Error())
+	}
+	// Prepend to match before the mocks which are registered earlier and provided from the configuration
+	ctx.MockBackends = append([]*context.MockBackend{compiled}, ctx.MockBackends...)
+	return value.Null, nil
+}
@@ -0,0 +1,113 @@
+package function
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+func Test_Testing_mock_backend_response(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []value.Value
+		expect  *config.MockBackend
+		isError bool
+	}{
+		{
+			name: "minimum arguments",
+			args: []value.Value{
+				&value.String{Value: "F_*"},
+				&value.String{Value: "GET"},
+				&value.String{Value: "/api/**"},
+				&value.Integer{Value: 503},
+			},
+			expect: &config.MockBackend{Backend: "F_*", Method: "GET", Path: "/api/**", Status: 503},
+		},
+		{
+			name: "full arguments with backend",
+			args: []value.Value{
+				&value.Backend{Value: &ast.BackendDeclaration{Name: &ast.Ident{Value: "F_origin"}}},
+				&value.String{Value: ""},
+				&value.String{Value: ""},
+				&value.Integer{Value: 200},
+				&value.String{Value: "ok"},
+				&value.String{Value: "Content-Type: text/plain\nX-Foo:  bar  \n"},
+				&value.RTime{Value: 100 * time.Millisecond},
+			},
+			expect: &config.MockBackend{
+				Backend: "F_origin",
+				Status:  200,
+				Body:    "ok",
+				Headers: map[string]string{"Content-Type": "text/plain", "X-Foo": "bar"},
+				Latency: "100ms",
+			},
+		},
+		{
+			name: "invalid header line",
+			args: []value.Value{
+				&value.String{Value: "F_origin"},
+				&value.String{Value: "GET"},
+				&value.String{Value: "/"},
+				&value.Integer{Value: 200},
+				&value.String{Value: ""},
+				&value.String{Value: "invalid"},
+			},
+			isError: true,
+		},
+		{
+			name: "invalid glob pattern",
+			args: []value.Value{
+				&value.String{Value: "F_origin"},
+				&value.String{Value: "GET"},
+				&value.String{Value: "/api/[a"},
+				&value.Integer{Value: 200},
+			},
+			isError: true,
+		},
+		{
+			name: "argument type mismatch",
+			args: []value.Value{
+				&value.String{Value: "F_origin"},
+				&value.String{Value: "GET"},
+				&value.String{Value: "/"},
+				&value.String{Value: "200"},
+			},
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registered := &config.MockBackend{Backend: "registered"}
+			mocks, err := context.NewMockBackends([]*config.MockBackend{registered})
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			c := &context.Context{MockBackends: mocks}
+			_, err = Testing_mock_backend_response(c, tt.args...)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			// Mock should be prepended to take precedence
+			var actual []*config.MockBackend
+			for _, m := range c.MockBackends {
+				actual = append(actual, m.MockBackend)
+			}
+			if diff := cmp.Diff([]*config.MockBackend{tt.expect, registered}, actual); diff != "" {
+				t.Errorf("Mock backends mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package function
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+const Testing_origin_request_Name = "testing.origin_request"
+
+var Testing_origin_request_ArgumentTypes = []value.Type{value.StringType, value.IntegerType}
+
+func Testing_origin_request_Validate(args []value.Value) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.ArgumentNotInRange(Testing_origin_request_Name, 1, 2, args)
+	}
+	for i := range args {
+		if args[i].Type() != Testing_origin_request_ArgumentTypes[i] {
+			return errors.TypeMismatch(
+				Testing_origin_request_Name, i+1, Testing_origin_request_ArgumentTypes[i], args[i].Type(),
+			)
+		}
+	}
+	return nil
+}
+
+// Returns the field of the recorded origin request, the last request is used when the index is not provided.
+// Field accepts "backend", "method", "url", "path", "body" or "http.{name}" for the header value
+func Testing_origin_request(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_origin_request_Validate(args); err != nil {
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+
+	if len(ctx.OriginRequests) == 0 {
+		return value.Null, errors.NewTestingError("No origin request is recorded")
+	}
+	index := len(ctx.OriginRequests) - 1
+	if len(args) > 1 {
+		index = int(value.Unwrap[*value.Integer](args[1]).Value)
+		if index < 0 || index >= len(ctx.OriginRequests) {
+			return value.Null, errors.NewTestingError(
+				"Origin request index %d is out of range, %d request(s) are recorded", index, len(ctx.OriginRequests),
+			)
+		}
+	}
+	req := ctx.OriginRequests[index]
+
+	field := value.Unwrap[*value.String](args[0]).Value
+	switch {
+	case field == "backend":
+		return &value.String{Value: req.Backend}, nil
+	case field == "method":
+		return &value.String{Value: req.Method}, nil
+	case field == "url":
+		return &value.String{Value: req.URL}, nil
+	case field == "path":
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			return value.Null, errors.NewTestingError("Failed to parse origin request URL %s: %s", req.URL, err)
+		}
+		if u.Path == "" {
+			return &value.String{Value: "/"}, nil
+		}
+		return &value.String{Value: u.Path}, nil
+	case field == "body":
+		return &value.String{Value: req.Body}, nil
+	case strings.HasPrefix(field, "http."):
+		values := req.Header.Values(strings.TrimPrefix(field, "http."))
+		if len(values) == 0 {
+			return &value.String{IsNotSet: true}, nil
+		}
+		return &value.String{Value: strings.Join(values, ", ")}, nil
+	}
+	return value.Null, errors.NewTestingError("Unknown field %s for %s", field, Testing_origin_request_Name)
+}
@@ -0,0 +1,53 @@
+package function
+
+import (
+	"github.com/gobwas/glob"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+const Testing_origin_request_count_Name = "testing.origin_request_count"
+
+func Testing_origin_request_count_Validate(args []value.Value) error {
+	if len(args) > 1 {
+		return errors.ArgumentNotInRange(Testing_origin_request_count_Name, 0, 1, args)
+	}
+	if len(args) == 1 && args[0].Type() != value.StringType && args[0].Type() != value.BackendType {
+		return errors.TypeMismatch(Testing_origin_request_count_Name, 1, value.StringType, args[0].Type())
+	}
+	return nil
+}
+
+// Returns the number of recorded origin requests, filtered by the backend name pattern if provided
+func Testing_origin_request_count(
+	ctx *context.Context,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_origin_request_count_Validate(args); err != nil {
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+	if len(args) == 0 {
+		return &value.Integer{Value: int64(len(ctx.OriginRequests))}, nil
+	}
+
+	var pattern string
+	if args[0].Type() == value.BackendType {
+		pattern = value.Unwrap[*value.Backend](args[0]).String()
+	} else {
+		pattern = value.Unwrap[*value.String](args[0]).Value
+	}
+	p, err := glob.Compile(pattern)
+	if err != nil {
+		return value.Null, errors.NewTestingError("Invalid glob pattern is provided: %s, %s", pattern, err)
+	}
+
+	var count int64
+	for _, req := range ctx.OriginRequests {
+		if p.Match(req.Backend) {
+			count++
+		}
+	}
+	return &value.Integer{Value: count}, nil
+}
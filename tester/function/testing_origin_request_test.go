@@ -0,0 +1,150 @@
+package function
+
+import (
+	ghttp "net/http"
+	"testing"
+
+	"github.com/ysugimoto/falco/v2/ast"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+)
+
+func Test_Testing_origin_request(t *testing.T) {
+	c := &context.Context{
+		OriginRequests: []*context.OriginRequest{
+			{
+				Backend: "F_origin",
+				Method:  "GET",
+				URL:     "https://example.com:443",
+				Header:  ghttp.Header{"X-Foo": {"foo"}},
+			},
+			{
+				Backend: "F_api",
+				Method:  "POST",
+				URL:     "https://api.example.com:443/v1/users?page=1",
+				Header:  ghttp.Header{"X-Foo": {"bar", "baz"}},
+				Body:    `{"name":"falco"}`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		args    []value.Value
+		expect  *value.String
+		isError bool
+	}{
+		{
+			name:   "last request method",
+			args:   []value.Value{&value.String{Value: "method"}},
+			expect: &value.String{Value: "POST"},
+		},
+		{
+			name:   "last request url",
+			args:   []value.Value{&value.String{Value: "url"}},
+			expect: &value.String{Value: "https://api.example.com:443/v1/users?page=1"},
+		},
+		{
+			name:   "last request body",
+			args:   []value.Value{&value.String{Value: "body"}},
+			expect: &value.String{Value: `{"name":"falco"}`},
+		},
+		{
+			name:   "last request header",
+			args:   []value.Value{&value.String{Value: "http.X-Foo"}},
+			expect: &value.String{Value: "bar, baz"},
+		},
+		{
+			name:   "not set header",
+			args:   []value.Value{&value.String{Value: "http.X-Bar"}},
+			expect: &value.String{IsNotSet: true},
+		},
+		{
+			name:   "first request backend",
+			args:   []value.Value{&value.String{Value: "backend"}, &value.Integer{Value: 0}},
+			expect: &value.String{Value: "F_origin"},
+		},
+		{
+			name:   "first request empty path",
+			args:   []value.Value{&value.String{Value: "path"}, &value.Integer{Value: 0}},
+			expect: &value.String{Value: "/"},
+		},
+		{
+			name:    "index out of range",
+			args:    []value.Value{&value.String{Value: "url"}, &value.Integer{Value: 2}},
+			isError: true,
+		},
+		{
+			name:    "unknown field",
+			args:    []value.Value{&value.String{Value: "status"}},
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret, err := Testing_origin_request(c, tt.args...)
+			if tt.isError {
+				if err == nil {
+					t.Errorf("Expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			v := value.Unwrap[*value.String](ret)
+			if v.Value != tt.expect.Value || v.IsNotSet != tt.expect.IsNotSet {
+				t.Errorf("Return value mismatch, expect=%v, got=%v", tt.expect, v)
+			}
+		})
+	}
+
+	t.Run("no recorded request", func(t *testing.T) {
+		_, err := Testing_origin_request(&context.Context{}, &value.String{Value: "url"})
+		if err == nil {
+			t.Errorf("Expected error but got nil")
+		}
+	})
+}
+
+func Test_Testing_origin_request_count(t *testing.T) {
+	c := &context.Context{
+		OriginRequests: []*context.OriginRequest{
+			{Backend: "F_origin"},
+			{Backend: "F_api"},
+			{Backend: "F_api"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		args   []value.Value
+		expect int64
+	}{
+		{name: "all requests", expect: 3},
+		{name: "filter by name", args: []value.Value{&value.String{Value: "F_api"}}, expect: 2},
+		{name: "filter by pattern", args: []value.Value{&value.String{Value: "F_*"}}, expect: 3},
+		{
+			name: "filter by backend",
+			args: []value.Value{
+				&value.Backend{Value: &ast.BackendDeclaration{Name: &ast.Ident{Value: "F_origin"}}},
+			},
+			expect: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ret, err := Testing_origin_request_count(c, tt.args...)
+			if err != nil {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+			if v := value.Unwrap[*value.Integer](ret).Value; v != tt.expect {
+				t.Errorf("Count mismatch, expect=%d, got=%d", tt.expect, v)
+			}
+		})
+	}
+}
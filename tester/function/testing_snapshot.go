@@ -0,0 +1,137 @@
+package function
+
+import (
+	"maps"
+	"strings"
+
+	"github.com/ysugimoto/falco/v2/interpreter"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/function/errors"
+	"github.com/ysugimoto/falco/v2/interpreter/process"
+	"github.com/ysugimoto/falco/v2/interpreter/value"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+)
+
+const Testing_snapshot_Name = "testing.snapshot"
+
+var Testing_snapshot_ArgumentTypes = []value.Type{value.StringType}
+
+func Testing_snapshot_Validate(args []value.Value) error {
+	if len(args) != 1 {
+		return errors.ArgumentNotEnough(Testing_snapshot_Name, 1, args)
+	}
+	if args[0].Type() != Testing_snapshot_ArgumentTypes[0] {
+		return errors.TypeMismatch(Testing_snapshot_Name, 1, Testing_snapshot_ArgumentTypes[0], args[0].Type())
+	}
+	return nil
+}
+
+// Normalized process flow which is stored as the snapshot.
+// Locations in VCL files are excluded so that the snapshot is stable on refactoring
+type snapshot struct {
+	Flows    []*snapshotFlow `json:"flows"`
+	Logs     []*snapshotLog  `json:"logs"`
+	Restarts int             `json:"restarts"`
+}
+
+type snapshotFlow struct {
+	Subroutine      string            `json:"subroutine,omitempty"`
+	Name            string            `json:"name,omitempty"`
+	Scope           string            `json:"scope"`
+	Request         *process.HttpFlow `json:"req,omitempty"`
+	BackendRequest  *process.HttpFlow `json:"bereq,omitempty"`
+	BackendResponse *process.HttpFlow `json:"beresp,omitempty"`
+	Response        *process.HttpFlow `json:"resp,omitempty"`
+}
+
+type snapshotLog struct {
+	Scope   string `json:"scope"`
+	Message string `json:"message"`
+}
+
+// Headers which have different values on each run are masked
+var snapshotVolatileHeaders = []string{"date", "x-timer"}
+
+func newSnapshot(ctx *context.Context, p *process.Process, defs *Definiions, name string) *snapshot {
+	s := &snapshot{
+		Flows:    []*snapshotFlow{},
+		Logs:     []*snapshotLog{},
+		Restarts: ctx.Restarts,
+	}
+	for _, f := range p.Flows {
+		// Subroutines in testing VCL are not a part of the processing flow
+		if _, ok := defs.Subroutines[f.Subroutine]; ok {
+			continue
+		}
+		s.Flows = append(s.Flows, newSnapshotFlow(f))
+	}
+	// Flows are recorded on entering subroutines so add the current state as the last flow
+	s.Flows = append(s.Flows, newSnapshotFlow(process.NewFlow(ctx, process.WithName(name))))
+	for _, l := range p.Logs {
+		s.Logs = append(s.Logs, &snapshotLog{
+			Scope:   l.Scope,
+			Message: l.Message,
+		})
+	}
+	return s
+}
+
+func newSnapshotFlow(f *process.Flow) *snapshotFlow {
+	return &snapshotFlow{
+		Subroutine:      f.Subroutine,
+		Name:            f.Name,
+		Scope:           f.Scope,
+		Request:         maskVolatileHeaders(f.Request),
+		BackendRequest:  maskVolatileHeaders(f.BackendRequest),
+		BackendResponse: maskVolatileHeaders(f.BackendResponse),
+		Response:        maskVolatileHeaders(f.Response),
+	}
+}
+
+func maskVolatileHeaders(f *process.HttpFlow) *process.HttpFlow {
+	if f == nil {
+		return nil
+	}
+	masked := *f
+	masked.Headers = maps.Clone(f.Headers)
+	for _, key := range snapshotVolatileHeaders {
+		if _, ok := masked.Headers[key]; ok {
+			masked.Headers[key] = "[masked]"
+		}
+	}
+	return &masked
+}
+
+// Compare the processing flow of the test until this function is called with the stored snapshot.
+// The snapshot is stored instead of comparing on update mode
+func Testing_snapshot(
+	ctx *context.Context,
+	i *interpreter.Interpreter,
+	defs *Definiions,
+	s *shared.Snapshots,
+	args ...value.Value,
+) (value.Value, error) {
+
+	if err := Testing_snapshot_Validate(args); err != nil {
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+
+	name := value.Unwrap[*value.String](args[0]).Value
+	diff, err := s.Match(name, newSnapshot(ctx, i.Process(), defs, name))
+	if err != nil {
+		if err == shared.ErrSnapshotNotFound {
+			return &value.Boolean{}, errors.NewAssertionError(
+				&value.String{Value: ""},
+				"Snapshot %q does not exist, run with --update-snapshots to store it", name,
+			)
+		}
+		return nil, errors.NewTestingError("%s", err.Error())
+	}
+	if diff != "" {
+		return &value.Boolean{}, errors.NewAssertionError(
+			&value.String{Value: ""},
+			"Snapshot %q mismatch\n%s", name, strings.TrimSpace(diff),
+		)
+	}
+	return &value.Boolean{Value: true}, nil
+}
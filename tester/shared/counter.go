@@ -24,3 +24,11 @@ func (c *Counter) Fail() {
 func (c *Counter) Skip() {
 	c.Skips++
 }
+
+// Merge adds the counts of another counter, used for aggregating results of test files
+func (c *Counter) Merge(o *Counter) {
+	c.Asserts += o.Asserts
+	c.Passes += o.Passes
+	c.Fails += o.Fails
+	c.Skips += o.Skips
+}
@@ -1,7 +1,10 @@
 package shared
 
 import (
+	"cmp"
+	"maps"
 	"math"
+	"slices"
 	"sync"
 
 	"github.com/ysugimoto/falco/v2/ast"
@@ -34,6 +37,7 @@ type Coverage struct {
 	Statements  *sync.Map // map[string]uint64
 	Branches    *sync.Map // map[string]uint64
 	NodeMap     *sync.Map // map[string]token.Token
+	Names       *sync.Map // map[string]string, subroutine names
 }
 
 func NewCoverage() *Coverage {
@@ -42,6 +46,7 @@ func NewCoverage() *Coverage {
 		Statements:  &sync.Map{},
 		Branches:    &sync.Map{},
 		NodeMap:     &sync.Map{},
+		Names:       &sync.Map{},
 	}
 }
 
@@ -66,6 +71,9 @@ func (c *Coverage) MarkBranch(key string) {
 func (c *Coverage) SetupSubroutine(key string, node ast.Node) {
 	c.Subroutines.LoadOrStore(key, uint64(0))
 	c.NodeMap.LoadOrStore(key, node.GetMeta().Token)
+	if sub, ok := node.(*ast.SubroutineDeclaration); ok {
+		c.Names.LoadOrStore(key, sub.Name.Value)
+	}
 }
 
 func (c *Coverage) SetupStatement(key string, node ast.Node) {
@@ -78,12 +86,37 @@ func (c *Coverage) SetupBranch(key string, node ast.Node) {
 	c.NodeMap.LoadOrStore(key, node.GetMeta().Token)
 }
 
+// Merge adds hit counts of another coverage, used for aggregating coverages of test files
+func (c *Coverage) Merge(o *Coverage) {
+	sum := func(dst, src *sync.Map) {
+		src.Range(func(key, val any) bool {
+			if v, loaded := dst.LoadOrStore(key, val); loaded {
+				dst.Store(key, v.(uint64)+val.(uint64)) // nolint:errcheck
+			}
+			return true
+		})
+	}
+	sum(c.Subroutines, o.Subroutines)
+	sum(c.Statements, o.Statements)
+	sum(c.Branches, o.Branches)
+
+	o.NodeMap.Range(func(key, val any) bool {
+		c.NodeMap.LoadOrStore(key, val)
+		return true
+	})
+	o.Names.Range(func(key, val any) bool {
+		c.Names.LoadOrStore(key, val)
+		return true
+	})
+}
+
 func (c *Coverage) Factory() *CoverageFactory {
 	r := &CoverageFactory{
 		Subroutines: make(CoverageFactoryItem),
 		Statements:  make(CoverageFactoryItem),
 		Branches:    make(CoverageFactoryItem),
 		NodeMap:     make(map[string]token.Token),
+		Names:       make(map[string]string),
 	}
 
 	c.Subroutines.Range(func(key, val any) bool {
@@ -102,6 +135,10 @@ func (c *Coverage) Factory() *CoverageFactory {
 		r.NodeMap[key.(string)] = val.(token.Token) // nolint:errcheck
 		return true
 	})
+	c.Names.Range(func(key, val any) bool {
+		r.Names[key.(string)] = val.(string) // nolint:errcheck
+		return true
+	})
 
 	return r
 }
@@ -113,6 +150,7 @@ type CoverageFactory struct {
 	Statements  CoverageFactoryItem
 	Branches    CoverageFactoryItem
 	NodeMap     map[string]token.Token
+	Names       map[string]string
 }
 
 func (c *CoverageFactory) Report() *CoverageReport {
@@ -155,3 +193,116 @@ type CoverageReport struct {
 	Statements  *CoverageReportItem
 	Branches    *CoverageReportItem
 }
+
+// CoverageFile is the coverage of a source file which is aggregated by line
+type CoverageFile struct {
+	File        string                `json:"file"`
+	Lines       []*CoverageLine       `json:"lines"`
+	Subroutines []*CoverageSubroutine `json:"subroutines"`
+	Branches    []*CoverageBranch     `json:"branches"`
+}
+
+// CoverageLine is the hit count of the line, the maximum count of statements on the line
+type CoverageLine struct {
+	Line int    `json:"line"`
+	Hits uint64 `json:"hits"`
+}
+
+type CoverageSubroutine struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	Hits uint64 `json:"hits"`
+}
+
+// CoverageBranch is the hit count of the branch.
+// Block is the index of the branching node like if statement in the file,
+// and Branch is the index of the branch in the block
+type CoverageBranch struct {
+	Line   int    `json:"line"`
+	Block  int    `json:"block"`
+	Branch int    `json:"branch"`
+	Hits   uint64 `json:"hits"`
+}
+
+// Files aggregates the coverage for each source file by line, sorted by file name
+func (c *CoverageFactory) Files() []*CoverageFile {
+	files := make(map[string]*CoverageFile)
+	lines := make(map[string]map[int]uint64)
+	getFile := func(name string) *CoverageFile {
+		if _, ok := files[name]; !ok {
+			files[name] = &CoverageFile{File: name}
+			lines[name] = make(map[int]uint64)
+		}
+		return files[name]
+	}
+	markLine := func(tok token.Token, hits uint64) {
+		getFile(tok.File)
+		if prev, ok := lines[tok.File][tok.Line]; !ok || prev < hits {
+			lines[tok.File][tok.Line] = hits
+		}
+	}
+
+	for id, hits := range c.Subroutines {
+		tok := c.NodeMap[id]
+		f := getFile(tok.File)
+		f.Subroutines = append(f.Subroutines, &CoverageSubroutine{
+			Name: c.Names[id],
+			Line: tok.Line,
+			Hits: hits,
+		})
+		markLine(tok, hits)
+	}
+	for id, hits := range c.Statements {
+		markLine(c.NodeMap[id], hits)
+	}
+
+	// Branches which belong to the same node are grouped as a block
+	branchIds := slices.SortedFunc(maps.Keys(c.Branches), func(a, b string) int {
+		ta, tb := c.NodeMap[a], c.NodeMap[b]
+		return cmp.Or(
+			cmp.Compare(ta.File, tb.File),
+			cmp.Compare(ta.Line, tb.Line),
+			cmp.Compare(ta.Position, tb.Position),
+			cmp.Compare(len(a), len(b)), // keep numeric suffix order like _2 and _10
+			cmp.Compare(a, b),
+		)
+	})
+	blocks := make(map[string]int)
+	var prev token.Token
+	for i, id := range branchIds {
+		tok := c.NodeMap[id]
+		f := getFile(tok.File)
+		if i == 0 || tok.File != prev.File || tok.Line != prev.Line || tok.Position != prev.Position {
+			blocks[tok.File]++
+		}
+		var branch int
+		if n := len(f.Branches); n > 0 && f.Branches[n-1].Block == blocks[tok.File]-1 {
+			branch = f.Branches[n-1].Branch + 1
+		}
+		f.Branches = append(f.Branches, &CoverageBranch{
+			Line:   tok.Line,
+			Block:  blocks[tok.File] - 1,
+			Branch: branch,
+			Hits:   c.Branches[id],
+		})
+		prev = tok
+	}
+
+	var result []*CoverageFile
+	for name, f := range files {
+		for line, hits := range lines[name] {
+			f.Lines = append(f.Lines, &CoverageLine{Line: line, Hits: hits})
+		}
+		slices.SortFunc(f.Lines, func(a, b *CoverageLine) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+		slices.SortFunc(f.Subroutines, func(a, b *CoverageSubroutine) int {
+			return cmp.Compare(a.Line, b.Line)
+		})
+		result = append(result, f)
+	}
+	slices.SortFunc(result, func(a, b *CoverageFile) int {
+		return cmp.Compare(a.File, b.File)
+	})
+	return result
+}
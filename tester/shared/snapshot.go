@@ -0,0 +1,204 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Directory name to store snapshot files, placed next to the test file
+const SnapshotDirectory = "__snapshots__"
+
+var ErrSnapshotNotFound = errors.New("Snapshot is not found")
+
+// Number of unchanged lines shown around the changed lines in the snapshot difference
+const snapshotDiffContext = 3
+
+// Snapshots manages the snapshots of a test file.
+// Snapshots are stored in a JSON file as an object which is keyed by the test, scope and snapshot name
+// because a test runs on each scope
+type Snapshots struct {
+	mu       sync.Mutex
+	file     string
+	update   bool
+	test     string // key prefix of the running test
+	stored   map[string]json.RawMessage
+	taken    map[string]struct{}
+	modified bool
+}
+
+// SnapshotFile returns the snapshot file path of the test file
+func SnapshotFile(testFile string) string {
+	return filepath.Join(filepath.Dir(testFile), SnapshotDirectory, filepath.Base(testFile)+".snap")
+}
+
+// LoadSnapshots reads stored snapshots from the file, the file may not exist.
+// When update is true, taken snapshots overwrite the stored ones instead of comparing
+func LoadSnapshots(file string, update bool) (*Snapshots, error) {
+	s := &Snapshots{
+		file:   file,
+		update: update,
+		stored: make(map[string]json.RawMessage),
+		taken:  make(map[string]struct{}),
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	if err := json.Unmarshal(buf, &s.stored); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to decode snapshot file %s", file)
+	}
+	return s, nil
+}
+
+// SetTest sets the running test and scope, following snapshots are taken for them
+func (s *Snapshots) SetTest(test, scope string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.test = fmt.Sprintf("%s [%s] ", test, scope)
+}
+
+// Match compares the snapshot with the stored one and returns the difference, empty string means matched.
+// ErrSnapshotNotFound is returned when the snapshot is not stored yet and not on update mode
+func (s *Snapshots) Match(name string, snapshot any) (string, error) {
+	actual, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := s.test + name
+	if _, ok := s.taken[key]; ok {
+		return "", errors.Errorf("Snapshot %q is already taken in this test, snapshot name must be unique", name)
+	}
+	s.taken[key] = struct{}{}
+
+	stored, ok := s.stored[key]
+	var expect bytes.Buffer
+	if ok {
+		if err := json.Indent(&expect, stored, "", "  "); err != nil {
+			return "", errors.WithMessagef(err, "Failed to decode snapshot %q", name)
+		}
+	}
+
+	if s.update {
+		if !ok || !bytes.Equal(expect.Bytes(), actual) {
+			s.stored[key] = actual
+			s.modified = true
+		}
+		return "", nil
+	}
+	if !ok {
+		return "", ErrSnapshotNotFound
+	}
+	if bytes.Equal(expect.Bytes(), actual) {
+		return "", nil
+	}
+	return diffLines(expect.String(), string(actual)), nil
+}
+
+// Save writes snapshots to the file if any snapshot is updated.
+// Snapshots which are not taken in the run are kept as they are
+func (s *Snapshots) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.modified {
+		return nil
+	}
+	buf, err := json.MarshalIndent(s.stored, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0o755); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := os.WriteFile(s.file, append(buf, '\n'), 0o644); err != nil {
+		return errors.WithStack(err)
+	}
+	s.modified = false
+	return nil
+}
+
+// diffLines returns line-based difference between expect and actual.
+// Removed lines are prefixed with "-", added lines are prefixed with "+",
+// and unchanged lines around them are shown as context
+func diffLines(expect, actual string) string {
+	a := strings.Split(expect, "\n")
+	b := strings.Split(actual, "\n")
+
+	// Compute the longest common subsequence table from the tail
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		mark byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, line{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, line{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, line{'+', b[j]})
+			j++
+		}
+	}
+
+	// Show only changed lines and their context
+	visible := make([]bool, len(lines))
+	for n, l := range lines {
+		if l.mark == ' ' {
+			continue
+		}
+		for k := max(0, n-snapshotDiffContext); k <= min(len(lines)-1, n+snapshotDiffContext); k++ {
+			visible[k] = true
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("--- stored\n+++ actual")
+	skipped := false
+	for n, l := range lines {
+		if !visible[n] {
+			skipped = true
+			continue
+		}
+		if skipped || n == 0 {
+			out.WriteString("\n@@")
+			skipped = false
+		}
+		fmt.Fprintf(&out, "\n%c %s", l.mark, l.text)
+	}
+	return out.String()
+}
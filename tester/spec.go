@@ -0,0 +1,321 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	ghttp "net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/interpreter/process"
+	"gopkg.in/yaml.v3"
+)
+
+// Scope name of test cases which are run from spec files
+const SpecScope = "HTTP"
+
+// SpecFile is declarative HTTP-level integration test file.
+// Spec files are written in YAML or JSON, and specs in the file are run in declared order
+// against the same interpreter so the cache is kept between specs like an actual edge node.
+type SpecFile struct {
+	Specs []*Spec `yaml:"specs"`
+}
+
+type Spec struct {
+	Name    string           `yaml:"name"`
+	Skip    bool             `yaml:"skip"`
+	Request *SpecRequest     `yaml:"request"`
+	Origin  []*SpecResponse  `yaml:"origin"` // responses are returned in order and the last one is repeated
+	Expect  *SpecExpectation `yaml:"expect"`
+}
+
+type SpecRequest struct {
+	Method  string            `yaml:"method"`
+	URL     string            `yaml:"url"` // path or absolute URL
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+type SpecResponse struct {
+	Status  int               `yaml:"status"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+}
+
+// SpecExpectation describes the expected client response and processing result.
+// Unspecified fields are not checked.
+type SpecExpectation struct {
+	Status         int                `yaml:"status"`
+	Headers        map[string]*string `yaml:"headers"` // null value expects the header does not exist
+	Body           *string            `yaml:"body"`
+	BodyContains   string             `yaml:"body_contains"`
+	Cached         *bool              `yaml:"cached"`
+	State          string             `yaml:"state"` // same as fastly_info.state
+	Restarts       *int               `yaml:"restarts"`
+	Logs           []string           `yaml:"logs"` // each message must be logged
+	OriginRequests *int               `yaml:"origin_requests"`
+	Error          string             `yaml:"error"` // processing error message should contain
+}
+
+func loadSpecFile(file string) (*SpecFile, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	// JSON is also decodable as YAML
+	var sf SpecFile
+	if err := yaml.Unmarshal(buf, &sf); err != nil {
+		return nil, errors.WithMessagef(err, "Failed to decode spec file %s", file)
+	}
+	for i, s := range sf.Specs {
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("spec #%d", i+1)
+		}
+		if s.Request == nil {
+			return nil, errors.Errorf("%s: request is required in %s", file, s.Name)
+		}
+	}
+	return &sf, nil
+}
+
+// SpecError reports unsatisfied expectations of the spec
+type SpecError struct {
+	Failures []string
+}
+
+func (e *SpecError) Error() string {
+	return strings.Join(e.Failures, "\n")
+}
+
+// specOrigin is the local stub origin which responds mocked responses of the running spec
+type specOrigin struct {
+	mu        sync.Mutex
+	responses []*SpecResponse
+	requests  int
+}
+
+func (o *specOrigin) reset(responses []*SpecResponse) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.responses = responses
+	o.requests = 0
+}
+
+func (o *specOrigin) received() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.requests
+}
+
+func (o *specOrigin) ServeHTTP(w ghttp.ResponseWriter, r *ghttp.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	io.Copy(io.Discard, r.Body) // nolint:errcheck
+
+	var resp *SpecResponse
+	if len(o.responses) > 0 {
+		resp = o.responses[min(o.requests, len(o.responses)-1)]
+	}
+	o.requests++
+	if resp == nil {
+		w.WriteHeader(ghttp.StatusOK)
+		return
+	}
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	status := resp.Status
+	if status == 0 {
+		status = ghttp.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, resp.Body) // nolint:errcheck
+}
+
+// Run specs in the spec file through the full request lifecycle of the interpreter
+func (t *Tester) runSpecFile(specFile string, r *testRun) (*TestResult, error) {
+	sf, err := loadSpecFile(specFile)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	origin := &specOrigin{}
+	server := httptest.NewServer(origin)
+	defer server.Close()
+
+	// All backends are routed to the stub origin
+	opts := slices.Clone(t.interpreterOptions)
+	opts = append(opts,
+		context.WithActualResponse(true),
+		context.WithOriginRequestRecording(true),
+		context.WithOverrideBackends(map[string]*config.OverrideBackend{
+			"*": {Host: server.Listener.Addr().String()},
+		}),
+	)
+	if r.coverage != nil {
+		opts = append(opts, context.WithCoverage(r.coverage))
+	}
+	i := interpreter.New(opts...)
+
+	var cases []*TestCase
+	for _, s := range sf.Specs {
+		if s.Skip {
+			cases = append(cases, &TestCase{Name: s.Name, Scope: SpecScope, Skip: true})
+			r.counter.Skip()
+			continue
+		}
+
+		req, err := s.Request.httpRequest()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "%s: invalid request in %s", specFile, s.Name)
+		}
+		origin.reset(s.Origin)
+		d := NewDebugger()
+		i.Debugger = d
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		i.ServeHTTP(w, req)
+		tc := &TestCase{
+			Name:  s.Name,
+			Scope: SpecScope,
+			Time:  time.Since(start).Milliseconds(),
+			Logs:  d.stack,
+		}
+		if failures := s.check(r, w.Result(), i, origin.received()); len(failures) > 0 {
+			tc.Error = &SpecError{Failures: failures}
+		}
+		cases = append(cases, tc)
+	}
+
+	return &TestResult{
+		Filename: specFile,
+		Cases:    cases,
+	}, nil
+}
+
+func (s *SpecRequest) httpRequest() (*ghttp.Request, error) {
+	u := s.URL
+	if u == "" {
+		u = "/"
+	}
+	if strings.HasPrefix(u, "/") {
+		u = "http://localhost" + u
+	}
+	method := s.Method
+	if method == "" {
+		method = ghttp.MethodGet
+	}
+
+	req, err := ghttp.NewRequest(strings.ToUpper(method), u, strings.NewReader(s.Body))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for k, v := range s.Headers {
+		if strings.EqualFold(k, "host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	// Set default RemoteAddr so that client.ip returns a valid value
+	req.RemoteAddr = "192.0.2.1:11111"
+	return req, nil
+}
+
+// check compares the client response and processing result with the expectation.
+// Each expectation is counted as an assertion, and all failure messages are returned
+func (s *Spec) check(r *testRun, resp *ghttp.Response, i *interpreter.Interpreter, originRequests int) []string {
+	var failures []string
+	assert := func(ok bool, format string, args ...any) {
+		if ok {
+			r.counter.Pass()
+			return
+		}
+		r.counter.Fail()
+		failures = append(failures, fmt.Sprintf(format, args...))
+	}
+
+	p := i.Process()
+	e := s.Expect
+	if e == nil {
+		e = &SpecExpectation{}
+	}
+
+	// Processing error fails the spec unless it is expected
+	var processError string
+	if p.Error != nil {
+		processError = p.Error.Error()
+	}
+	switch {
+	case e.Error != "":
+		assert(
+			strings.Contains(processError, e.Error),
+			"Processing error should contain %q, got %q", e.Error, processError,
+		)
+	case processError != "":
+		assert(false, "Unexpected processing error: %s", processError)
+	}
+
+	if e.Status > 0 {
+		assert(resp.StatusCode == e.Status, "Status code should be %d, got %d", e.Status, resp.StatusCode)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(e.Headers)) {
+		expect := e.Headers[key]
+		values, ok := resp.Header[ghttp.CanonicalHeaderKey(key)]
+		if expect == nil {
+			assert(!ok, "Header %s should not exist, got %q", key, strings.Join(values, ", "))
+			continue
+		}
+		actual := strings.Join(values, ", ")
+		assert(ok && actual == *expect, "Header %s should be %q, got %q", key, *expect, actual)
+	}
+
+	if e.Body != nil || e.BodyContains != "" {
+		buf, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return append(failures, fmt.Sprintf("Failed to read response body: %s", err))
+		}
+		body := string(buf)
+		if e.Body != nil {
+			assert(body == *e.Body, "Body should be %q, got %q", *e.Body, body)
+		}
+		if e.BodyContains != "" {
+			assert(strings.Contains(body, e.BodyContains), "Body should contain %q, got %q", e.BodyContains, body)
+		}
+	}
+
+	if e.Cached != nil {
+		assert(p.Cached == *e.Cached, "Cached should be %t, got %t", *e.Cached, p.Cached)
+	}
+	if e.State != "" {
+		assert(p.State == e.State, "State should be %q, got %q", e.State, p.State)
+	}
+	if e.Restarts != nil {
+		assert(p.Restarts == *e.Restarts, "Restarts should be %d, got %d", *e.Restarts, p.Restarts)
+	}
+	if e.OriginRequests != nil {
+		assert(
+			originRequests == *e.OriginRequests,
+			"Origin requests should be %d, got %d", *e.OriginRequests, originRequests,
+		)
+	}
+
+	for _, log := range e.Logs {
+		assert(slices.ContainsFunc(p.Logs, func(l *process.Log) bool {
+			return l.Message == log
+		}), "Log %q should be logged", log)
+	}
+
+	return failures
+}
@@ -0,0 +1,197 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+)
+
+const specMainVCL = `
+backend F_origin {
+  .host = "example.com";
+  .port = "443";
+  .ssl = true;
+}
+
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.url.path == "/old") {
+    error 601;
+  }
+  set req.backend = F_origin;
+  return (lookup);
+}
+
+sub vcl_fetch {
+  #FASTLY FETCH
+  if (beresp.status >= 500 && req.restarts < 1) {
+    restart;
+  }
+  set beresp.ttl = 60s;
+  return (deliver);
+}
+
+sub vcl_error {
+  #FASTLY ERROR
+  if (obj.status == 601) {
+    set obj.status = 301;
+    set obj.http.Location = "https://example.com/new";
+    synthetic "";
+    return (deliver);
+  }
+}
+
+sub vcl_deliver {
+  #FASTLY DELIVER
+  log "deliver " req.url.path;
+  return (deliver);
+}
+`
+
+func TestRunSpecFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		spec    string
+		expect  map[string][]string // failure messages for each spec
+		counter *shared.Counter
+	}{
+		{
+			name: "yaml spec file",
+			file: "default.spec.yaml",
+			spec: `
+specs:
+  - name: redirect
+    request:
+      url: /old
+    expect:
+      status: 301
+      headers:
+        Location: https://example.com/new
+        X-Foo: null
+      origin_requests: 0
+  - name: miss
+    request:
+      url: /index.html
+    origin:
+      - status: 200
+        headers:
+          Content-Type: text/plain
+        body: hello
+    expect:
+      status: 200
+      headers:
+        Content-Type: text/plain
+      body: hello
+      cached: false
+      state: MISS
+      logs:
+        - deliver /index.html
+  - name: hit
+    request:
+      url: /index.html
+    expect:
+      body_contains: hell
+      cached: true
+      state: HIT
+      origin_requests: 0
+  - name: skipped
+    skip: true
+    request:
+      url: /
+`,
+			expect: map[string][]string{
+				"redirect": nil,
+				"miss":     nil,
+				"hit":      nil,
+				"skipped":  nil,
+			},
+			counter: &shared.Counter{Asserts: 14, Passes: 14, Skips: 1},
+		},
+		{
+			name: "json spec file with failures",
+			file: "default.spec.json",
+			spec: `{
+  "specs": [
+    {
+      "name": "restart",
+      "request": { "method": "post", "url": "/api", "body": "{}" },
+      "origin": [{ "status": 503 }, { "status": 200, "body": "ok", "headers": { "X-Foo": "bar" } }],
+      "expect": {
+        "status": 201,
+        "body": "ok",
+        "restarts": 1,
+        "origin_requests": 2,
+        "headers": { "X-Foo": null }
+      }
+    }
+  ]
+}`,
+			expect: map[string][]string{
+				"restart": {
+					"Status code should be 201, got 200",
+					`Header X-Foo should not exist, got "bar"`,
+				},
+			},
+			counter: &shared.Counter{Asserts: 5, Passes: 3, Fails: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			main := filepath.Join(dir, "main.vcl")
+			if err := os.WriteFile(main, []byte(specMainVCL), 0o644); err != nil {
+				t.Fatalf("Failed to write main VCL: %s", err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.spec), 0o644); err != nil {
+				t.Fatalf("Failed to write spec file: %s", err)
+			}
+			resolvers, err := resolver.NewFileResolvers(main, nil)
+			if err != nil {
+				t.Fatalf("Failed to create resolver: %s", err)
+			}
+
+			c := &config.TestConfig{
+				Filter:     "*.test.vcl",
+				SpecFilter: "*.spec.{yaml,yml,json}",
+			}
+			factory, err := New(c, []context.Option{context.WithResolver(resolvers[0])}).Run(main)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if len(factory.Results) != 1 {
+				t.Fatalf("Expected 1 result, got %d", len(factory.Results))
+			}
+
+			actual := make(map[string][]string)
+			for _, c := range factory.Results[0].Cases {
+				if c.Scope != SpecScope {
+					t.Errorf("Unexpected scope %s", c.Scope)
+				}
+				actual[c.Name] = nil
+				if c.Error == nil {
+					continue
+				}
+				e, ok := c.Error.(*SpecError)
+				if !ok {
+					t.Errorf("Unexpected error type %T: %s", c.Error, c.Error)
+					continue
+				}
+				actual[c.Name] = e.Failures
+			}
+			if diff := cmp.Diff(tt.expect, actual); diff != "" {
+				t.Errorf("Spec failures mismatch, diff=%s", diff)
+			}
+			if diff := cmp.Diff(tt.counter, factory.Statistics); diff != "" {
+				t.Errorf("Statistics mismatch, diff=%s", diff)
+			}
+		})
+	}
+}
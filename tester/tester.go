@@ -3,7 +3,9 @@ package tester
 import (
 	ghttp "net/http"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -12,7 +14,6 @@ import (
 	"github.com/ysugimoto/falco/v2/config"
 	"github.com/ysugimoto/falco/v2/interpreter"
 	"github.com/ysugimoto/falco/v2/interpreter/context"
-	"github.com/ysugimoto/falco/v2/interpreter/function"
 	"github.com/ysugimoto/falco/v2/interpreter/http"
 	"github.com/ysugimoto/falco/v2/interpreter/value"
 	"github.com/ysugimoto/falco/v2/interpreter/variable"
@@ -45,23 +46,42 @@ func New(c *config.TestConfig, opts []context.Option) *Tester {
 	}
 	if c.Coverage {
 		t.coverage = shared.NewCoverage()
-		t.interpreterOptions = append(t.interpreterOptions, context.WithCoverage(t.coverage))
 	}
+	// Testing variables are stateless so inject them once
+	variable.Inject(&tv.TestingVariables{})
 	return t
 }
 
+// testRun holds states which are isolated for each test file
+// so that test files can run concurrently
+type testRun struct {
+	counter   *shared.Counter
+	coverage  *shared.Coverage
+	snapshots *shared.Snapshots
+}
+
+func (t *Tester) newTestRun() *testRun {
+	r := &testRun{
+		counter: shared.NewCounter(),
+	}
+	if t.coverage != nil {
+		r.coverage = shared.NewCoverage()
+	}
+	return r
+}
+
 // Find test target VCL files
 // Note that:
 // - Test files must have ".test.vcl" extension e.g default.test.vcl
 // - Tester finds files from all include paths
-func (t *Tester) listTestFiles(main string) ([]string, error) {
+func (t *Tester) listTestFiles(main, filter string) ([]string, error) {
 	// correct include paths
 	searchDirs := []string{filepath.Dir(main)}
 	searchDirs = append(searchDirs, t.config.IncludePaths...)
 
 	var testFiles []string
 	for i := range searchDirs {
-		files, err := findTestTargetFiles(searchDirs[i], t.config.Filter)
+		files, err := findTestTargetFiles(searchDirs[i], filter)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -74,18 +94,69 @@ func (t *Tester) listTestFiles(main string) ([]string, error) {
 // Only expose function for running tests
 func (t *Tester) Run(main string) (*TestFactory, error) {
 	// Find test target VCL files
-	targetFiles, err := t.listTestFiles(main)
+	targetFiles, err := t.listTestFiles(main, t.config.Filter)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	// Run tests
-	var results []*TestResult
-	for i := range targetFiles {
-		result, err := t.run(targetFiles[i])
+	runners := make([]func(string, *testRun) (*TestResult, error), len(targetFiles))
+	for i := range runners {
+		runners[i] = t.run
+	}
+	// Find HTTP-level spec files
+	if t.config.SpecFilter != "" {
+		specFiles, err := t.listTestFiles(main, t.config.SpecFilter)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
-		results = append(results, result)
+		for _, file := range specFiles {
+			if slices.Contains(targetFiles, file) {
+				continue
+			}
+			targetFiles = append(targetFiles, file)
+			runners = append(runners, t.runSpecFile)
+		}
+	}
+	// Run tests, results are stored by index to keep the file order regardless of the finish order
+	results := make([]*TestResult, len(targetFiles))
+	runs := make([]*testRun, len(targetFiles))
+	errs := make([]error, len(targetFiles))
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(t.config.Parallel, 1))
+	for i := range targetFiles {
+		sem <- struct{}{}
+		// Stop running remaining files when an error has already occurred
+		if failed.Load() {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			runs[i] = t.newTestRun()
+			results[i], errs[i] = runners[i](targetFiles[i], runs[i])
+			if errs[i] != nil {
+				failed.Store(true)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range targetFiles {
+		if errs[i] != nil {
+			return nil, errors.WithStack(errs[i])
+		}
+		if runs[i] == nil {
+			continue
+		}
+		t.counter.Merge(runs[i].counter)
+		if t.coverage != nil {
+			t.coverage.Merge(runs[i].coverage)
+		}
 	}
 
 	factory := &TestFactory{
@@ -99,7 +170,7 @@ func (t *Tester) Run(main string) (*TestFactory, error) {
 }
 
 // Actually run testing method
-func (t *Tester) run(testFile string) (*TestResult, error) {
+func (t *Tester) run(testFile string, r *testRun) (*TestResult, error) {
 	resolvers, err := resolver.NewFileResolvers(testFile, t.config.IncludePaths)
 	if err != nil {
 		return nil, errors.WithStack(err)
@@ -110,6 +181,11 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 		return nil, errors.WithStack(err)
 	}
 
+	r.snapshots, err = shared.LoadSnapshots(shared.SnapshotFile(testFile), t.config.UpdateSnapshots)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
 	l := lexer.NewFromString(main.Data, lexer.WithFile(main.Name))
 	vcl, err := parser.New(l, parser.WithCustomParser(syntax.CustomParsers()...)).ParseVCL()
 	if err != nil {
@@ -132,7 +208,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 		for _, stmt := range vcl.Statements {
 			switch st := stmt.(type) {
 			case *syntax.DescribeStatement:
-				results, err := t.runDescribedTests(defs, st)
+				results, err := t.runDescribedTests(defs, st, r)
 				if len(results) > 0 {
 					cases = append(cases, results...)
 				}
@@ -143,7 +219,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 			case *ast.SubroutineDeclaration:
 				// Some functions like "testing.table_set()" will take side-effect for another testing subroutine
 				// so we always initialize interpreter, inject testing functions for each subroutine
-				i := t.setupInterpreter(defs)
+				i := t.setupInterpreter(defs, r)
 
 				mockRequest, err := http.NewRequest(ghttp.MethodGet, "http://localhost", ghttp.NoBody)
 				if err != nil {
@@ -169,10 +245,11 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 							Scope: s.String(),
 							Skip:  true,
 						})
-						t.counter.Skip()
+						r.counter.Skip()
 						continue
 					}
 
+					r.snapshots.SetTest(metadata.Name, strings.ToLower(s.String()))
 					start := time.Now()
 					err := i.ProcessTestSubroutine(s, st)
 					cases = append(cases, &TestCase{
@@ -183,7 +260,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 						Logs:  d.stack,
 					})
 					if err != nil {
-						t.counter.Fail()
+						r.counter.Fail()
 					}
 				}
 			}
@@ -199,6 +276,9 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 	case <-timeoutChan:
 		return nil, ErrTimeout
 	case cases := <-finishChan:
+		if err := r.snapshots.Save(); err != nil {
+			return nil, errors.WithStack(err)
+		}
 		return &TestResult{
 			Filename: testFile,
 			Cases:    cases,
@@ -210,6 +290,7 @@ func (t *Tester) run(testFile string) (*TestResult, error) {
 func (t *Tester) runDescribedTests(
 	defs *tf.Definiions,
 	d *syntax.DescribeStatement,
+	r *testRun,
 ) ([]*TestCase, error) {
 
 	var cases []*TestCase
@@ -221,7 +302,7 @@ func (t *Tester) runDescribedTests(
 	mockRequest.RemoteAddr = "192.0.2.1:11111"
 
 	// describe should run as group testing, create interpreter once through tests
-	i := t.setupInterpreter(defs)
+	i := t.setupInterpreter(defs, r)
 
 	if err := i.TestProcessInit(mockRequest); err != nil {
 		return cases, errors.WithStack(err)
@@ -253,10 +334,12 @@ func (t *Tester) runDescribedTests(
 					Scope: s.String(),
 					Skip:  true,
 				})
-				t.counter.Skip()
+				r.counter.Skip()
 				continue
 			}
 
+			r.snapshots.SetTest(d.Name.String()+" › "+metadata.Name, strings.ToLower(s.String()))
+
 			// Run before_xxx hook that corresponds to scope is exists
 			if hook, ok := d.Befores[strings.ToLower("before_"+s.String())]; ok {
 				i.SetScope(s)
@@ -280,7 +363,7 @@ func (t *Tester) runDescribedTests(
 				Logs:  debugger.stack,
 			})
 			if err != nil {
-				t.counter.Fail()
+				r.counter.Fail()
 			}
 
 			// Run after_xxx hook that corresponds to scope is exists
@@ -301,8 +384,13 @@ func (t *Tester) runDescribedTests(
 }
 
 // Set up interprete for each test subroutines
-func (t *Tester) setupInterpreter(defs *tf.Definiions) *interpreter.Interpreter {
-	i := interpreter.New(t.interpreterOptions...)
+func (t *Tester) setupInterpreter(defs *tf.Definiions, r *testRun) *interpreter.Interpreter {
+	// Origin requests are recorded for testing.origin_request functions
+	opts := append(slices.Clone(t.interpreterOptions), context.WithOriginRequestRecording(true))
+	if r.coverage != nil {
+		opts = append(opts, context.WithCoverage(r.coverage))
+	}
+	i := interpreter.New(opts...)
 	i.Debugger = NewDebugger() // store the default debugger
 	i.IdentResolver = func(val string) value.Value {
 		if v, ok := defs.Backends[val]; ok {
@@ -317,8 +405,8 @@ func (t *Tester) setupInterpreter(defs *tf.Definiions) *interpreter.Interpreter
 		}
 		return nil
 	}
-	variable.Inject(&tv.TestingVariables{})
-	function.Inject(tf.TestingFunctions(i, defs, t.counter, t.coverage))
+	// Testing functions are bound to the interpreter so inject them only for this interpreter
+	i.InjectFunctions(tf.TestingFunctions(i, defs, r.counter, r.coverage, r.snapshots))
 
 	return i
 }
@@ -0,0 +1,235 @@
+package tester
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/ysugimoto/falco/v2/config"
+	"github.com/ysugimoto/falco/v2/interpreter/context"
+	"github.com/ysugimoto/falco/v2/lexer"
+	"github.com/ysugimoto/falco/v2/resolver"
+	"github.com/ysugimoto/falco/v2/tester/shared"
+)
+
+const parallelMainVCL = `
+sub vcl_recv {
+  #FASTLY RECV
+  if (req.http.Foo) {
+    set req.http.Bar = "1";
+  } else {
+    set req.http.Bar = "0";
+  }
+  return (lookup);
+}
+`
+
+const parallelTestVCL = `
+// @scope: recv
+// @suite: set header when Foo exists
+sub test_foo_exists {
+  set req.http.Foo = "%d";
+  testing.call_subroutine("vcl_recv");
+  assert.equal(req.http.Bar, "1");
+}
+
+describe group_%d {
+  // @scope: recv
+  sub test_foo_not_exists {
+    testing.call_subroutine("vcl_recv");
+    assert.equal(req.http.Bar, "1");
+  }
+
+  // @skip
+  // @scope: recv
+  sub test_skipped {}
+}
+`
+
+func TestRunParallel(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	if err := os.WriteFile(main, []byte(parallelMainVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write main VCL: %s", err)
+	}
+	for i := range 8 {
+		file := filepath.Join(dir, fmt.Sprintf("t%d.test.vcl", i))
+		if err := os.WriteFile(file, []byte(fmt.Sprintf(parallelTestVCL, i, i)), 0o644); err != nil {
+			t.Fatalf("Failed to write test VCL: %s", err)
+		}
+	}
+
+	run := func(parallel int) *TestFactory {
+		resolvers, err := resolver.NewFileResolvers(main, nil)
+		if err != nil {
+			t.Fatalf("Failed to create resolver: %s", err)
+		}
+		c := &config.TestConfig{
+			Filter:   "*.test.vcl",
+			Parallel: parallel,
+			Coverage: true,
+		}
+		factory, err := New(c, []context.Option{context.WithResolver(resolvers[0])}).Run(main)
+		if err != nil {
+			t.Fatalf("Unexpected error on parallel %d: %s", parallel, err)
+		}
+		return factory
+	}
+
+	sequential := run(1)
+	// Failed test is counted by both of assertion and test runner
+	expect := &shared.Counter{Asserts: 24, Passes: 8, Fails: 16, Skips: 8}
+	if diff := cmp.Diff(expect, sequential.Statistics); diff != "" {
+		t.Errorf("Statistics mismatch, diff=%s", diff)
+	}
+
+	parallel := run(4)
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(TestCase{}, "Time", "Error"),
+		cmpopts.IgnoreTypes(&lexer.Lexer{}),
+	}
+	if diff := cmp.Diff(sequential.Results, parallel.Results, opts...); diff != "" {
+		t.Errorf("Results must be the same order as sequential run, diff=%s", diff)
+	}
+	if diff := cmp.Diff(sequential.Statistics, parallel.Statistics); diff != "" {
+		t.Errorf("Statistics mismatch, diff=%s", diff)
+	}
+	if diff := cmp.Diff(sequential.Coverage, parallel.Coverage); diff != "" {
+		t.Errorf("Coverage mismatch, diff=%s", diff)
+	}
+}
+
+const snapshotMainVCL = `
+sub vcl_recv {
+  #FASTLY RECV
+  set req.http.Foo = "%s";
+  log "recv";
+  return (lookup);
+}
+`
+
+const snapshotTestVCL = `
+// @scope: recv
+sub test_recv {
+  testing.call_subroutine("vcl_recv");
+  testing.snapshot("recv");
+}
+`
+
+func TestRunSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	testFile := filepath.Join(dir, "main.test.vcl")
+	if err := os.WriteFile(testFile, []byte(snapshotTestVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write test VCL: %s", err)
+	}
+
+	run := func(foo string, update bool) error {
+		if err := os.WriteFile(main, []byte(fmt.Sprintf(snapshotMainVCL, foo)), 0o644); err != nil {
+			t.Fatalf("Failed to write main VCL: %s", err)
+		}
+		resolvers, err := resolver.NewFileResolvers(main, nil)
+		if err != nil {
+			t.Fatalf("Failed to create resolver: %s", err)
+		}
+		c := &config.TestConfig{
+			Filter:          "*.test.vcl",
+			UpdateSnapshots: update,
+		}
+		factory, err := New(c, []context.Option{context.WithResolver(resolvers[0])}).Run(main)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return factory.Results[0].Cases[0].Error
+	}
+
+	if err := run("foo", false); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected snapshot not found error, got %v", err)
+	}
+	if err := run("foo", true); err != nil {
+		t.Errorf("Unexpected error on updating snapshots: %s", err)
+	}
+	if _, err := os.Stat(shared.SnapshotFile(testFile)); err != nil {
+		t.Errorf("Snapshot file should be written: %s", err)
+	}
+	if err := run("foo", false); err != nil {
+		t.Errorf("Unexpected error on matching snapshot: %s", err)
+	}
+
+	err := run("bar", false)
+	if err == nil {
+		t.Fatalf("Expected snapshot mismatch error but got nil")
+	}
+	for _, line := range []string{`-           "foo": "foo"`, `+           "foo": "bar"`} {
+		if !strings.Contains(err.Error(), line) {
+			t.Errorf("Difference should contain %q, got %s", line, err.Error())
+		}
+	}
+}
+
+const multiScopeSnapshotTestVCL = `
+// @scope: recv,deliver
+sub test_scopes {
+  set req.http.Scope = "foo";
+  testing.snapshot("flow");
+}
+
+describe group {
+  // @scope: recv
+  sub test_scopes {
+    testing.snapshot("flow");
+  }
+}
+`
+
+func TestRunSnapshotKeys(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.vcl")
+	testFile := filepath.Join(dir, "main.test.vcl")
+	if err := os.WriteFile(main, []byte(fmt.Sprintf(snapshotMainVCL, "foo")), 0o644); err != nil {
+		t.Fatalf("Failed to write main VCL: %s", err)
+	}
+	if err := os.WriteFile(testFile, []byte(multiScopeSnapshotTestVCL), 0o644); err != nil {
+		t.Fatalf("Failed to write test VCL: %s", err)
+	}
+	resolvers, err := resolver.NewFileResolvers(main, nil)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %s", err)
+	}
+
+	for _, update := range []bool{true, false} {
+		c := &config.TestConfig{
+			Filter:          "*.test.vcl",
+			UpdateSnapshots: update,
+		}
+		factory, err := New(c, []context.Option{context.WithResolver(resolvers[0])}).Run(main)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		for _, c := range factory.Results[0].Cases {
+			if c.Error != nil {
+				t.Errorf("Unexpected error on %s [%s], update=%t: %s", c.Name, c.Scope, update, c.Error)
+			}
+		}
+	}
+
+	s, err := shared.LoadSnapshots(shared.SnapshotFile(testFile), false)
+	if err != nil {
+		t.Fatalf("Failed to load snapshots: %s", err)
+	}
+	// Snapshot which has the same name is stored for each test and scope
+	for _, test := range []string{"test_scopes", "group › test_scopes"} {
+		s.SetTest(test, "recv")
+		if _, err := s.Match("flow", nil); err == shared.ErrSnapshotNotFound {
+			t.Errorf("Snapshot of %s should be stored", test)
+		}
+	}
+	s.SetTest("test_scopes", "deliver")
+	if _, err := s.Match("flow", nil); err == shared.ErrSnapshotNotFound {
+		t.Errorf("Snapshot of deliver scope should be stored")
+	}
+}